@@ -0,0 +1,158 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ChromaticAdaptationMethod is the cone response model used to compute chromatic adaptation matrices
+type ChromaticAdaptationMethod uint8
+
+const (
+	AdaptationBradford ChromaticAdaptationMethod = iota
+	AdaptationVonKries
+	AdaptationCAT02
+)
+
+func (m ChromaticAdaptationMethod) String() string {
+	switch m {
+	case AdaptationBradford:
+		return "Bradford"
+	case AdaptationVonKries:
+		return "von Kries"
+	case AdaptationCAT02:
+		return "CAT02"
+	}
+	return fmt.Sprintf("Unknown (%d)", uint8(m))
+}
+
+var coneResponses = map[ChromaticAdaptationMethod][3][3]float64{
+	AdaptationBradford: {
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	},
+	// Hunt-Pointer-Estevez
+	AdaptationVonKries: {
+		{0.40024, 0.70760, -0.08081},
+		{-0.22630, 1.16532, 0.04570},
+		{0.0, 0.0, 0.91822},
+	},
+	AdaptationCAT02: {
+		{0.7328, 0.4296, -0.1624},
+		{-0.7036, 1.6975, 0.0061},
+		{0.0030, 0.0136, 0.9834},
+	},
+}
+
+// AdaptationMatrix computes the chromatic adaptation matrix from the source white point to the destination white point
+//
+// the resulting matrix can be applied to XYZ values using AdaptXYZ
+func (m ChromaticAdaptationMethod) AdaptationMatrix(src XYZNumber, dst XYZNumber) ([3][3]float64, error) {
+	cone, ok := coneResponses[m]
+	if !ok {
+		return [3][3]float64{}, fmt.Errorf("unknown chromatic adaptation method %d", uint8(m))
+	}
+	inverseCone, err := invert3x3(cone)
+	if err != nil {
+		return [3][3]float64{}, err
+	}
+	srcCone := apply3x3(cone, src.array())
+	dstCone := apply3x3(cone, dst.array())
+	var scale [3][3]float64
+	for i := 0; i < 3; i++ {
+		if srcCone[i] == 0 {
+			return [3][3]float64{}, errors.New("invalid source white point")
+		}
+		scale[i][i] = dstCone[i] / srcCone[i]
+	}
+	return multiply3x3(inverseCone, multiply3x3(scale, cone)), nil
+}
+
+// AdaptXYZ applies a chromatic adaptation matrix to an XYZ value
+func AdaptXYZ(matrix [3][3]float64, xyz XYZNumber) XYZNumber {
+	v := apply3x3(matrix, xyz.array())
+	return XYZNumber{X: v[0], Y: v[1], Z: v[2]}
+}
+
+// ChromaticAdaptation returns the chromatic adaptation matrix from the chad tag
+//
+// the matrix adapts from the actual (device) illumination to the PCS illuminant (D50)
+//
+// ok is false if the profile has no chad tag
+func (p *Profile) ChromaticAdaptation() (matrix [3][3]float64, ok bool, err error) {
+	tag, present := p.TagByHeader(TagHeaderChromaticAdaptationMatrix)
+	if !present || tag == nil {
+		return matrix, false, nil
+	}
+	val, err := tag.Value()
+	if err != nil {
+		return matrix, false, fmt.Errorf("failed to decode %s tag: %w", TagHeaderChromaticAdaptationMatrix, err)
+	}
+	values, isFloats := val.([]float64)
+	if !isFloats || len(values) != 9 {
+		return matrix, false, fmt.Errorf("%s tag is not a 3x3 matrix (got %T)", TagHeaderChromaticAdaptationMatrix, val)
+	}
+	for i, v := range values {
+		matrix[i/3][i%3] = v
+	}
+	return matrix, true, nil
+}
+
+// OriginalWhitePoint returns the media white point before chromatic adaptation to the PCS
+//
+// for profiles with a chad tag whose media white point has been adapted to the PCS illuminant (e.g. v4 display
+// profiles, where the wtpt tag is D50), the inverse chad matrix is applied - otherwise the media white point
+// is returned as is
+func (p *Profile) OriginalWhitePoint() (XYZNumber, error) {
+	wp, err := p.mediaWhitePoint()
+	if err != nil {
+		return XYZNumber{}, err
+	}
+	ill := p.pcsIlluminant()
+	const tolerance = 0.001
+	if math.Abs(wp.X-ill[0]) > tolerance || math.Abs(wp.Y-ill[1]) > tolerance || math.Abs(wp.Z-ill[2]) > tolerance {
+		// not adapted...
+		return wp, nil
+	}
+	inverse, ok, err := p.inverseChromaticAdaptation()
+	if err != nil || !ok {
+		return wp, err
+	}
+	return AdaptXYZ(inverse, wp), nil
+}
+
+// AdaptToPCS adapts XYZ values under the original (device) illumination to the PCS illuminant using the chad tag
+//
+// if the profile has no chad tag, the XYZ value is returned as is
+func (p *Profile) AdaptToPCS(xyz XYZNumber) (XYZNumber, error) {
+	matrix, ok, err := p.ChromaticAdaptation()
+	if err != nil || !ok {
+		return xyz, err
+	}
+	return AdaptXYZ(matrix, xyz), nil
+}
+
+// AdaptFromPCS adapts PCS XYZ values (D50) to the original (device) illumination using the inverse of the chad tag
+//
+// if the profile has no chad tag, the XYZ value is returned as is
+func (p *Profile) AdaptFromPCS(xyz XYZNumber) (XYZNumber, error) {
+	inverse, ok, err := p.inverseChromaticAdaptation()
+	if err != nil || !ok {
+		return xyz, err
+	}
+	return AdaptXYZ(inverse, xyz), nil
+}
+
+func (p *Profile) inverseChromaticAdaptation() ([3][3]float64, bool, error) {
+	matrix, ok, err := p.ChromaticAdaptation()
+	if err != nil || !ok {
+		return matrix, ok, err
+	}
+	inverse, err := invert3x3(matrix)
+	if err != nil {
+		return inverse, false, fmt.Errorf("invalid %s tag: %w", TagHeaderChromaticAdaptationMatrix, err)
+	}
+	return inverse, true, nil
+}
@@ -0,0 +1,172 @@
+package iccarus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestChromaticAdaptationMethod_String(t *testing.T) {
+	assert.Equal(t, "Bradford", AdaptationBradford.String())
+	assert.Equal(t, "von Kries", AdaptationVonKries.String())
+	assert.Equal(t, "CAT02", AdaptationCAT02.String())
+	assert.Equal(t, "Unknown (9)", ChromaticAdaptationMethod(9).String())
+}
+
+func TestChromaticAdaptationMethod_AdaptationMatrix(t *testing.T) {
+	t.Run("Bradford D65 to D50", func(t *testing.T) {
+		m, err := AdaptationBradford.AdaptationMatrix(D65, D50)
+		require.NoError(t, err)
+		expect := [3][3]float64{
+			{1.0478, 0.0229, -0.0502},
+			{0.0295, 0.9905, -0.0171},
+			{-0.0092, 0.0151, 0.7518},
+		}
+		for i := range expect {
+			for j := range expect[i] {
+				assert.InDelta(t, expect[i][j], m[i][j], 0.0001)
+			}
+		}
+	})
+	for _, method := range []ChromaticAdaptationMethod{AdaptationBradford, AdaptationVonKries, AdaptationCAT02} {
+		t.Run(method.String(), func(t *testing.T) {
+			m, err := method.AdaptationMatrix(D65, D50)
+			require.NoError(t, err)
+			// source white maps to destination white...
+			white := AdaptXYZ(m, D65)
+			assert.InDelta(t, D50.X, white.X, 0.000001)
+			assert.InDelta(t, D50.Y, white.Y, 0.000001)
+			assert.InDelta(t, D50.Z, white.Z, 0.000001)
+			// and back again...
+			back, err := method.AdaptationMatrix(D50, D65)
+			require.NoError(t, err)
+			white = AdaptXYZ(back, white)
+			assert.InDelta(t, D65.X, white.X, 0.000001)
+			assert.InDelta(t, D65.Y, white.Y, 0.000001)
+			assert.InDelta(t, D65.Z, white.Z, 0.000001)
+		})
+	}
+	t.Run("Unknown Method", func(t *testing.T) {
+		_, err := ChromaticAdaptationMethod(9).AdaptationMatrix(D65, D50)
+		assert.ErrorContains(t, err, "unknown chromatic adaptation method 9")
+	})
+	t.Run("Invalid Source White", func(t *testing.T) {
+		_, err := AdaptationVonKries.AdaptationMatrix(XYZNumber{}, D50)
+		assert.ErrorContains(t, err, "invalid source white point")
+	})
+}
+
+func TestProfile_ChromaticAdaptation(t *testing.T) {
+	p3 := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	t.Run("Success", func(t *testing.T) {
+		m, ok, err := p3.ChromaticAdaptation()
+		require.NoError(t, err)
+		require.True(t, ok)
+		bradford, err := AdaptationBradford.AdaptationMatrix(D65, D50)
+		require.NoError(t, err)
+		for i := range m {
+			for j := range m[i] {
+				assert.InDelta(t, bradford[i][j], m[i][j], 0.0002)
+			}
+		}
+	})
+	t.Run("Not Present", func(t *testing.T) {
+		_, ok, err := (&Profile{}).ChromaticAdaptation()
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("Fails to decode", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderChromaticAdaptationMatrix: {error: errors.New("foo")},
+			},
+		}
+		_, _, err := p.ChromaticAdaptation()
+		assert.ErrorContains(t, err, "failed to decode chad tag: foo")
+	})
+	t.Run("Not a 3x3", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderChromaticAdaptationMatrix: {value: []float64{1, 2, 3}},
+			},
+		}
+		_, _, err := p.ChromaticAdaptation()
+		assert.ErrorContains(t, err, "chad tag is not a 3x3 matrix")
+	})
+}
+
+func TestProfile_OriginalWhitePoint(t *testing.T) {
+	chad, err := AdaptationBradford.AdaptationMatrix(D65, D50)
+	require.NoError(t, err)
+	t.Run("Adapted", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderMediaWhitePointTag:        {value: []XYZNumber{D50}},
+				TagHeaderChromaticAdaptationMatrix: {value: append(append(chad[0][:], chad[1][:]...), chad[2][:]...)},
+			},
+		}
+		wp, err := p.OriginalWhitePoint()
+		require.NoError(t, err)
+		assert.InDelta(t, D65.X, wp.X, 0.000001)
+		assert.InDelta(t, D65.Y, wp.Y, 0.000001)
+		assert.InDelta(t, D65.Z, wp.Z, 0.000001)
+	})
+	t.Run("Not Adapted", func(t *testing.T) {
+		// this profile has a chad tag, but the wtpt is D65...
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		wp, err := p.OriginalWhitePoint()
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9505, wp.X, 0.0001)
+		assert.InDelta(t, 1.0, wp.Y, 0.0001)
+		assert.InDelta(t, 1.0891, wp.Z, 0.0001)
+	})
+	t.Run("No chad", func(t *testing.T) {
+		p := &Profile{Header: Header{Illuminant: [3]float64{D50.X, D50.Y, D50.Z}}}
+		wp, err := p.OriginalWhitePoint()
+		require.NoError(t, err)
+		assert.Equal(t, D50, wp)
+	})
+	t.Run("Invalid chad", func(t *testing.T) {
+		p := &Profile{
+			Header: Header{Illuminant: [3]float64{D50.X, D50.Y, D50.Z}},
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderChromaticAdaptationMatrix: {value: make([]float64, 9)},
+			},
+		}
+		_, err := p.OriginalWhitePoint()
+		assert.ErrorContains(t, err, "invalid chad tag: matrix is not invertible")
+	})
+	t.Run("Invalid wtpt", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderMediaWhitePointTag: {error: errors.New("foo")},
+			},
+		}
+		_, err := p.OriginalWhitePoint()
+		assert.ErrorContains(t, err, "failed to decode wtpt tag: foo")
+	})
+}
+
+func TestProfile_AdaptPCS(t *testing.T) {
+	p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	// D65 device white adapts to D50 PCS...
+	xyz, err := p.AdaptToPCS(D65)
+	require.NoError(t, err)
+	assert.InDelta(t, D50.X, xyz.X, 0.0002)
+	assert.InDelta(t, D50.Y, xyz.Y, 0.0002)
+	assert.InDelta(t, D50.Z, xyz.Z, 0.0002)
+	xyz, err = p.AdaptFromPCS(xyz)
+	require.NoError(t, err)
+	assert.InDelta(t, D65.X, xyz.X, 0.000001)
+	assert.InDelta(t, D65.Y, xyz.Y, 0.000001)
+	assert.InDelta(t, D65.Z, xyz.Z, 0.000001)
+	t.Run("No chad", func(t *testing.T) {
+		xyz, err := (&Profile{}).AdaptToPCS(D65)
+		require.NoError(t, err)
+		assert.Equal(t, D65, xyz)
+		xyz, err = (&Profile{}).AdaptFromPCS(D50)
+		require.NoError(t, err)
+		assert.Equal(t, D50, xyz)
+	})
+}
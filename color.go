@@ -0,0 +1,164 @@
+package iccarus
+
+import "math"
+
+// Lab represents a CIE L*a*b* color
+type Lab struct {
+	L, A, B float64
+}
+
+// LCh represents a CIE L*C*h° color (the cylindrical form of Lab - H is in degrees 0..360)
+type LCh struct {
+	L, C, H float64
+}
+
+// Luv represents a CIE L*u*v* color
+type Luv struct {
+	L, U, V float64
+}
+
+// XyY represents a CIE xyY color
+type XyY struct {
+	// X is the x chromaticity coordinate
+	X float64
+	// Y is the y chromaticity coordinate
+	Y float64
+	// Luminance is the Y tristimulus value
+	Luminance float64
+}
+
+// D50 is the ICC PCS illuminant white point
+var D50 = XYZNumber{X: d50[0], Y: d50[1], Z: d50[2]}
+
+// D65 is the CIE standard illuminant D65 white point
+var D65 = XYZNumber{X: 0.95047, Y: 1.0, Z: 1.08883}
+
+// ToLab converts XYZ to Lab relative to the given white point
+func (xyz XYZNumber) ToLab(white XYZNumber) Lab {
+	v := xyzToLab(xyz.array(), white.array())
+	return Lab{L: v[0], A: v[1], B: v[2]}
+}
+
+// ToLuv converts XYZ to Luv relative to the given white point
+func (xyz XYZNumber) ToLuv(white XYZNumber) Luv {
+	yr := xyz.Y / white.Y
+	var l float64
+	if yr > labEpsilon {
+		l = 116*math.Cbrt(yr) - 16
+	} else {
+		l = labKappa * yr
+	}
+	if l == 0 {
+		return Luv{}
+	}
+	u, v := xyz.uv()
+	un, vn := white.uv()
+	return Luv{L: l, U: 13 * l * (u - un), V: 13 * l * (v - vn)}
+}
+
+// ToXyY converts XYZ to xyY
+//
+// the white point provides the chromaticity for black (where x & y are otherwise undefined)
+func (xyz XYZNumber) ToXyY(white XYZNumber) XyY {
+	sum := xyz.X + xyz.Y + xyz.Z
+	if sum == 0 {
+		ws := white.X + white.Y + white.Z
+		if ws == 0 {
+			return XyY{}
+		}
+		return XyY{X: white.X / ws, Y: white.Y / ws}
+	}
+	return XyY{X: xyz.X / sum, Y: xyz.Y / sum, Luminance: xyz.Y}
+}
+
+// uv returns the u', v' chromaticity (CIE 1976 UCS)
+func (xyz XYZNumber) uv() (float64, float64) {
+	d := xyz.X + 15*xyz.Y + 3*xyz.Z
+	if d == 0 {
+		return 0, 0
+	}
+	return 4 * xyz.X / d, 9 * xyz.Y / d
+}
+
+func (xyz XYZNumber) array() [3]float64 {
+	return [3]float64{xyz.X, xyz.Y, xyz.Z}
+}
+
+// ToXYZ converts Lab to XYZ relative to the given white point
+func (lab Lab) ToXYZ(white XYZNumber) XYZNumber {
+	v := labToXYZ([3]float64{lab.L, lab.A, lab.B}, white.array())
+	return XYZNumber{X: v[0], Y: v[1], Z: v[2]}
+}
+
+// ToLCh converts Lab to LCh
+func (lab Lab) ToLCh() LCh {
+	return LCh{L: lab.L, C: math.Hypot(lab.A, lab.B), H: hueAngle(lab.B, lab.A)}
+}
+
+// ToLab converts LCh to Lab
+func (lch LCh) ToLab() Lab {
+	h := radians(lch.H)
+	return Lab{L: lch.L, A: lch.C * math.Cos(h), B: lch.C * math.Sin(h)}
+}
+
+// ToXYZ converts Luv to XYZ relative to the given white point
+func (luv Luv) ToXYZ(white XYZNumber) XYZNumber {
+	if luv.L <= 0 {
+		return XYZNumber{}
+	}
+	un, vn := white.uv()
+	u := luv.U/(13*luv.L) + un
+	v := luv.V/(13*luv.L) + vn
+	var y float64
+	if luv.L > labKappa*labEpsilon {
+		y = math.Pow((luv.L+16)/116, 3)
+	} else {
+		y = luv.L / labKappa
+	}
+	y *= white.Y
+	if v == 0 {
+		return XYZNumber{}
+	}
+	return XYZNumber{X: y * 9 * u / (4 * v), Y: y, Z: y * (12 - 3*u - 20*v) / (4 * v)}
+}
+
+// ToXYZ converts xyY to XYZ
+func (c XyY) ToXYZ() XYZNumber {
+	if c.Y == 0 {
+		return XYZNumber{}
+	}
+	return XYZNumber{X: c.X * c.Luminance / c.Y, Y: c.Luminance, Z: (1 - c.X - c.Y) * c.Luminance / c.Y}
+}
+
+// WhitePoint returns the PCS illuminant from the profile header (defaulting to D50 when not set)
+//
+// this is the default white point used by the Profile color conversion methods (e.g. Profile.XYZToLab)
+func (p *Profile) WhitePoint() XYZNumber {
+	ill := p.pcsIlluminant()
+	return XYZNumber{X: ill[0], Y: ill[1], Z: ill[2]}
+}
+
+// XYZToLab converts XYZ to Lab relative to the profile white point (see Profile.WhitePoint)
+func (p *Profile) XYZToLab(xyz XYZNumber) Lab {
+	return xyz.ToLab(p.WhitePoint())
+}
+
+// LabToXYZ converts Lab to XYZ relative to the profile white point (see Profile.WhitePoint)
+func (p *Profile) LabToXYZ(lab Lab) XYZNumber {
+	return lab.ToXYZ(p.WhitePoint())
+}
+
+// XYZToLuv converts XYZ to Luv relative to the profile white point (see Profile.WhitePoint)
+func (p *Profile) XYZToLuv(xyz XYZNumber) Luv {
+	return xyz.ToLuv(p.WhitePoint())
+}
+
+// LuvToXYZ converts Luv to XYZ relative to the profile white point (see Profile.WhitePoint)
+func (p *Profile) LuvToXYZ(luv Luv) XYZNumber {
+	return luv.ToXYZ(p.WhitePoint())
+}
+
+// XYZToXyY converts XYZ to xyY (using the profile white point for black - see Profile.WhitePoint)
+func (p *Profile) XYZToXyY(xyz XYZNumber) XyY {
+	return xyz.ToXyY(p.WhitePoint())
+}
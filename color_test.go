@@ -0,0 +1,91 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// sRGB red (D65)
+var testRedXYZ = XYZNumber{X: 0.412456, Y: 0.212673, Z: 0.019334}
+
+func TestXYZNumber_ToLab(t *testing.T) {
+	lab := testRedXYZ.ToLab(D65)
+	assert.InDelta(t, 53.24, lab.L, 0.01)
+	assert.InDelta(t, 80.09, lab.A, 0.01)
+	assert.InDelta(t, 67.20, lab.B, 0.01)
+	back := lab.ToXYZ(D65)
+	assert.InDelta(t, testRedXYZ.X, back.X, 0.000001)
+	assert.InDelta(t, testRedXYZ.Y, back.Y, 0.000001)
+	assert.InDelta(t, testRedXYZ.Z, back.Z, 0.000001)
+	white := D50.ToLab(D50)
+	assert.InDelta(t, 100.0, white.L, 0.000001)
+	assert.InDelta(t, 0.0, white.A, 0.000001)
+	assert.InDelta(t, 0.0, white.B, 0.000001)
+}
+
+func TestXYZNumber_ToLuv(t *testing.T) {
+	luv := testRedXYZ.ToLuv(D65)
+	assert.InDelta(t, 53.24, luv.L, 0.01)
+	assert.InDelta(t, 175.01, luv.U, 0.05)
+	assert.InDelta(t, 37.76, luv.V, 0.05)
+	back := luv.ToXYZ(D65)
+	assert.InDelta(t, testRedXYZ.X, back.X, 0.000001)
+	assert.InDelta(t, testRedXYZ.Y, back.Y, 0.000001)
+	assert.InDelta(t, testRedXYZ.Z, back.Z, 0.000001)
+	t.Run("Dark", func(t *testing.T) {
+		dark := XYZNumber{X: 0.001, Y: 0.002, Z: 0.003}
+		back := dark.ToLuv(D50).ToXYZ(D50)
+		assert.InDelta(t, dark.X, back.X, 0.000001)
+		assert.InDelta(t, dark.Y, back.Y, 0.000001)
+		assert.InDelta(t, dark.Z, back.Z, 0.000001)
+	})
+	t.Run("Black", func(t *testing.T) {
+		assert.Equal(t, Luv{}, XYZNumber{}.ToLuv(D65))
+		assert.Equal(t, XYZNumber{}, Luv{}.ToXYZ(D65))
+	})
+}
+
+func TestXYZNumber_ToXyY(t *testing.T) {
+	xyy := testRedXYZ.ToXyY(D65)
+	assert.InDelta(t, 0.64, xyy.X, 0.0001)
+	assert.InDelta(t, 0.33, xyy.Y, 0.0001)
+	assert.InDelta(t, testRedXYZ.Y, xyy.Luminance, 0.000001)
+	back := xyy.ToXYZ()
+	assert.InDelta(t, testRedXYZ.X, back.X, 0.000001)
+	assert.InDelta(t, testRedXYZ.Y, back.Y, 0.000001)
+	assert.InDelta(t, testRedXYZ.Z, back.Z, 0.000001)
+	t.Run("Black", func(t *testing.T) {
+		black := XYZNumber{}.ToXyY(D65)
+		assert.InDelta(t, 0.3127, black.X, 0.0001)
+		assert.InDelta(t, 0.3290, black.Y, 0.0001)
+		assert.Equal(t, 0.0, black.Luminance)
+		assert.Equal(t, XyY{}, XYZNumber{}.ToXyY(XYZNumber{}))
+		assert.Equal(t, XYZNumber{}, XyY{}.ToXYZ())
+	})
+}
+
+func TestLab_ToLCh(t *testing.T) {
+	lch := Lab{L: 50, A: 0, B: -20}.ToLCh()
+	assert.InDelta(t, 50.0, lch.L, 0.000001)
+	assert.InDelta(t, 20.0, lch.C, 0.000001)
+	assert.InDelta(t, 270.0, lch.H, 0.000001)
+	lab := LCh{L: 53.24, C: 104.55, H: 40.0}.ToLab()
+	back := lab.ToLCh()
+	assert.InDelta(t, 53.24, back.L, 0.000001)
+	assert.InDelta(t, 104.55, back.C, 0.000001)
+	assert.InDelta(t, 40.0, back.H, 0.000001)
+}
+
+func TestProfile_ColorConversions(t *testing.T) {
+	p := &Profile{}
+	assert.Equal(t, D50, p.WhitePoint())
+	p = &Profile{Header: Header{Illuminant: [3]float64{D65.X, D65.Y, D65.Z}}}
+	assert.Equal(t, D65, p.WhitePoint())
+	lab := p.XYZToLab(testRedXYZ)
+	assert.InDelta(t, 80.09, lab.A, 0.01)
+	assert.InDelta(t, testRedXYZ.X, p.LabToXYZ(lab).X, 0.000001)
+	luv := p.XYZToLuv(testRedXYZ)
+	assert.InDelta(t, 175.01, luv.U, 0.05)
+	assert.InDelta(t, testRedXYZ.X, p.LuvToXYZ(luv).X, 0.000001)
+	assert.InDelta(t, 0.64, p.XYZToXyY(testRedXYZ).X, 0.0001)
+}
@@ -20,61 +20,225 @@ type ChannelTransformer interface {
 	Transform(inputs ...float64) (outputs []float64, err error)
 }
 
+// shaperTransform is a transform that works in both directions (MatrixShaper or GrayTRC)
+type shaperTransform interface {
+	ToCIEXYZ
+	FromCIEXYZ
+}
+
 var _ ToCIEXYZ = (*Profile)(nil)
 var _ FromCIEXYZ = (*Profile)(nil)
 
+var (
+	aToBTags = [3]TagHeaderName{TagHeaderAToB0, TagHeaderAToB1, TagHeaderAToB2}
+	bToATags = [3]TagHeaderName{TagHeaderBToA0, TagHeaderBToA1, TagHeaderBToA2}
+)
+
+// ToCIEXYZ converts device channels to CIE XYZ using the perceptual rendering intent
 func (p *Profile) ToCIEXYZ(channels ...float64) ([]float64, error) {
-	a2bTag, err := p.findA2B0()
+	return p.ToCIEXYZIntent(IntentPerceptual, channels...)
+}
+
+// FromCIEXYZ converts CIE XYZ to device channels using the perceptual rendering intent
+func (p *Profile) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	return p.FromCIEXYZIntent(IntentPerceptual, channels...)
+}
+
+// ToCIEXYZIntent converts device channels to CIE XYZ using the specified rendering intent
+//
+// the A2Bx tag for the intent is used - falling back to A2B0 and then to matrix/TRC (or gray TRC)
+//
+// the PCS encoding of the profile is decoded - so the result is always actual CIE XYZ (D50, Y of 1.0 for white)
+// even for profiles with a Lab PCS
+func (p *Profile) ToCIEXYZIntent(intent RenderingIntent, channels ...float64) ([]float64, error) {
+	xyz, err := p.toXYZ(intent, channels...)
 	if err != nil {
 		return nil, err
 	}
-	return a2bTag.ToCIEXYZ(channels...)
+	return xyz[:], nil
 }
 
-func (p *Profile) FromCIEXYZ(channels ...float64) ([]float64, error) {
-	b2aTag, err := p.findB2A0()
+// FromCIEXYZIntent converts CIE XYZ to device channels using the specified rendering intent
+//
+// the B2Ax tag for the intent is used - falling back to B2A0 and then to matrix/TRC (or gray TRC)
+//
+// the CIE XYZ channels are encoded into the PCS encoding of the profile (Lab or XYZ)
+func (p *Profile) FromCIEXYZIntent(intent RenderingIntent, channels ...float64) ([]float64, error) {
+	return p.fromXYZ(intent, channels)
+}
+
+// ToCIELab converts device channels to CIE Lab (relative to the PCS illuminant) using the perceptual rendering intent
+func (p *Profile) ToCIELab(channels ...float64) ([]float64, error) {
+	return p.ToCIELabIntent(IntentPerceptual, channels...)
+}
+
+// FromCIELab converts CIE Lab (relative to the PCS illuminant) to device channels using the perceptual rendering intent
+func (p *Profile) FromCIELab(channels ...float64) ([]float64, error) {
+	return p.FromCIELabIntent(IntentPerceptual, channels...)
+}
+
+// ToCIELabIntent converts device channels to CIE Lab (relative to the PCS illuminant) using the specified rendering intent
+func (p *Profile) ToCIELabIntent(intent RenderingIntent, channels ...float64) ([]float64, error) {
+	xyz, err := p.toXYZ(intent, channels...)
+	if err != nil {
+		return nil, err
+	}
+	lab := xyzToLab(xyz, p.pcsIlluminant())
+	return lab[:], nil
+}
+
+// FromCIELabIntent converts CIE Lab (relative to the PCS illuminant) to device channels using the specified rendering intent
+func (p *Profile) FromCIELabIntent(intent RenderingIntent, channels ...float64) ([]float64, error) {
+	if len(channels) == 3 {
+		xyz := labToXYZ([3]float64{channels[0], channels[1], channels[2]}, p.pcsIlluminant())
+		channels = xyz[:]
+	}
+	return p.fromXYZ(intent, channels)
+}
+
+// toXYZ converts device channels to actual CIE XYZ (decoding the PCS encoding) using the specified rendering intent
+func (p *Profile) toXYZ(intent RenderingIntent, channels ...float64) ([3]float64, error) {
+	a2bTag, err := p.findA2B(intent)
+	if err != nil {
+		return [3]float64{}, err
+	}
+	result, err := a2bTag.ToCIEXYZ(channels...)
+	if err != nil {
+		return [3]float64{}, err
+	} else if len(result) != 3 {
+		return [3]float64{}, fmt.Errorf("expected 3 PCS channels, got %d", len(result))
+	}
+	xyz := decodePCS(p.pcsEncodingOf(a2bTag), result, p.pcsIlluminant())
+	if intent == IntentAbsoluteColorimetric {
+		scale, err := p.absoluteScale()
+		if err != nil {
+			return [3]float64{}, err
+		}
+		xyz = [3]float64{xyz[0] * scale[0], xyz[1] * scale[1], xyz[2] * scale[2]}
+	}
+	return xyz, nil
+}
+
+// fromXYZ converts actual CIE XYZ to device channels (encoding to the PCS encoding) using the specified rendering intent
+func (p *Profile) fromXYZ(intent RenderingIntent, channels []float64) ([]float64, error) {
+	b2aTag, err := p.findB2A(intent)
 	if err != nil {
 		return nil, err
+	} else if len(channels) != 3 {
+		return nil, fmt.Errorf("expected 3 input channels, got %d", len(channels))
+	}
+	xyz := [3]float64{channels[0], channels[1], channels[2]}
+	if intent == IntentAbsoluteColorimetric {
+		scale, err := p.absoluteScale()
+		if err != nil {
+			return nil, err
+		}
+		xyz = [3]float64{xyz[0] / scale[0], xyz[1] / scale[1], xyz[2] / scale[2]}
 	}
-	return b2aTag.FromCIEXYZ(channels...)
+	return b2aTag.FromCIEXYZ(encodePCS(p.pcsEncodingOf(b2aTag), xyz, p.pcsIlluminant())...)
 }
 
-func (p *Profile) findA2B0() (ToCIEXYZ, error) {
-	if p.a2b0 != nil {
-		return p.a2b0, nil
+func (p *Profile) findA2B(intent RenderingIntent) (ToCIEXYZ, error) {
+	idx, err := intent.lutIndex()
+	if err != nil {
+		return nil, err
 	}
-	tag, ok := p.TagByHeader(TagHeaderAToB0)
-	if !ok || tag == nil {
-		return nil, errors.New("A2B0 tag not found")
+	if p.a2b[idx] != nil {
+		return p.a2b[idx], nil
 	}
-	val, err := tag.Value()
+	name, val, err := p.findIntentTag(aToBTags, idx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode A2B0 tag: %w", err)
+		return nil, err
+	} else if name == "" {
+		// fallback to matrix/TRC or gray TRC...
+		if shaper, err := p.findShaper(); err != nil {
+			return nil, err
+		} else if shaper != nil {
+			p.a2b[idx] = shaper
+			return shaper, nil
+		}
+		return nil, fmt.Errorf("%s tag not found", aToBTags[idx])
 	}
-	modular, ok := val.(ToCIEXYZ)
+	lut, ok := val.(ToCIEXYZ)
 	if !ok {
-		return nil, fmt.Errorf("A2B0 tag does not implement interface ToCIEXYZ (got %T)", val)
+		return nil, fmt.Errorf("%s tag does not implement interface ToCIEXYZ (got %T)", name, val)
 	}
-	p.a2b0 = modular
-	return modular, nil
+	p.a2b[idx] = lut
+	return lut, nil
 }
 
-func (p *Profile) findB2A0() (FromCIEXYZ, error) {
-	if p.b2a0 != nil {
-		return p.b2a0, nil
+func (p *Profile) findB2A(intent RenderingIntent) (FromCIEXYZ, error) {
+	idx, err := intent.lutIndex()
+	if err != nil {
+		return nil, err
 	}
-	tag, ok := p.TagByHeader(TagHeaderBToA0)
-	if !ok || tag == nil {
-		return nil, errors.New("B2A0 tag not found")
+	if p.b2a[idx] != nil {
+		return p.b2a[idx], nil
 	}
-	val, err := tag.Value()
+	name, val, err := p.findIntentTag(bToATags, idx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode B2A0 tag: %w", err)
+		return nil, err
+	} else if name == "" {
+		// fallback to matrix/TRC or gray TRC...
+		if shaper, err := p.findShaper(); err != nil {
+			return nil, err
+		} else if shaper != nil {
+			p.b2a[idx] = shaper
+			return shaper, nil
+		}
+		return nil, fmt.Errorf("%s tag not found", bToATags[idx])
 	}
-	modular, ok := val.(FromCIEXYZ)
+	lut, ok := val.(FromCIEXYZ)
 	if !ok {
-		return nil, fmt.Errorf("B2A0 tag does not implement interface FromCIEXYZ (got %T)", val)
+		return nil, fmt.Errorf("%s tag does not implement interface FromCIEXYZ (got %T)", name, val)
+	}
+	p.b2a[idx] = lut
+	return lut, nil
+}
+
+// findIntentTag finds and decodes the tag for the intent index - falling back to the perceptual (index 0) tag
+//
+// returns an empty name if neither tag is present
+func (p *Profile) findIntentTag(names [3]TagHeaderName, idx int) (TagHeaderName, any, error) {
+	for _, name := range []TagHeaderName{names[idx], names[0]} {
+		if tag, ok := p.TagByHeader(name); ok && tag != nil {
+			val, err := tag.Value()
+			if err != nil {
+				return name, nil, fmt.Errorf("failed to decode %s tag: %w", name, err)
+			}
+			return name, val, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// absoluteScale returns the per-channel XYZ scaling from relative to absolute colorimetric
+// (i.e. media white point / PCS illuminant)
+func (p *Profile) absoluteScale() ([3]float64, error) {
+	wp, err := p.mediaWhitePoint()
+	if err != nil {
+		return [3]float64{}, err
+	}
+	ill := p.Header.Illuminant
+	if ill[0] <= 0 || ill[1] <= 0 || ill[2] <= 0 {
+		return [3]float64{}, errors.New("invalid PCS illuminant")
+	}
+	return [3]float64{wp.X / ill[0], wp.Y / ill[1], wp.Z / ill[2]}, nil
+}
+
+// findShaper finds the fallback transform for profiles without A2B/B2A tags
+//
+// matrix/TRC (RGB) is tried first, then gray TRC (monochrome) - returns nil (with no error) if neither is present
+func (p *Profile) findShaper() (shaperTransform, error) {
+	if shaper, err := p.findMatrixShaper(); err != nil {
+		return nil, err
+	} else if shaper != nil {
+		return shaper, nil
+	}
+	if gray, err := p.findGrayTRC(); err != nil {
+		return nil, err
+	} else if gray != nil {
+		return gray, nil
 	}
-	p.b2a0 = modular
-	return modular, nil
+	return nil, nil
 }
@@ -62,7 +62,7 @@ func TestProfile_ToCIEXYZ_Errors(t *testing.T) {
 	})
 	t.Run("Tag incorrect number of channels (cached)", func(t *testing.T) {
 		p := &Profile{
-			a2b0: &ModularTag{InputChannels: 3},
+			a2b: [3]ToCIEXYZ{&ModularTag{InputChannels: 3}},
 		}
 		_, err := p.ToCIEXYZ()
 		require.Error(t, err)
@@ -125,10 +125,243 @@ func TestProfile_FromCIEXYZ_Errors(t *testing.T) {
 	})
 	t.Run("Tag incorrect number of channels (cached)", func(t *testing.T) {
 		p := &Profile{
-			b2a0: &ModularTag{InputChannels: 3},
+			b2a: [3]FromCIEXYZ{&ModularTag{InputChannels: 3}},
 		}
 		_, err := p.FromCIEXYZ()
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "expected 3 input channels, got 0")
 	})
 }
+
+// mockPCSTransform returns a fixed result in both directions (and records the inputs)
+type mockPCSTransform struct {
+	result []float64
+	inputs []float64
+}
+
+func (m *mockPCSTransform) ToCIEXYZ(channels ...float64) ([]float64, error) {
+	m.inputs = channels
+	return m.result, nil
+}
+
+func (m *mockPCSTransform) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	m.inputs = channels
+	return m.result, nil
+}
+
+func TestProfile_ToCIEXYZIntent(t *testing.T) {
+	// LUT tag results are in the normalized PCS encoding...
+	a2b0 := &mockPCSTransform{result: encodePCS(pcsXYZNormalized, [3]float64{0.0, 0.0, 0.0}, d50)}
+	a2b1 := &mockPCSTransform{result: encodePCS(pcsXYZNormalized, [3]float64{0.1, 0.1, 0.1}, d50)}
+	a2b2 := &mockPCSTransform{result: encodePCS(pcsXYZNormalized, [3]float64{0.2, 0.2, 0.2}, d50)}
+	t.Run("Selects tag by intent", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderAToB0: {value: a2b0},
+				TagHeaderAToB1: {value: a2b1},
+				TagHeaderAToB2: {value: a2b2},
+			},
+		}
+		for intent, expect := range map[RenderingIntent]float64{IntentPerceptual: 0.0, IntentRelativeColorimetric: 0.1, IntentSaturation: 0.2} {
+			xyz, err := p.ToCIEXYZIntent(intent, 1, 1, 1)
+			require.NoError(t, err)
+			require.Len(t, xyz, 3)
+			for _, v := range xyz {
+				assert.InDelta(t, expect, v, 0.000001)
+			}
+		}
+		assert.Same(t, a2b1, p.a2b[1])
+	})
+	t.Run("Falls back to A2B0", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderAToB0: {value: a2b0},
+			},
+		}
+		xyz, err := p.ToCIEXYZIntent(IntentSaturation, 1, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.0, 0.0, 0.0}, xyz)
+		assert.Same(t, a2b0, p.a2b[2])
+	})
+	t.Run("Falls back to matrix/TRC", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		rel, err := p.ToCIEXYZIntent(IntentRelativeColorimetric, 0.2, 0.4, 0.6)
+		require.NoError(t, err)
+		perc, err := p.ToCIEXYZIntent(IntentPerceptual, 0.2, 0.4, 0.6)
+		require.NoError(t, err)
+		assert.Equal(t, perc, rel)
+	})
+	t.Run("Absolute colorimetric (XYZ PCS)", func(t *testing.T) {
+		white := &mockPCSTransform{result: encodePCS(pcsXYZNormalized, d50, d50)}
+		p := &Profile{
+			Header: Header{PCS: "XYZ", Illuminant: [3]float64{0.9642, 1.0, 0.8249}},
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderAToB1:              {value: white},
+				TagHeaderMediaWhitePointTag: {value: []XYZNumber{{X: 0.9, Y: 0.95, Z: 0.8}}},
+			},
+		}
+		xyz, err := p.ToCIEXYZIntent(IntentAbsoluteColorimetric, 1, 1, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9, xyz[0], 0.0001)
+		assert.InDelta(t, 0.95, xyz[1], 0.0001)
+		assert.InDelta(t, 0.8, xyz[2], 0.0001)
+	})
+	t.Run("Absolute colorimetric (Lab PCS)", func(t *testing.T) {
+		p := testProfile(t, "default/ISOcoated_v2_300_eci.icc")
+		rel, err := p.ToCIEXYZIntent(IntentRelativeColorimetric, 0, 0, 0, 0)
+		require.NoError(t, err)
+		abs, err := p.ToCIEXYZIntent(IntentAbsoluteColorimetric, 0, 0, 0, 0)
+		require.NoError(t, err)
+		// paper white is darker than the PCS illuminant...
+		assert.Less(t, abs[0], rel[0])
+		// and round trips back to paper...
+		cmyk, err := p.FromCIEXYZIntent(IntentAbsoluteColorimetric, abs...)
+		require.NoError(t, err)
+		require.Len(t, cmyk, 4)
+		for _, v := range cmyk {
+			assert.InDelta(t, 0.0, v, 0.001)
+		}
+	})
+	t.Run("Absolute colorimetric invalid illuminant", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderAToB1: {value: a2b1},
+			},
+		}
+		_, err := p.ToCIEXYZIntent(IntentAbsoluteColorimetric, 1, 1, 1)
+		assert.ErrorContains(t, err, "invalid PCS illuminant")
+	})
+	t.Run("Unknown intent", func(t *testing.T) {
+		p := &Profile{}
+		_, err := p.ToCIEXYZIntent(RenderingIntent(7), 1, 1, 1)
+		assert.ErrorContains(t, err, "unknown rendering intent 7")
+	})
+	t.Run("Tag Not Found", func(t *testing.T) {
+		p := &Profile{}
+		_, err := p.ToCIEXYZIntent(IntentRelativeColorimetric, 1, 1, 1)
+		assert.ErrorContains(t, err, "A2B1 tag not found")
+	})
+}
+
+func TestProfile_FromCIEXYZIntent(t *testing.T) {
+	b2a0 := &mockPCSTransform{result: []float64{0.0, 0.0, 0.0}}
+	b2a1 := &mockPCSTransform{result: []float64{0.1, 0.1, 0.1}}
+	b2a2 := &mockPCSTransform{result: []float64{0.2, 0.2, 0.2}}
+	t.Run("Selects tag by intent", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderBToA0: {value: b2a0},
+				TagHeaderBToA1: {value: b2a1},
+				TagHeaderBToA2: {value: b2a2},
+			},
+		}
+		for intent, expect := range map[RenderingIntent]float64{IntentPerceptual: 0.0, IntentRelativeColorimetric: 0.1, IntentSaturation: 0.2} {
+			rgb, err := p.FromCIEXYZIntent(intent, 1, 1, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []float64{expect, expect, expect}, rgb)
+		}
+		assert.Same(t, b2a2, p.b2a[2])
+	})
+	t.Run("Falls back to B2A0", func(t *testing.T) {
+		p := &Profile{
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderBToA0: {value: b2a0},
+			},
+		}
+		rgb, err := p.FromCIEXYZIntent(IntentRelativeColorimetric, 1, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.0, 0.0, 0.0}, rgb)
+	})
+	t.Run("Absolute colorimetric (XYZ PCS)", func(t *testing.T) {
+		mock := &mockPCSTransform{result: []float64{1, 1, 1}}
+		p := &Profile{
+			Header: Header{PCS: "XYZ", Illuminant: [3]float64{0.9642, 1.0, 0.8249}},
+			tagsByHeader: map[TagHeaderName]*Tag{
+				TagHeaderBToA1:              {value: mock},
+				TagHeaderMediaWhitePointTag: {value: []XYZNumber{{X: 0.9, Y: 0.95, Z: 0.8}}},
+			},
+		}
+		_, err := p.FromCIEXYZIntent(IntentAbsoluteColorimetric, 0.9, 0.95, 0.8)
+		require.NoError(t, err)
+		// media white is scaled to PCS illuminant (and encoded) before the B2A1 tag...
+		require.Len(t, mock.inputs, 3)
+		expect := encodePCS(pcsXYZNormalized, d50, d50)
+		assert.InDelta(t, expect[0], mock.inputs[0], 0.0001)
+		assert.InDelta(t, expect[1], mock.inputs[1], 0.0001)
+		assert.InDelta(t, expect[2], mock.inputs[2], 0.0001)
+	})
+	t.Run("Unknown intent", func(t *testing.T) {
+		p := &Profile{}
+		_, err := p.FromCIEXYZIntent(RenderingIntent(7), 1, 1, 1)
+		assert.ErrorContains(t, err, "unknown rendering intent 7")
+	})
+	t.Run("Tag Not Found", func(t *testing.T) {
+		p := &Profile{}
+		_, err := p.FromCIEXYZIntent(IntentSaturation, 1, 1, 1)
+		assert.ErrorContains(t, err, "B2A2 tag not found")
+	})
+}
+
+func TestProfile_PCSDecoding(t *testing.T) {
+	t.Run("Lab PCS (legacy 16-bit)", func(t *testing.T) {
+		p := testProfile(t, "default/ISOcoated_v2_300_eci.icc")
+		// relative colorimetric paper white is the PCS illuminant...
+		xyz, err := p.ToCIEXYZIntent(IntentRelativeColorimetric, 0, 0, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, xyz, 3)
+		assert.InDelta(t, 0.9642, xyz[0], 0.0001)
+		assert.InDelta(t, 1.0, xyz[1], 0.0001)
+		assert.InDelta(t, 0.8249, xyz[2], 0.0001)
+		lab, err := p.ToCIELabIntent(IntentRelativeColorimetric, 0, 0, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, lab, 3)
+		assert.InDelta(t, 100.0, lab[0], 0.001)
+		assert.InDelta(t, 0.0, lab[1], 0.001)
+		assert.InDelta(t, 0.0, lab[2], 0.001)
+		// cyan ink...
+		lab, err = p.ToCIELabIntent(IntentRelativeColorimetric, 1, 0, 0, 0)
+		require.NoError(t, err)
+		assert.InDelta(t, 58.17, lab[0], 0.01)
+		assert.InDelta(t, -38.67, lab[1], 0.01)
+		assert.InDelta(t, -50.29, lab[2], 0.01)
+		cmyk, err := p.FromCIELabIntent(IntentRelativeColorimetric, lab...)
+		require.NoError(t, err)
+		require.Len(t, cmyk, 4)
+		assert.InDelta(t, 1.0, cmyk[0], 0.01)
+		assert.InDelta(t, 0.0, cmyk[1], 0.01)
+		assert.InDelta(t, 0.0, cmyk[2], 0.01)
+		assert.InDelta(t, 0.0, cmyk[3], 0.01)
+	})
+	t.Run("XYZ PCS", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		lab, err := p.ToCIELab(1, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, lab, 3)
+		assert.InDelta(t, 100.0, lab[0], 0.01)
+		assert.InDelta(t, 0.0, lab[1], 0.01)
+		assert.InDelta(t, 0.0, lab[2], 0.01)
+		lab, err = p.ToCIELab(1, 0, 0)
+		require.NoError(t, err)
+		rgb, err := p.FromCIELab(lab...)
+		require.NoError(t, err)
+		require.Len(t, rgb, 3)
+		assert.InDelta(t, 1.0, rgb[0], 0.0001)
+		assert.InDelta(t, 0.0, rgb[1], 0.0001)
+		assert.InDelta(t, 0.0, rgb[2], 0.0001)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		p := &Profile{}
+		_, err := p.ToCIELab(1, 1, 1)
+		assert.ErrorContains(t, err, "A2B0 tag not found")
+		_, err = p.FromCIELab(1, 1, 1)
+		assert.ErrorContains(t, err, "B2A0 tag not found")
+		p = &Profile{
+			a2b: [3]ToCIEXYZ{&mockPCSTransform{result: []float64{1, 1}}},
+			b2a: [3]FromCIEXYZ{&mockPCSTransform{result: []float64{1, 1}}},
+		}
+		_, err = p.ToCIELab(1, 1, 1)
+		assert.ErrorContains(t, err, "expected 3 PCS channels, got 2")
+		_, err = p.FromCIELab(1, 1)
+		assert.ErrorContains(t, err, "expected 3 input channels, got 2")
+	})
+}
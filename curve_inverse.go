@@ -0,0 +1,91 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// inverseCurve is a ChannelTransformer that inverts a (monotonic) single channel curve
+//
+// the inverse is found numerically (by bisection) so works for any curve type
+type inverseCurve struct {
+	curve      ChannelTransformer
+	lo, hi     float64
+	increasing bool
+}
+
+var _ ChannelTransformer = (*inverseCurve)(nil)
+
+// inverseCurveIterations gives a precision of better than 1/2^32
+const inverseCurveIterations = 32
+
+func invertCurve(curve ChannelTransformer) (ChannelTransformer, error) {
+	if curve == nil {
+		return nil, errors.New("cannot invert nil curve")
+	}
+	switch c := curve.(type) {
+	case *CurveTag:
+		switch c.Type {
+		case CurveTypeIdentity:
+			return c, nil
+		case CurveTypeGamma:
+			if c.Gamma == 0 {
+				return nil, errors.New("cannot invert curve with zero gamma")
+			}
+			return &CurveTag{Type: CurveTypeGamma, Gamma: 1 / c.Gamma}, nil
+		}
+	case *ParametricCurveTag:
+		if c.FunctionType == SimpleGammaFunction && len(c.Parameters) == 1 && c.Parameters[0] != 0 {
+			return &ParametricCurveTag{FunctionType: SimpleGammaFunction, Parameters: []float64{1 / c.Parameters[0]}}, nil
+		}
+	}
+	lo, err := curve.Transform(0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot invert curve: %w", err)
+	}
+	hi, err := curve.Transform(1)
+	if err != nil {
+		return nil, fmt.Errorf("cannot invert curve: %w", err)
+	}
+	return &inverseCurve{
+		curve:      curve,
+		lo:         lo[0],
+		hi:         hi[0],
+		increasing: hi[0] >= lo[0],
+	}, nil
+}
+
+func (c *inverseCurve) Transform(inputs ...float64) ([]float64, error) {
+	if len(inputs) != 1 {
+		return nil, fmt.Errorf("inverse curve expects 1 input, got %d", len(inputs))
+	}
+	y := inputs[0]
+	// clamp to the range of the curve...
+	if c.increasing {
+		if y <= c.lo {
+			return []float64{0}, nil
+		} else if y >= c.hi {
+			return []float64{1}, nil
+		}
+	} else {
+		if y >= c.lo {
+			return []float64{0}, nil
+		} else if y <= c.hi {
+			return []float64{1}, nil
+		}
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < inverseCurveIterations; i++ {
+		mid := (lo + hi) / 2
+		v, err := c.curve.Transform(mid)
+		if err != nil {
+			return nil, err
+		}
+		if (v[0] < y) == c.increasing {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return []float64{(lo + hi) / 2}, nil
+}
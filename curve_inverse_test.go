@@ -0,0 +1,92 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInvertCurve(t *testing.T) {
+	t.Run("Identity", func(t *testing.T) {
+		c := &CurveTag{Type: CurveTypeIdentity}
+		inv, err := invertCurve(c)
+		require.NoError(t, err)
+		assert.Equal(t, c, inv)
+	})
+	t.Run("Gamma", func(t *testing.T) {
+		inv, err := invertCurve(&CurveTag{Type: CurveTypeGamma, Gamma: 2.0})
+		require.NoError(t, err)
+		out, err := inv.Transform(0.25)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, out[0], 0.000001)
+	})
+	t.Run("Zero Gamma", func(t *testing.T) {
+		_, err := invertCurve(&CurveTag{Type: CurveTypeGamma})
+		assert.ErrorContains(t, err, "zero gamma")
+	})
+	t.Run("Parametric Simple Gamma", func(t *testing.T) {
+		inv, err := invertCurve(&ParametricCurveTag{FunctionType: SimpleGammaFunction, Parameters: []float64{2.0}})
+		require.NoError(t, err)
+		out, err := inv.Transform(0.25)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, out[0], 0.000001)
+	})
+	t.Run("Parametric sRGB", func(t *testing.T) {
+		curve := &ParametricCurveTag{
+			FunctionType: SplitFunction,
+			Parameters:   []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045},
+		}
+		inv, err := invertCurve(curve)
+		require.NoError(t, err)
+		for _, x := range []float64{0, 0.01, 0.04045, 0.2, 0.5, 0.8, 1} {
+			y, err := curve.Transform(x)
+			require.NoError(t, err)
+			out, err := inv.Transform(y...)
+			require.NoError(t, err)
+			assert.InDelta(t, x, out[0], 0.000001)
+		}
+	})
+	t.Run("Points", func(t *testing.T) {
+		curve := &CurveTag{Type: CurveTypePoints, Points: []uint16{0, 16384, 65535}}
+		inv, err := invertCurve(curve)
+		require.NoError(t, err)
+		out, err := inv.Transform(16384.0 / 65535.0)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, out[0], 0.000001)
+		// out of range clamps...
+		out, err = inv.Transform(-1)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, out[0])
+		out, err = inv.Transform(2)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, out[0])
+	})
+	t.Run("Decreasing", func(t *testing.T) {
+		curve := &CurveTag{Type: CurveTypePoints, Points: []uint16{65535, 0}}
+		inv, err := invertCurve(curve)
+		require.NoError(t, err)
+		out, err := inv.Transform(0.25)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.75, out[0], 0.000001)
+		out, err = inv.Transform(2)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, out[0])
+		out, err = inv.Transform(-1)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, out[0])
+	})
+	t.Run("Nil", func(t *testing.T) {
+		_, err := invertCurve(nil)
+		assert.ErrorContains(t, err, "cannot invert nil curve")
+	})
+	t.Run("Failing Curve", func(t *testing.T) {
+		_, err := invertCurve(&mockFailTransformer{})
+		assert.ErrorContains(t, err, "cannot invert curve")
+	})
+	t.Run("Wrong Input Count", func(t *testing.T) {
+		inv, err := invertCurve(&CurveTag{Type: CurveTypePoints, Points: []uint16{0, 65535}})
+		require.NoError(t, err)
+		_, err = inv.Transform(0.1, 0.2)
+		assert.ErrorContains(t, err, "inverse curve expects 1 input")
+	})
+}
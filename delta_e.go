@@ -0,0 +1,142 @@
+package iccarus
+
+import "math"
+
+// DeltaE94Application is the application (weighting) used for DeltaE94
+type DeltaE94Application uint8
+
+const (
+	DeltaE94GraphicArts DeltaE94Application = iota
+	DeltaE94Textiles
+)
+
+// DeltaE76 computes the CIE76 color difference (euclidean distance in Lab)
+func DeltaE76(reference Lab, sample Lab) float64 {
+	dl := reference.L - sample.L
+	da := reference.A - sample.A
+	db := reference.B - sample.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// DeltaE94 computes the CIE94 color difference
+//
+// note that CIE94 is not symmetric - the chroma weighting is based on the reference color
+func DeltaE94(reference Lab, sample Lab, application DeltaE94Application) float64 {
+	kL, k1, k2 := 1.0, 0.045, 0.015
+	if application == DeltaE94Textiles {
+		kL, k1, k2 = 2.0, 0.048, 0.014
+	}
+	c1 := math.Hypot(reference.A, reference.B)
+	c2 := math.Hypot(sample.A, sample.B)
+	dl := reference.L - sample.L
+	dc := c1 - c2
+	da := reference.A - sample.A
+	db := reference.B - sample.B
+	dh2 := math.Max(0, da*da+db*db-dc*dc)
+	sc := 1 + k1*c1
+	sh := 1 + k2*c1
+	return math.Sqrt(sq(dl/kL) + sq(dc/sc) + dh2/sq(sh))
+}
+
+// DeltaE2000 computes the CIEDE2000 color difference (with kL, kC & kH of 1)
+func DeltaE2000(reference Lab, sample Lab) float64 {
+	const pow25to7 = 6103515625.0 // 25^7
+	c1 := math.Hypot(reference.A, reference.B)
+	c2 := math.Hypot(sample.A, sample.B)
+	cBar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+pow25to7)))
+	a1 := (1 + g) * reference.A
+	a2 := (1 + g) * sample.A
+	c1p := math.Hypot(a1, reference.B)
+	c2p := math.Hypot(a2, sample.B)
+	h1p := hueAngle(reference.B, a1)
+	h2p := hueAngle(sample.B, a2)
+	dLp := sample.L - reference.L
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(radians(dhp/2))
+	lBarP := (reference.L + sample.L) / 2
+	cBarP := (c1p + c2p) / 2
+	hBarP := h1p + h2p
+	if c1p*c2p != 0 {
+		if math.Abs(h1p-h2p) > 180 {
+			if hBarP < 360 {
+				hBarP += 360
+			} else {
+				hBarP -= 360
+			}
+		}
+		hBarP /= 2
+	}
+	t := 1 - 0.17*math.Cos(radians(hBarP-30)) +
+		0.24*math.Cos(radians(2*hBarP)) +
+		0.32*math.Cos(radians(3*hBarP+6)) -
+		0.20*math.Cos(radians(4*hBarP-63))
+	dTheta := 30 * math.Exp(-sq((hBarP-275)/25))
+	cBarP7 := math.Pow(cBarP, 7)
+	rc := 2 * math.Sqrt(cBarP7/(cBarP7+pow25to7))
+	sl := 1 + (0.015*sq(lBarP-50))/math.Sqrt(20+sq(lBarP-50))
+	sc := 1 + 0.045*cBarP
+	sh := 1 + 0.015*cBarP*t
+	rt := -math.Sin(radians(2*dTheta)) * rc
+	return math.Sqrt(sq(dLp/sl) + sq(dCp/sc) + sq(dHp/sh) + rt*(dCp/sc)*(dHp/sh))
+}
+
+// DeltaECMC computes the CMC l:c color difference
+//
+// commonly used weightings are 2:1 (acceptability) and 1:1 (perceptibility)
+//
+// note that CMC is not symmetric - the weighting is based on the reference color
+func DeltaECMC(reference Lab, sample Lab, lightness float64, chroma float64) float64 {
+	c1 := math.Hypot(reference.A, reference.B)
+	c2 := math.Hypot(sample.A, sample.B)
+	dl := reference.L - sample.L
+	dc := c1 - c2
+	da := reference.A - sample.A
+	db := reference.B - sample.B
+	dh2 := math.Max(0, da*da+db*db-dc*dc)
+	h1 := hueAngle(reference.B, reference.A)
+	var t float64
+	if h1 >= 164 && h1 <= 345 {
+		t = 0.56 + math.Abs(0.2*math.Cos(radians(h1+168)))
+	} else {
+		t = 0.36 + math.Abs(0.4*math.Cos(radians(h1+35)))
+	}
+	c14 := c1 * c1 * c1 * c1
+	f := math.Sqrt(c14 / (c14 + 1900))
+	sl := 0.511
+	if reference.L >= 16 {
+		sl = (0.040975 * reference.L) / (1 + 0.01765*reference.L)
+	}
+	sc := (0.0638*c1)/(1+0.0131*c1) + 0.638
+	sh := sc * (f*t + 1 - f)
+	return math.Sqrt(sq(dl/(lightness*sl)) + sq(dc/(chroma*sc)) + dh2/sq(sh))
+}
+
+// hueAngle returns the hue angle (in degrees 0..360) for the b & a components
+func hueAngle(b float64, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func sq(v float64) float64 {
+	return v * v
+}
@@ -0,0 +1,71 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var testDeltaEPair = [2]Lab{{L: 0.9, A: 16.3, B: -2.22}, {L: 0.7, A: 14.2, B: -1.80}}
+
+func TestDeltaE76(t *testing.T) {
+	assert.InDelta(t, 2.151, DeltaE76(testDeltaEPair[0], testDeltaEPair[1]), 0.001)
+	assert.Equal(t, 0.0, DeltaE76(testDeltaEPair[0], testDeltaEPair[0]))
+}
+
+func TestDeltaE94(t *testing.T) {
+	assert.InDelta(t, 1.249, DeltaE94(testDeltaEPair[0], testDeltaEPair[1], DeltaE94GraphicArts), 0.001)
+	assert.InDelta(t, 1.3950, DeltaE94(Lab{L: 50, A: 2.6772, B: -79.7751}, Lab{L: 50, A: 0, B: -82.7485}, DeltaE94GraphicArts), 0.0001)
+	// textiles halves the lightness weighting...
+	assert.InDelta(t, 0.5, DeltaE94(Lab{L: 50}, Lab{L: 49}, DeltaE94Textiles), 0.000001)
+	assert.Equal(t, 0.0, DeltaE94(testDeltaEPair[0], testDeltaEPair[0], DeltaE94GraphicArts))
+}
+
+func TestDeltaE2000(t *testing.T) {
+	// test data from Sharma, Wu & Dalal - "The CIEDE2000 Color-Difference Formula"
+	testCases := []struct {
+		reference Lab
+		sample    Lab
+		expect    float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 3.1571, -77.2803}, Lab{50, 0, -82.7485}, 2.8615},
+		{Lab{50, 0, 0}, Lab{50, -1, 2}, 2.3669},
+		{Lab{50, -1, 2}, Lab{50, 0, 0}, 2.3669},
+		{Lab{50, 2.49, -0.001}, Lab{50, -2.49, 0.0009}, 7.1792},
+		{Lab{50, 2.49, -0.001}, Lab{50, -2.49, 0.0011}, 7.2195},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{50, 2.5, 0}, Lab{50, 3.1736, 0.5854}, 1.0000},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+		{Lab{63.0109, -31.0961, -5.8663}, Lab{62.8187, -29.7946, -4.0864}, 1.2630},
+		{Lab{22.7233, 20.0904, -46.6940}, Lab{23.0331, 14.9730, -42.5619}, 2.0373},
+		{Lab{90.8027, -2.0831, 1.4410}, Lab{91.1528, -1.6435, 0.0447}, 1.4441},
+		{Lab{2.0776, 0.0795, -1.1350}, Lab{0.9033, -0.0636, -0.5514}, 0.9082},
+	}
+	for i, tc := range testCases {
+		assert.InDelta(t, tc.expect, DeltaE2000(tc.reference, tc.sample), 0.0001, "test case %d", i)
+	}
+	assert.InDelta(t, 1.523, DeltaE2000(testDeltaEPair[0], testDeltaEPair[1]), 0.001)
+	assert.Equal(t, 0.0, DeltaE2000(Lab{}, Lab{}))
+}
+
+func TestDeltaECMC(t *testing.T) {
+	assert.InDelta(t, 1.443, DeltaECMC(testDeltaEPair[0], testDeltaEPair[1], 2, 1), 0.001)
+	// blue hue (within 164..345)...
+	assert.InDelta(t, 1.7387, DeltaECMC(Lab{L: 50, A: 2.6772, B: -79.7751}, Lab{L: 50, A: 0, B: -82.7485}, 1, 1), 0.0001)
+	assert.Equal(t, 0.0, DeltaECMC(testDeltaEPair[0], testDeltaEPair[0], 2, 1))
+}
+
+func TestDeltaE_ProfileRoundTrip(t *testing.T) {
+	p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	for _, rgb := range [][]float64{{0.2, 0.4, 0.6}, {0.9, 0.1, 0.5}, {1, 1, 1}} {
+		lab, err := p.ToCIELab(rgb...)
+		require.NoError(t, err)
+		back, err := p.FromCIELab(lab...)
+		require.NoError(t, err)
+		again, err := p.ToCIELab(back...)
+		require.NoError(t, err)
+		de := DeltaE2000(Lab{L: lab[0], A: lab[1], B: lab[2]}, Lab{L: again[0], A: again[1], B: again[2]})
+		assert.Less(t, de, 0.01)
+	}
+}
@@ -1,5 +1,10 @@
 package iccarus
 
+import (
+	"encoding/binary"
+	"math"
+)
+
 func readS15Fixed16BE(raw []byte) float64 {
 	if len(raw) < 4 {
 		panic("readS15Fixed16BE: not enough bytes")
@@ -8,3 +13,25 @@ func readS15Fixed16BE(raw []byte) float64 {
 	lsb := uint16(raw[2])<<8 | uint16(raw[3])
 	return float64(msb) + float64(lsb)/65536.0
 }
+
+func encodeS15Fixed16BE(value float64) []byte {
+	fixed := math.Round(value * 65536.0)
+	if fixed > math.MaxInt32 {
+		fixed = math.MaxInt32
+	} else if fixed < math.MinInt32 {
+		fixed = math.MinInt32
+	}
+	result := make([]byte, 4)
+	binary.BigEndian.PutUint32(result, uint32(int32(fixed)))
+	return result
+}
+
+// encodeNormalizedUint16 encodes a normalized (0..1) value as a uint16 (0..65535)
+func encodeNormalizedUint16(value float64) uint16 {
+	return uint16(math.Round(clamp01(value) * 65535.0))
+}
+
+// encodeNormalizedUint8 encodes a normalized (0..1) value as a uint8 (0..255)
+func encodeNormalizedUint8(value float64) uint8 {
+	return uint8(math.Round(clamp01(value) * 255.0))
+}
@@ -1,25 +1,10 @@
 package iccarus
 
 import (
-	"encoding/binary"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
-func encodeS15Fixed16BE(value float64) []byte {
-	if value > 32767 {
-		value = 32767
-	} else if value < -32768 {
-		value = -32768
-	}
-	intPart := int16(value)
-	fracPart := uint16((value - float64(intPart)) * 65536.0)
-	result := make([]byte, 4)
-	binary.BigEndian.PutUint16(result[0:2], uint16(intPart))
-	binary.BigEndian.PutUint16(result[2:4], fracPart)
-	return result
-}
-
 func TestReadS15Fixed16BE(t *testing.T) {
 	t.Run("PositiveWhole", func(t *testing.T) {
 		val := readS15Fixed16BE([]byte{0x00, 0x01, 0x00, 0x00}) // 1.0
@@ -47,3 +32,27 @@ func TestReadS15Fixed16BE(t *testing.T) {
 		})
 	})
 }
+
+func TestEncodeS15Fixed16BE(t *testing.T) {
+	testCases := []struct {
+		value  float64
+		expect []byte
+	}{
+		{1.0, []byte{0x00, 0x01, 0x00, 0x00}},
+		{2.5, []byte{0x00, 0x02, 0x80, 0x00}},
+		{-1.0, []byte{0xFF, 0xFF, 0x00, 0x00}},
+		{-1.5, []byte{0xFF, 0xFE, 0x80, 0x00}},
+		{-0.5, []byte{0xFF, 0xFF, 0x80, 0x00}},
+		{0, []byte{0x00, 0x00, 0x00, 0x00}},
+		{0.964202880859375, []byte{0x00, 0x00, 0xF6, 0xD6}},
+		{40000, []byte{0x7F, 0xFF, 0xFF, 0xFF}},
+		{-40000, []byte{0x80, 0x00, 0x00, 0x00}},
+	}
+	for _, tc := range testCases {
+		raw := encodeS15Fixed16BE(tc.value)
+		assert.Equal(t, tc.expect, raw)
+		if tc.value > -32768 && tc.value < 32767 {
+			assert.Equal(t, tc.value, readS15Fixed16BE(raw))
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GrayTRC represents a monochrome transform - as used by grayscale profiles
+// that describe the device with a gray tone reproduction curve (kTRC) tag
+//
+// the gray value is linearized by the curve and then mapped onto the Y axis of the white point - or, for profiles
+// with a Lab PCS, onto L* (with a* = b* = 0) in the normalized Lab PCS encoding
+// (the inverse direction uses the inverted curve)
+type GrayTRC struct {
+	// Curve is the gray tone reproduction curve
+	Curve ChannelTransformer
+	// WhitePoint is the white point onto which gray values are mapped (not used when LabPCS is set)
+	WhitePoint XYZNumber
+	// LabPCS is set when the curve output is L* (normalized 0..1) - as for profiles with a Lab PCS
+	LabPCS       bool
+	inverseCurve ChannelTransformer
+}
+
+var _ ToCIEXYZ = (*GrayTRC)(nil)
+var _ FromCIEXYZ = (*GrayTRC)(nil)
+
+// NewGrayTRC creates a new GrayTRC from the gray curve and white point
+func NewGrayTRC(curve ChannelTransformer, whitePoint XYZNumber) (*GrayTRC, error) {
+	if whitePoint.Y <= 0 {
+		return nil, errors.New("invalid white point (Y must be greater than zero)")
+	}
+	inverse, err := invertCurve(curve)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gray TRC curve: %w", err)
+	}
+	return &GrayTRC{
+		Curve:        curve,
+		WhitePoint:   whitePoint,
+		inverseCurve: inverse,
+	}, nil
+}
+
+func (g *GrayTRC) ToCIEXYZ(channels ...float64) ([]float64, error) {
+	if len(channels) != 1 {
+		return nil, fmt.Errorf("gray TRC expects 1 input channel, got %d", len(channels))
+	}
+	if g.Curve == nil {
+		return nil, errors.New("gray TRC missing curve")
+	}
+	v, err := g.Curve.Transform(clamp01(channels[0]))
+	if err != nil {
+		return nil, fmt.Errorf("failed processing gray TRC curve: %w", err)
+	}
+	if g.LabPCS {
+		// (a* = b* = 0 in the normalized Lab PCS encoding)
+		return []float64{v[0], 128.0 / 255, 128.0 / 255}, nil
+	}
+	return []float64{v[0] * g.WhitePoint.X, v[0] * g.WhitePoint.Y, v[0] * g.WhitePoint.Z}, nil
+}
+
+func (g *GrayTRC) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	if len(channels) != 3 {
+		return nil, fmt.Errorf("gray TRC expects 3 input channels, got %d", len(channels))
+	}
+	if g.inverseCurve == nil {
+		return nil, errors.New("gray TRC has no inverse curve (use NewGrayTRC)")
+	}
+	value := channels[0]
+	if !g.LabPCS {
+		value = channels[1] / g.WhitePoint.Y
+	}
+	v, err := g.inverseCurve.Transform(clamp01(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed processing gray TRC inverse curve: %w", err)
+	}
+	return []float64{v[0]}, nil
+}
+
+// findGrayTRC builds a GrayTRC from the profile's kTRC tag and media white point (the curve output is L* for
+// profiles with a Lab PCS)
+//
+// returns nil (with no error) if the profile does not have a kTRC tag
+func (p *Profile) findGrayTRC() (*GrayTRC, error) {
+	if p.grayTRC != nil {
+		return p.grayTRC, nil
+	}
+	if tag, ok := p.TagByHeader(TagHeaderKTRC); !ok || tag == nil {
+		return nil, nil
+	}
+	val, err := p.TagValue(TagHeaderKTRC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s tag: %w", TagHeaderKTRC, err)
+	}
+	curve, ok := val.(ChannelTransformer)
+	if !ok {
+		return nil, fmt.Errorf("%s tag does not implement interface ChannelTransformer (got %T)", TagHeaderKTRC, val)
+	}
+	whitePoint, err := p.mediaWhitePoint()
+	if err != nil {
+		return nil, err
+	}
+	gray, err := NewGrayTRC(curve, whitePoint)
+	if err != nil {
+		return nil, err
+	}
+	gray.LabPCS = p.Header.PCS == "Lab"
+	p.grayTRC = gray
+	return gray, nil
+}
+
+// mediaWhitePoint returns the media white point (wtpt) tag value - or the header illuminant if there is no wtpt tag
+func (p *Profile) mediaWhitePoint() (XYZNumber, error) {
+	if tag, ok := p.TagByHeader(TagHeaderMediaWhitePointTag); ok && tag != nil {
+		val, err := tag.Value()
+		if err != nil {
+			return XYZNumber{}, fmt.Errorf("failed to decode %s tag: %w", TagHeaderMediaWhitePointTag, err)
+		}
+		xyz, ok := val.([]XYZNumber)
+		if !ok || len(xyz) == 0 {
+			return XYZNumber{}, fmt.Errorf("%s tag is not an XYZ tag (got %T)", TagHeaderMediaWhitePointTag, val)
+		}
+		return xyz[0], nil
+	}
+	return XYZNumber{
+		X: p.Header.Illuminant[0],
+		Y: p.Header.Illuminant[1],
+		Z: p.Header.Illuminant[2],
+	}, nil
+}
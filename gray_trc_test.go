@@ -0,0 +1,180 @@
+package iccarus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGrayTRC(t *testing.T) {
+	wtpt := XYZNumber{X: 0.9642, Y: 1.0, Z: 0.8249}
+	t.Run("ToCIEXYZ", func(t *testing.T) {
+		g, err := NewGrayTRC(&CurveTag{Type: CurveTypeGamma, Gamma: 2.0}, wtpt)
+		require.NoError(t, err)
+		xyz, err := g.ToCIEXYZ(0.5)
+		require.NoError(t, err)
+		require.Len(t, xyz, 3)
+		assert.InDelta(t, 0.9642*0.25, xyz[0], 0.0001)
+		assert.InDelta(t, 0.25, xyz[1], 0.0001)
+		assert.InDelta(t, 0.8249*0.25, xyz[2], 0.0001)
+	})
+	t.Run("Round Trip", func(t *testing.T) {
+		g, err := NewGrayTRC(&CurveTag{Type: CurveTypePoints, Points: []uint16{0, 10000, 30000, 65535}}, wtpt)
+		require.NoError(t, err)
+		for _, v := range []float64{0, 0.1, 0.5, 0.9, 1} {
+			xyz, err := g.ToCIEXYZ(v)
+			require.NoError(t, err)
+			back, err := g.FromCIEXYZ(xyz...)
+			require.NoError(t, err)
+			require.Len(t, back, 1)
+			assert.InDelta(t, v, back[0], 0.0001)
+		}
+	})
+	t.Run("Lab PCS", func(t *testing.T) {
+		g, err := NewGrayTRC(&CurveTag{Type: CurveTypeGamma, Gamma: 2.0}, wtpt)
+		require.NoError(t, err)
+		g.LabPCS = true
+		lab, err := g.ToCIEXYZ(0.5)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, []float64{0.25, 128.0 / 255, 128.0 / 255}, lab, 0.0001)
+		back, err := g.FromCIEXYZ(lab...)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, back[0], 0.0001)
+	})
+	t.Run("Invalid White Point", func(t *testing.T) {
+		_, err := NewGrayTRC(&CurveTag{Type: CurveTypeIdentity}, XYZNumber{})
+		assert.ErrorContains(t, err, "invalid white point")
+	})
+	t.Run("Invalid Curve", func(t *testing.T) {
+		_, err := NewGrayTRC(nil, wtpt)
+		assert.ErrorContains(t, err, "invalid gray TRC curve")
+	})
+}
+
+func TestGrayTRC_Errors(t *testing.T) {
+	t.Run("ToCIEXYZ wrong channels", func(t *testing.T) {
+		g := &GrayTRC{}
+		_, err := g.ToCIEXYZ(1, 2)
+		assert.ErrorContains(t, err, "gray TRC expects 1 input channel, got 2")
+	})
+	t.Run("ToCIEXYZ missing curve", func(t *testing.T) {
+		g := &GrayTRC{}
+		_, err := g.ToCIEXYZ(1)
+		assert.ErrorContains(t, err, "gray TRC missing curve")
+	})
+	t.Run("ToCIEXYZ curve fails", func(t *testing.T) {
+		g := &GrayTRC{Curve: &mockFailTransformer{}}
+		_, err := g.ToCIEXYZ(1)
+		assert.ErrorContains(t, err, "failed processing gray TRC curve")
+	})
+	t.Run("FromCIEXYZ wrong channels", func(t *testing.T) {
+		g := &GrayTRC{}
+		_, err := g.FromCIEXYZ(1)
+		assert.ErrorContains(t, err, "gray TRC expects 3 input channels, got 1")
+	})
+	t.Run("FromCIEXYZ no inverse curve", func(t *testing.T) {
+		g := &GrayTRC{}
+		_, err := g.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "gray TRC has no inverse curve")
+	})
+	t.Run("FromCIEXYZ inverse curve fails", func(t *testing.T) {
+		g := &GrayTRC{WhitePoint: XYZNumber{Y: 1}, inverseCurve: &mockFailTransformer{}}
+		_, err := g.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "failed processing gray TRC inverse curve")
+	})
+}
+
+func TestProfile_findGrayTRC(t *testing.T) {
+	tags := func() map[TagHeaderName]*Tag {
+		return map[TagHeaderName]*Tag{
+			TagHeaderKTRC:               {Name: TagCurve, value: &CurveTag{Type: CurveTypeGamma, Gamma: 2.2}},
+			TagHeaderMediaWhitePointTag: {Name: TagXYZ, value: []XYZNumber{{X: 0.9642, Y: 1.0, Z: 0.8249}}},
+		}
+	}
+	t.Run("Success", func(t *testing.T) {
+		p := &Profile{tagsByHeader: tags()}
+		xyz, err := p.ToCIEXYZ(1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9642, xyz[0], 0.0001)
+		assert.InDelta(t, 1.0, xyz[1], 0.0001)
+		assert.InDelta(t, 0.8249, xyz[2], 0.0001)
+		gray, err := p.FromCIEXYZ(0.9642*0.5, 0.5, 0.8249*0.5)
+		require.NoError(t, err)
+		require.Len(t, gray, 1)
+		assert.InDelta(t, 0.7297, gray[0], 0.0001)
+		cached, err := p.findGrayTRC()
+		require.NoError(t, err)
+		assert.Same(t, p.grayTRC, cached)
+	})
+	t.Run("Lab PCS", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderKTRC] = &Tag{Name: TagCurve, value: &CurveTag{Type: CurveTypeIdentity}}
+		// (the media white point is not used for a Lab PCS)
+		hdrs[TagHeaderMediaWhitePointTag] = &Tag{Name: TagXYZ, value: []XYZNumber{{X: 0.9505, Y: 1.0, Z: 1.089}}}
+		p := &Profile{tagsByHeader: hdrs, Header: Header{PCS: "Lab", Illuminant: d50}}
+		lab, err := p.ToCIELab(0.5)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, []float64{50, 0, 0}, lab, 0.0001)
+		xyz, err := p.ToCIEXYZ(1)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, d50[:], xyz, 0.0001)
+		gray, err := p.FromCIELab(50, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, gray, 1)
+		assert.InDelta(t, 0.5, gray[0], 0.0001)
+		cached, err := p.findGrayTRC()
+		require.NoError(t, err)
+		assert.True(t, cached.LabPCS)
+	})
+	t.Run("Header Illuminant White Point", func(t *testing.T) {
+		hdrs := tags()
+		delete(hdrs, TagHeaderMediaWhitePointTag)
+		p := &Profile{tagsByHeader: hdrs, Header: Header{Illuminant: [3]float64{0.9505, 1.0, 1.089}}}
+		xyz, err := p.ToCIEXYZ(1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9505, xyz[0], 0.0001)
+		assert.InDelta(t, 1.089, xyz[2], 0.0001)
+	})
+	t.Run("No kTRC", func(t *testing.T) {
+		p := &Profile{}
+		gray, err := p.findGrayTRC()
+		require.NoError(t, err)
+		assert.Nil(t, gray)
+	})
+	t.Run("kTRC fails to decode", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderKTRC] = &Tag{error: errors.New("foo")}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.ToCIEXYZ(1)
+		assert.ErrorContains(t, err, "failed to decode kTRC tag")
+	})
+	t.Run("kTRC not a curve", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderKTRC] = &Tag{value: "foo"}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "kTRC tag does not implement interface ChannelTransformer")
+	})
+	t.Run("wtpt fails to decode", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderMediaWhitePointTag] = &Tag{error: errors.New("foo")}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findGrayTRC()
+		assert.ErrorContains(t, err, "failed to decode wtpt tag")
+	})
+	t.Run("wtpt not XYZ", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderMediaWhitePointTag] = &Tag{value: "foo"}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findGrayTRC()
+		assert.ErrorContains(t, err, "wtpt tag is not an XYZ tag")
+	})
+	t.Run("Zero white point", func(t *testing.T) {
+		hdrs := tags()
+		delete(hdrs, TagHeaderMediaWhitePointTag)
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findGrayTRC()
+		assert.ErrorContains(t, err, "invalid white point")
+	})
+}
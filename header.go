@@ -3,6 +3,7 @@ package iccarus
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -30,8 +31,14 @@ type Header struct {
 	Illuminant      [3]float64
 	Creator         string
 	ProfileID       [16]byte
+	// raw is the original bytes of date & signature fields (keyed by header offset) that would not be encoded back
+	// the same (e.g. NUL padded signatures or invalid dates) - written back while the field value is unchanged
+	raw map[int][]byte
 }
 
+// headerSignatureOffsets are the header offsets of the signature fields
+var headerSignatureOffsets = []int{4, 12, 16, 20, 40, 48, 52, 80}
+
 func parseHeader(r io.Reader) (Header, error) {
 	var buf [128]byte
 	if _, err := io.ReadFull(r, buf[:]); err != nil {
@@ -44,22 +51,29 @@ func parseHeader(r io.Reader) (Header, error) {
 	var profileId [16]byte
 	copy(profileId[:], buf[84:100])
 	versionRaw := binary.BigEndian.Uint32(buf[8:12])
+	var raw map[int][]byte
+	keepRaw := func(offset int, original []byte, encoded []byte) {
+		if !bytes.Equal(original, encoded) {
+			if raw == nil {
+				raw = make(map[int][]byte)
+			}
+			raw[offset] = bytes.Clone(original)
+		}
+	}
+	for _, offset := range headerSignatureOffsets {
+		keepRaw(offset, buf[offset:offset+4], signatureBytes(stringed(buf[offset:offset+4])))
+	}
+	created := decodeDateTime(buf[24:36])
+	keepRaw(24, buf[24:36], encodeDateTime(created))
 	return Header{
-		ProfileSize: binary.BigEndian.Uint32(buf[0:4]),
-		CMMType:     stringed(buf[4:8]),
-		VersionRaw:  versionRaw,
-		Version:     versionFromRaw(versionRaw),
-		DeviceClass: stringed(buf[12:16]),
-		ColorSpace:  stringed(buf[16:20]),
-		PCS:         stringed(buf[20:24]),
-		Created: time.Date(
-			int(binary.BigEndian.Uint16(buf[24:26])),
-			time.Month(binary.BigEndian.Uint16(buf[26:28])),
-			int(binary.BigEndian.Uint16(buf[28:30])),
-			int(binary.BigEndian.Uint16(buf[30:32])),
-			int(binary.BigEndian.Uint16(buf[32:34])),
-			int(binary.BigEndian.Uint16(buf[34:36])),
-			0, time.UTC),
+		ProfileSize:     binary.BigEndian.Uint32(buf[0:4]),
+		CMMType:         stringed(buf[4:8]),
+		VersionRaw:      versionRaw,
+		Version:         versionFromRaw(versionRaw),
+		DeviceClass:     stringed(buf[12:16]),
+		ColorSpace:      stringed(buf[16:20]),
+		PCS:             stringed(buf[20:24]),
+		Created:         created,
 		Signature:       signature,
 		Platform:        stringed(buf[40:44]),
 		Flags:           binary.BigEndian.Uint32(buf[44:48]),
@@ -74,9 +88,83 @@ func parseHeader(r io.Reader) (Header, error) {
 		},
 		Creator:   stringed(buf[80:84]),
 		ProfileID: profileId,
+		raw:       raw,
 	}, nil
 }
 
+// decodeDateTime decodes a dateTimeNumber - an all-zero dateTimeNumber is the zero time
+func decodeDateTime(data []byte) time.Time {
+	if bytes.Equal(data, make([]byte, 12)) {
+		return time.Time{}
+	}
+	return time.Date(
+		int(binary.BigEndian.Uint16(data[0:2])),
+		time.Month(binary.BigEndian.Uint16(data[2:4])),
+		int(binary.BigEndian.Uint16(data[4:6])),
+		int(binary.BigEndian.Uint16(data[6:8])),
+		int(binary.BigEndian.Uint16(data[8:10])),
+		int(binary.BigEndian.Uint16(data[10:12])),
+		0, time.UTC)
+}
+
+// encodeDateTime encodes a dateTimeNumber - the zero time is encoded as an all-zero dateTimeNumber
+func encodeDateTime(t time.Time) []byte {
+	result := make([]byte, 12)
+	if !t.IsZero() {
+		binary.BigEndian.PutUint16(result[0:2], uint16(t.Year()))
+		binary.BigEndian.PutUint16(result[2:4], uint16(t.Month()))
+		binary.BigEndian.PutUint16(result[4:6], uint16(t.Day()))
+		binary.BigEndian.PutUint16(result[6:8], uint16(t.Hour()))
+		binary.BigEndian.PutUint16(result[8:10], uint16(t.Minute()))
+		binary.BigEndian.PutUint16(result[10:12], uint16(t.Second()))
+	}
+	return result
+}
+
+// encode encodes the header (with the supplied profile size)
+func (h Header) encode(profileSize uint32) []byte {
+	buf := make([]byte, 128)
+	signature := func(offset int, s string) {
+		if raw, ok := h.raw[offset]; ok && stringed(raw) == s {
+			copy(buf[offset:offset+4], raw)
+		} else {
+			copy(buf[offset:offset+4], signatureBytes(s))
+		}
+	}
+	binary.BigEndian.PutUint32(buf[0:4], profileSize)
+	signature(4, h.CMMType)
+	versionRaw := h.VersionRaw
+	if versionRaw == 0 {
+		versionRaw = h.Version.raw()
+	}
+	binary.BigEndian.PutUint32(buf[8:12], versionRaw)
+	signature(12, h.DeviceClass)
+	signature(16, h.ColorSpace)
+	signature(20, h.PCS)
+	if raw, ok := h.raw[24]; ok && h.Created.Equal(decodeDateTime(raw)) {
+		copy(buf[24:36], raw)
+	} else {
+		copy(buf[24:36], encodeDateTime(h.Created))
+	}
+	copy(buf[36:40], "acsp")
+	signature(40, h.Platform)
+	binary.BigEndian.PutUint32(buf[44:48], h.Flags)
+	signature(48, h.Manufacturer)
+	signature(52, h.Model)
+	copy(buf[56:64], h.Attributes[:])
+	binary.BigEndian.PutUint32(buf[64:68], h.RenderingIntent)
+	illuminant := h.Illuminant
+	if illuminant == ([3]float64{}) {
+		illuminant = d50
+	}
+	for i, v := range illuminant {
+		copy(buf[68+i*4:72+i*4], encodeS15Fixed16BE(v))
+	}
+	signature(80, h.Creator)
+	copy(buf[84:100], h.ProfileID[:])
+	return buf
+}
+
 type Version struct {
 	Major    int
 	Minor    int
@@ -87,6 +175,10 @@ func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Revision)
 }
 
+func (v Version) raw() uint32 {
+	return uint32(v.Major&0xFF)<<24 | uint32(v.Minor&0x0F)<<20 | uint32(v.Revision&0x0F)<<16
+}
+
 func versionFromRaw(v uint32) Version {
 	return Version{
 		Major:    int((v >> 24) & 0xFF),
@@ -107,3 +199,19 @@ func stringed(data []byte) string {
 	}
 	return s
 }
+
+// signatureBytes is the reverse of stringed - encoding a signature string back to 4 bytes
+func signatureBytes(s string) []byte {
+	result := []byte{0, 0, 0, 0}
+	if s == "" {
+		return result
+	}
+	if len(s) == 10 && strings.HasPrefix(s, "0x") {
+		if decoded, err := hex.DecodeString(s[2:]); err == nil {
+			copy(result, decoded)
+			return result
+		}
+	}
+	copy(result, []byte(s + "    ")[:4])
+	return result
+}
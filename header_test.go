@@ -76,3 +76,62 @@ func TestStringed(t *testing.T) {
 	s = stringed([]byte{'a', 255, 0, 0})
 	assert.Equal(t, "0x61FF0000", s)
 }
+
+func TestSignatureBytes(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0}, signatureBytes(""))
+	assert.Equal(t, []byte("a   "), signatureBytes("a"))
+	assert.Equal(t, []byte("Lab "), signatureBytes("Lab"))
+	assert.Equal(t, []byte("acsp"), signatureBytes("acspXYZ"))
+	assert.Equal(t, []byte{'a', 255, 0, 0}, signatureBytes("0x61FF0000"))
+	assert.Equal(t, []byte("0xZZ"), signatureBytes("0xZZZZZZZZ"))
+}
+
+func TestHeader_encode(t *testing.T) {
+	t.Run("Round Trip", func(t *testing.T) {
+		data := testProfileBytes(t, "default/ISOcoated_v2_300_eci.icc")
+		hdr, err := parseHeader(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, data[:128], hdr.encode(hdr.ProfileSize))
+	})
+	t.Run("Round Trip Zero Date", func(t *testing.T) {
+		data := bytes.Clone(testProfileBytes(t, "default/ISOcoated_v2_300_eci.icc")[:128])
+		clear(data[24:36])
+		hdr, err := parseHeader(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.True(t, hdr.Created.IsZero())
+		assert.Equal(t, data, hdr.encode(hdr.ProfileSize))
+	})
+	t.Run("Round Trip Invalid Date & NUL Padding", func(t *testing.T) {
+		data := bytes.Clone(testProfileBytes(t, "default/ISOcoated_v2_300_eci.icc")[:128])
+		copy(data[4:8], "HDM\x00")
+		copy(data[20:24], "Lab\x00")
+		copy(data[24:36], []byte{0x07, 0xd7, 0, 13, 0, 32, 0, 25, 0, 0, 0, 0}) // month 13, day 32, hour 25
+		copy(data[80:84], "ab\x00\x00")
+		hdr, err := parseHeader(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "HDM", hdr.CMMType)
+		assert.Equal(t, "Lab", hdr.PCS)
+		assert.Equal(t, "ab", hdr.Creator)
+		assert.Equal(t, data, hdr.encode(hdr.ProfileSize))
+		// changed values are encoded normally...
+		hdr.PCS = "XYZ"
+		hdr.Creator = "cd"
+		hdr.Created = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		raw := hdr.encode(hdr.ProfileSize)
+		assert.Equal(t, []byte("HDM\x00"), raw[4:8])
+		assert.Equal(t, []byte("XYZ "), raw[20:24])
+		assert.Equal(t, []byte{0x07, 0xe4, 0, 1, 0, 2, 0, 3, 0, 4, 0, 5}, raw[24:36])
+		assert.Equal(t, []byte("cd  "), raw[80:84])
+	})
+	t.Run("Defaults", func(t *testing.T) {
+		raw := Header{Version: Version{Major: 4, Minor: 3}}.encode(1234)
+		hdr, err := parseHeader(bytes.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1234), hdr.ProfileSize)
+		assert.Equal(t, uint32(0x04300000), hdr.VersionRaw)
+		assert.Equal(t, "4.3.0", hdr.Version.String())
+		assert.Equal(t, "acsp", hdr.Signature)
+		assert.Equal(t, [3]float64{0.964202880859375, 1, 0.8249053955078125}, hdr.Illuminant)
+		assert.Equal(t, make([]byte, 12), raw[24:36])
+	})
+}
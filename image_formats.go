@@ -0,0 +1,101 @@
+package iccarus
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrNoICCProfile is the error returned when an image does not contain an ICC profile
+	ErrNoICCProfile = errors.New("no ICC profile found")
+	// ErrUnsupportedImageFormat is the error returned by ExtractFromImage when the image format is not recognised
+	ErrUnsupportedImageFormat = errors.New("unsupported image format")
+)
+
+// ImageExtractor is the signature of functions that extract an ICC profile from an image
+// (e.g. ExtractFromJPEG)
+//
+// extractors should return ErrNoICCProfile when the image does not contain an ICC profile
+type ImageExtractor func(r io.Reader, options *ParseOptions) (*Profile, error)
+
+type imageFormat struct {
+	name    string
+	magic   string
+	extract ImageExtractor
+}
+
+var (
+	imageFormatsMutex sync.RWMutex
+	imageFormats      = append([]imageFormat{
+		{"jpeg", "\xff\xd8", ExtractFromJPEG},
+		{"png", "\x89PNG\r\n\x1a\n", ExtractFromPNG},
+		{"tiff", "II*\x00", ExtractFromTIFF},
+		{"tiff", "MM\x00*", ExtractFromTIFF},
+		{"webp", "RIFF????WEBP", ExtractFromWebP},
+		{"jxl", jxlCodestreamSignature, ExtractFromJXL},
+		{"jxl", jxlContainerSignature, ExtractFromJXL},
+		{"psd", psdSignature, ExtractFromPSD},
+		{"gif", gifSignature87a, ExtractFromGIF},
+		{"gif", gifSignature89a, ExtractFromGIF},
+		{"bmp", bmpSignature, extractProfileFromBMP},
+	}, heifImageFormats()...)
+)
+
+func heifImageFormats() []imageFormat {
+	result := make([]imageFormat, 0, len(heifBrands))
+	for _, brand := range heifBrands {
+		result = append(result, imageFormat{"heif", "????ftyp" + brand, ExtractFromHEIF})
+	}
+	return result
+}
+
+// RegisterImageFormat registers an image format (and its extractor) for use by ExtractFromImage
+//
+// the magic is the prefix that identifies the image format - any "?" in the magic matches any byte
+//
+// formats are matched in order of registration (after the built-in formats)
+func RegisterImageFormat(name string, magic string, extract ImageExtractor) {
+	imageFormatsMutex.Lock()
+	defer imageFormatsMutex.Unlock()
+	imageFormats = append(imageFormats, imageFormat{name: name, magic: magic, extract: extract})
+}
+
+// ExtractFromImage extracts ICC profile from an image - detecting the image format from its magic bytes
+//
+// built-in formats are .jpeg, .png, .tif, .webp, .jxl, .heic/.heif/.avif, .psd/.psb, .gif & .bmp (other formats can be added using RegisterImageFormat)
+//
+// returns ErrUnsupportedImageFormat if the image format is not recognised, or ErrNoICCProfile if the image
+// does not contain an ICC profile (use errors.Is to check)
+//
+// for images that may contain more than one ICC profile (e.g. multi-page .tif), use ExtractAllFromImage
+func ExtractFromImage(r io.Reader, options *ParseOptions) (*Profile, error) {
+	br := bufio.NewReader(r)
+	format, ok := sniffImageFormat(br)
+	if !ok {
+		return nil, ErrUnsupportedImageFormat
+	}
+	return format.extract(br, options)
+}
+
+// sniffImageFormat determines the image format from the magic bytes (without consuming them)
+func sniffImageFormat(r *bufio.Reader) (imageFormat, bool) {
+	imageFormatsMutex.RLock()
+	defer imageFormatsMutex.RUnlock()
+	for _, format := range imageFormats {
+		if b, err := r.Peek(len(format.magic)); err == nil && matchMagic(format.magic, b) {
+			return format, true
+		}
+	}
+	return imageFormat{}, false
+}
+
+func matchMagic(magic string, b []byte) bool {
+	for i, c := range b {
+		if magic[i] != c && magic[i] != '?' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/go-andiamo/iccarus/_test_data/images"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExtractFromImage(t *testing.T) {
+	for _, name := range images.List() {
+		t.Run(name, func(t *testing.T) {
+			data := testImageBytes(t, name)
+			p, err := ExtractFromImage(bytes.NewReader(data), nil)
+			require.NoError(t, err)
+			var expect *Profile
+			switch {
+			case strings.HasSuffix(name, ".jpeg"):
+				expect, err = ExtractFromJPEG(bytes.NewReader(data), nil)
+			case strings.HasSuffix(name, ".tif"):
+				expect, err = ExtractFromTIFF(bytes.NewReader(data), nil)
+			case strings.HasSuffix(name, ".png"):
+				expect, err = ExtractFromPNG(bytes.NewReader(data), nil)
+			case strings.HasSuffix(name, ".webp"):
+				expect, err = ExtractFromWebP(bytes.NewReader(data), nil)
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expect.Header, p.Header)
+			assert.Equal(t, expect.TagHeaderTable, p.TagHeaderTable)
+		})
+	}
+	t.Run("Big Endian TIFF", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedRawInTIFF(&buf, bytes.NewReader(testMinimalTIFF(binary.BigEndian)), testProfileBytes(t, testEmbedProfile))
+		require.NoError(t, err)
+		p, err := ExtractFromImage(&buf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "RGB", p.Header.ColorSpace)
+	})
+	t.Run("Options", func(t *testing.T) {
+		p, err := ExtractFromImage(bytes.NewReader(testImageBytes(t, "marrow_icc.png")), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		assert.Empty(t, p.TagBlocks)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		data := testEncodedImage(t, png.Encode)
+		_, err := ExtractFromImage(bytes.NewReader(data), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		assert.False(t, errors.Is(err, ErrUnsupportedImageFormat))
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"Empty":     {},
+			"PDF":       []byte("%PDF-1.7\n"),
+			"Short":     {0xFF},
+			"RIFF WAVE": []byte("RIFF\x00\x00\x00\x00WAVEfmt "),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromImage(bytes.NewReader(data), nil)
+				assert.ErrorIs(t, err, ErrUnsupportedImageFormat)
+				assert.False(t, errors.Is(err, ErrNoICCProfile))
+			})
+		}
+	})
+}
+
+func TestRegisterImageFormat(t *testing.T) {
+	saved := imageFormats
+	defer func() {
+		imageFormats = saved
+	}()
+	data := testProfileBytes(t, testEmbedProfile)
+	_, err := ExtractFromImage(bytes.NewReader(data), nil)
+	require.ErrorIs(t, err, ErrUnsupportedImageFormat)
+	// raw ICC profile data ('acsp' signature at offset 36)...
+	RegisterImageFormat("icc", strings.Repeat("?", 36)+"acsp", ParseProfile)
+	called := false
+	RegisterImageFormat("test", "TEST", func(r io.Reader, options *ParseOptions) (*Profile, error) {
+		called = true
+		return nil, ErrNoICCProfile
+	})
+	p, err := ExtractFromImage(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "RGB", p.Header.ColorSpace)
+	_, err = ExtractFromImage(strings.NewReader("TEST..."), nil)
+	assert.ErrorIs(t, err, ErrNoICCProfile)
+	assert.True(t, called)
+	// built-in formats are still matched first...
+	_, err = ExtractFromImage(bytes.NewReader(testImageBytes(t, "marrow_icc.jpeg")), nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,180 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// maxImageTransformCache is the maximum number of distinct colors cached while transforming an image
+const maxImageTransformCache = 1 << 16
+
+// TransformImage transforms every pixel of an image from the source profile to the destination profile
+// using the rendering intent
+//
+// see Transform.TransformImage for details
+func TransformImage(img image.Image, src *Profile, dst *Profile, intent RenderingIntent) (image.Image, error) {
+	t, err := NewTransform(src, dst, intent)
+	if err != nil {
+		return nil, err
+	}
+	return t.TransformImage(img)
+}
+
+// TransformImage transforms every pixel of an image, returning a new image
+//
+// the source image must match the source profile color space - RGB & GRAY (any image), CMYK (*image.CMYK)
+//
+// the returned image type depends on the destination profile color space and the source image bit depth
+// (16-bit for *image.NRGBA64, *image.RGBA64 & *image.Gray16 images, otherwise 8-bit):
+//
+//	RGB:  *image.NRGBA or *image.NRGBA64
+//	GRAY: *image.Gray or *image.Gray16 (*image.NRGBA or *image.NRGBA64 if the source image is not opaque)
+//	CMYK: *image.CMYK (the source image must be opaque)
+//
+// alpha is preserved - colors are transformed un-premultiplied
+func (t *Transform) TransformImage(img image.Image) (image.Image, error) {
+	read, channels, err := imagePixelReader(img, t.Source.Header.ColorSpace)
+	if err != nil {
+		return nil, fmt.Errorf("source profile: %w", err)
+	}
+	write, outChannels, result, err := imagePixelWriter(img, t.Destination.Header.ColorSpace)
+	if err != nil {
+		return nil, fmt.Errorf("destination profile: %w", err)
+	}
+	cache := make(map[uint64][]float64)
+	inputs := make([]float64, channels)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			values, alpha := read(x, y)
+			var key uint64
+			for _, v := range values {
+				key = key<<16 | uint64(v)
+			}
+			out, ok := cache[key]
+			if !ok {
+				for i, v := range values {
+					inputs[i] = float64(v) / 0xFFFF
+				}
+				if out, err = t.Transform(inputs...); err != nil {
+					return nil, fmt.Errorf("pixel (%d, %d): %w", x, y, err)
+				} else if len(out) != outChannels {
+					return nil, fmt.Errorf("pixel (%d, %d): expected %d output channels, got %d", x, y, outChannels, len(out))
+				}
+				if len(cache) < maxImageTransformCache {
+					cache[key] = out
+				}
+			}
+			write(x, y, out, alpha)
+		}
+	}
+	return result, nil
+}
+
+// imagePixelReader returns a reader of (un-premultiplied 16-bit) pixel channels & alpha for the color space
+func imagePixelReader(img image.Image, colorSpace string) (func(x, y int) ([]uint16, uint16), int, error) {
+	switch colorSpace {
+	case "RGB":
+		values := make([]uint16, 3)
+		return func(x, y int) ([]uint16, uint16) {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			values[0], values[1], values[2] = c.R, c.G, c.B
+			return values, c.A
+		}, 3, nil
+	case "GRAY":
+		values := make([]uint16, 1)
+		return func(x, y int) ([]uint16, uint16) {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			values[0] = color.Gray16Model.Convert(color.NRGBA64{R: c.R, G: c.G, B: c.B, A: 0xFFFF}).(color.Gray16).Y
+			return values, c.A
+		}, 1, nil
+	case "CMYK":
+		cmyk, ok := img.(*image.CMYK)
+		if !ok {
+			return nil, 0, fmt.Errorf("color space CMYK requires an *image.CMYK image (got %T)", img)
+		}
+		values := make([]uint16, 4)
+		return func(x, y int) ([]uint16, uint16) {
+			c := cmyk.CMYKAt(x, y)
+			values[0], values[1], values[2], values[3] = uint16(c.C)*0x101, uint16(c.M)*0x101, uint16(c.Y)*0x101, uint16(c.K)*0x101
+			return values, 0xFFFF
+		}, 4, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported image color space %q", colorSpace)
+}
+
+// imagePixelWriter returns a new image (for the color space & source image) and a writer of transformed pixels to it
+func imagePixelWriter(img image.Image, colorSpace string) (func(x, y int, values []float64, alpha uint16), int, image.Image, error) {
+	bounds := img.Bounds()
+	deep := isDeepImage(img)
+	opaque := isOpaqueImage(img)
+	switch {
+	case colorSpace == "RGB" || (colorSpace == "GRAY" && !opaque):
+		gray := colorSpace == "GRAY"
+		channels := 3
+		if gray {
+			channels = 1
+		}
+		rgb := func(values []float64) (float64, float64, float64) {
+			if gray {
+				return values[0], values[0], values[0]
+			}
+			return values[0], values[1], values[2]
+		}
+		if deep {
+			result := image.NewNRGBA64(bounds)
+			return func(x, y int, values []float64, alpha uint16) {
+				r, g, b := rgb(values)
+				result.SetNRGBA64(x, y, color.NRGBA64{R: encodeNormalizedUint16(r), G: encodeNormalizedUint16(g), B: encodeNormalizedUint16(b), A: alpha})
+			}, channels, result, nil
+		}
+		result := image.NewNRGBA(bounds)
+		return func(x, y int, values []float64, alpha uint16) {
+			r, g, b := rgb(values)
+			result.SetNRGBA(x, y, color.NRGBA{R: encodeNormalizedUint8(r), G: encodeNormalizedUint8(g), B: encodeNormalizedUint8(b), A: uint8(alpha >> 8)})
+		}, channels, result, nil
+	case colorSpace == "GRAY":
+		if deep {
+			result := image.NewGray16(bounds)
+			return func(x, y int, values []float64, _ uint16) {
+				result.SetGray16(x, y, color.Gray16{Y: encodeNormalizedUint16(values[0])})
+			}, 1, result, nil
+		}
+		result := image.NewGray(bounds)
+		return func(x, y int, values []float64, _ uint16) {
+			result.SetGray(x, y, color.Gray{Y: encodeNormalizedUint8(values[0])})
+		}, 1, result, nil
+	case colorSpace == "CMYK":
+		if !opaque {
+			return nil, 0, nil, errors.New("color space CMYK does not support transparency")
+		}
+		result := image.NewCMYK(bounds)
+		return func(x, y int, values []float64, _ uint16) {
+			result.SetCMYK(x, y, color.CMYK{
+				C: encodeNormalizedUint8(values[0]),
+				M: encodeNormalizedUint8(values[1]),
+				Y: encodeNormalizedUint8(values[2]),
+				K: encodeNormalizedUint8(values[3]),
+			})
+		}, 4, result, nil
+	}
+	return nil, 0, nil, fmt.Errorf("unsupported image color space %q", colorSpace)
+}
+
+// isDeepImage determines whether the image has 16-bit channels
+func isDeepImage(img image.Image) bool {
+	switch img.(type) {
+	case *image.NRGBA64, *image.RGBA64, *image.Gray16:
+		return true
+	}
+	return false
+}
+
+func isOpaqueImage(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
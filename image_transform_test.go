@@ -0,0 +1,120 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTransformImage(t *testing.T) {
+	srgb := testStandardProfile(t, SRGB)
+	p3 := testStandardProfile(t, DisplayP3)
+	gray := testStandardProfile(t, GrayGamma22)
+	cmyk := testProfile(t, "default/ISOcoated_v2_300_eci.icc")
+	t.Run("NRGBA", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(10, 10, 13, 11))
+		img.SetNRGBA(10, 10, color.NRGBA{R: 255, A: 255})
+		img.SetNRGBA(11, 10, color.NRGBA{R: 255, A: 128})
+		img.SetNRGBA(12, 10, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+		result, err := TransformImage(img, srgb, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA{}, result)
+		out := result.(*image.NRGBA)
+		assert.Equal(t, img.Bounds(), out.Bounds())
+		assert.Equal(t, color.NRGBA{R: 234, G: 51, B: 35, A: 255}, out.NRGBAAt(10, 10))
+		assert.Equal(t, color.NRGBA{R: 234, G: 51, B: 35, A: 128}, out.NRGBAAt(11, 10))
+		assert.Equal(t, color.NRGBA{R: 128, G: 128, B: 128, A: 255}, out.NRGBAAt(12, 10))
+	})
+	t.Run("RGBA (premultiplied)", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		img.SetRGBA(0, 0, color.RGBA{R: 128, A: 128})
+		result, err := TransformImage(img, srgb, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA{}, result)
+		assert.Equal(t, color.NRGBA{R: 234, G: 51, B: 35, A: 128}, result.(*image.NRGBA).NRGBAAt(0, 0))
+	})
+	t.Run("NRGBA64", func(t *testing.T) {
+		img := image.NewNRGBA64(image.Rect(0, 0, 1, 1))
+		img.SetNRGBA64(0, 0, color.NRGBA64{R: 0xFFFF, A: 0x8000})
+		result, err := TransformImage(img, srgb, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA64{}, result)
+		c := result.(*image.NRGBA64).NRGBA64At(0, 0)
+		assert.InDelta(t, 0.9175*0xFFFF, float64(c.R), 0.002*0xFFFF)
+		assert.InDelta(t, 0.2003*0xFFFF, float64(c.G), 0.002*0xFFFF)
+		assert.InDelta(t, 0.1386*0xFFFF, float64(c.B), 0.002*0xFFFF)
+		assert.Equal(t, uint16(0x8000), c.A)
+	})
+	t.Run("Gray", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 2, 1))
+		img.SetGray(0, 0, color.Gray{Y: 255})
+		img.SetGray(1, 0, color.Gray{Y: 100})
+		result, err := TransformImage(img, gray, gray, IntentPerceptual)
+		require.NoError(t, err)
+		require.IsType(t, &image.Gray{}, result)
+		assert.Equal(t, color.Gray{Y: 255}, result.(*image.Gray).GrayAt(0, 0))
+		assert.Equal(t, color.Gray{Y: 100}, result.(*image.Gray).GrayAt(1, 0))
+		result, err = TransformImage(img, gray, srgb, IntentPerceptual)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA{}, result)
+		c := result.(*image.NRGBA).NRGBAAt(0, 0)
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, c)
+	})
+	t.Run("Gray16", func(t *testing.T) {
+		img := image.NewGray16(image.Rect(0, 0, 1, 1))
+		img.SetGray16(0, 0, color.Gray16{Y: 0x8000})
+		result, err := TransformImage(img, gray, gray, IntentPerceptual)
+		require.NoError(t, err)
+		require.IsType(t, &image.Gray16{}, result)
+		assert.InDelta(t, 0x8000, float64(result.(*image.Gray16).Gray16At(0, 0).Y), 2)
+	})
+	t.Run("Gray Not Opaque", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		img.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 10})
+		result, err := TransformImage(img, srgb, gray, IntentPerceptual)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA{}, result)
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 10}, result.(*image.NRGBA).NRGBAAt(0, 0))
+	})
+	t.Run("CMYK", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		img.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+		result, err := TransformImage(img, srgb, cmyk, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		require.IsType(t, &image.CMYK{}, result)
+		c := result.(*image.CMYK).CMYKAt(0, 0)
+		assert.InDelta(t, 0, float64(c.C), 3)
+		assert.InDelta(t, 0, float64(c.K), 3)
+		back, err := TransformImage(result, cmyk, srgb, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		require.IsType(t, &image.NRGBA{}, back)
+		rgb := back.(*image.NRGBA).NRGBAAt(0, 0)
+		assert.InDelta(t, 255, float64(rgb.R), 5)
+		assert.InDelta(t, 255, float64(rgb.G), 5)
+		assert.InDelta(t, 255, float64(rgb.B), 5)
+		assert.Equal(t, uint8(255), rgb.A)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		_, err := TransformImage(img, nil, srgb, IntentPerceptual)
+		assert.ErrorContains(t, err, "source profile is nil")
+		_, err = TransformImage(img, cmyk, srgb, IntentPerceptual)
+		assert.ErrorContains(t, err, "source profile: color space CMYK requires an *image.CMYK image (got *image.NRGBA)")
+		_, err = TransformImage(img, srgb, cmyk, IntentPerceptual)
+		assert.ErrorContains(t, err, "destination profile: color space CMYK does not support transparency")
+		lab := testStandardProfile(t, SRGB)
+		lab.Header.ColorSpace = "Lab"
+		_, err = TransformImage(img, lab, srgb, IntentPerceptual)
+		assert.ErrorContains(t, err, `source profile: unsupported image color space "Lab"`)
+		_, err = TransformImage(img, srgb, lab, IntentPerceptual)
+		assert.ErrorContains(t, err, `destination profile: unsupported image color space "Lab"`)
+		mismatch := testStandardProfile(t, SRGB)
+		mismatch.Header.ColorSpace = "GRAY"
+		_, err = TransformImage(img, srgb, mismatch, IntentPerceptual)
+		assert.ErrorContains(t, err, "pixel (0, 0): expected 1 output channels, got 3")
+		_, err = TransformImage(img, mismatch, srgb, IntentPerceptual)
+		assert.ErrorContains(t, err, "pixel (0, 0): source profile: ")
+	})
+}
@@ -0,0 +1,48 @@
+package iccarus
+
+import "errors"
+
+func invert3x3(m [3][3]float64) ([3][3]float64, error) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	if det == 0 {
+		return [3][3]float64{}, errors.New("matrix is not invertible")
+	}
+	inv := 1 / det
+	return [3][3]float64{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) * inv,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) * inv,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) * inv,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) * inv,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) * inv,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) * inv,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) * inv,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) * inv,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) * inv,
+		},
+	}, nil
+}
+
+func multiply3x3(a, b [3][3]float64) (result [3][3]float64) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return result
+}
+
+func apply3x3(m [3][3]float64, v [3]float64) (result [3]float64) {
+	for i := 0; i < 3; i++ {
+		result[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2]
+	}
+	return result
+}
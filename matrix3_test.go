@@ -0,0 +1,43 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInvert3x3(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		m := [3][3]float64{
+			{2, 0, 1},
+			{1, 3, 2},
+			{1, 1, 2},
+		}
+		inv, err := invert3x3(m)
+		require.NoError(t, err)
+		identity := multiply3x3(m, inv)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				expected := 0.0
+				if i == j {
+					expected = 1.0
+				}
+				assert.InDelta(t, expected, identity[i][j], 0.000001)
+			}
+		}
+	})
+	t.Run("Singular", func(t *testing.T) {
+		_, err := invert3x3([3][3]float64{{1, 2, 3}, {2, 4, 6}, {0, 0, 1}})
+		assert.ErrorContains(t, err, "matrix is not invertible")
+	})
+}
+
+func TestApply3x3(t *testing.T) {
+	m := [3][3]float64{
+		{1, 2, 3},
+		{4, 5, 6},
+		{7, 8, 9},
+	}
+	result := apply3x3(m, [3]float64{1, 0, -1})
+	assert.Equal(t, [3]float64{-2, -2, -2}, result)
+}
@@ -0,0 +1,124 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MatrixShaper represents a matrix/TRC transform - as used by RGB display profiles
+// that describe the device with colorant (rXYZ, gXYZ, bXYZ) and tone reproduction curve (rTRC, gTRC, bTRC) tags
+//
+// device values are linearized by the curves and then converted to PCS XYZ by the colorant matrix
+// (the inverse direction uses the inverse matrix and inverted curves)
+type MatrixShaper struct {
+	// Matrix is the colorant matrix - each column is the XYZ of the red, green & blue colorants
+	Matrix [3][3]float64
+	// Curves are the red, green & blue tone reproduction curves
+	Curves        [3]ChannelTransformer
+	inverseMatrix [3][3]float64
+	inverseCurves [3]ChannelTransformer
+}
+
+var _ ToCIEXYZ = (*MatrixShaper)(nil)
+var _ FromCIEXYZ = (*MatrixShaper)(nil)
+
+// NewMatrixShaper creates a new MatrixShaper from the colorant matrix and red, green & blue curves
+func NewMatrixShaper(matrix [3][3]float64, curves [3]ChannelTransformer) (*MatrixShaper, error) {
+	inverse, err := invert3x3(matrix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid colorant matrix: %w", err)
+	}
+	result := &MatrixShaper{
+		Matrix:        matrix,
+		Curves:        curves,
+		inverseMatrix: inverse,
+	}
+	for i, curve := range curves {
+		if result.inverseCurves[i], err = invertCurve(curve); err != nil {
+			return nil, fmt.Errorf("invalid TRC curve %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+func (m *MatrixShaper) ToCIEXYZ(channels ...float64) ([]float64, error) {
+	if len(channels) != 3 {
+		return nil, fmt.Errorf("matrix/TRC expects 3 input channels, got %d", len(channels))
+	}
+	var linear [3]float64
+	for i, curve := range m.Curves {
+		if curve == nil {
+			return nil, fmt.Errorf("matrix/TRC missing curve %d", i)
+		}
+		v, err := curve.Transform(clamp01(channels[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed processing matrix/TRC curve %d: %w", i, err)
+		}
+		linear[i] = v[0]
+	}
+	xyz := apply3x3(m.Matrix, linear)
+	return xyz[:], nil
+}
+
+func (m *MatrixShaper) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	if len(channels) != 3 {
+		return nil, fmt.Errorf("matrix/TRC expects 3 input channels, got %d", len(channels))
+	}
+	linear := apply3x3(m.inverseMatrix, [3]float64{channels[0], channels[1], channels[2]})
+	result := make([]float64, 3)
+	for i, curve := range m.inverseCurves {
+		if curve == nil {
+			return nil, errors.New("matrix/TRC has no inverse curves (use NewMatrixShaper)")
+		}
+		v, err := curve.Transform(clamp01(linear[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed processing matrix/TRC inverse curve %d: %w", i, err)
+		}
+		result[i] = v[0]
+	}
+	return result, nil
+}
+
+// findMatrixShaper builds a MatrixShaper from the profile's colorant and TRC tags
+//
+// returns nil (with no error) if the profile does not have all the required tags
+func (p *Profile) findMatrixShaper() (*MatrixShaper, error) {
+	if p.matrixShaper != nil {
+		return p.matrixShaper, nil
+	}
+	colorantHeaders := [3]TagHeaderName{TagHeaderRedMatrixColumn, TagHeaderGreenMatrixColumn, TagHeaderBlueMatrixColumn}
+	trcHeaders := [3]TagHeaderName{TagHeaderRedTRC, TagHeaderGreenTRC, TagHeaderBlueTRC}
+	for i := 0; i < 3; i++ {
+		if tag, ok := p.TagByHeader(colorantHeaders[i]); !ok || tag == nil {
+			return nil, nil
+		}
+		if tag, ok := p.TagByHeader(trcHeaders[i]); !ok || tag == nil {
+			return nil, nil
+		}
+	}
+	var matrix [3][3]float64
+	var curves [3]ChannelTransformer
+	for i := 0; i < 3; i++ {
+		val, err := p.TagValue(colorantHeaders[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s tag: %w", colorantHeaders[i], err)
+		}
+		xyz, ok := val.([]XYZNumber)
+		if !ok || len(xyz) == 0 {
+			return nil, fmt.Errorf("%s tag is not an XYZ tag (got %T)", colorantHeaders[i], val)
+		}
+		matrix[0][i], matrix[1][i], matrix[2][i] = xyz[0].X, xyz[0].Y, xyz[0].Z
+		if val, err = p.TagValue(trcHeaders[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode %s tag: %w", trcHeaders[i], err)
+		}
+		if curves[i], ok = val.(ChannelTransformer); !ok {
+			return nil, fmt.Errorf("%s tag does not implement interface ChannelTransformer (got %T)", trcHeaders[i], val)
+		}
+	}
+	shaper, err := NewMatrixShaper(matrix, curves)
+	if err != nil {
+		return nil, err
+	}
+	p.matrixShaper = shaper
+	return shaper, nil
+}
@@ -0,0 +1,179 @@
+package iccarus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMatrixShaper(t *testing.T) {
+	p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	t.Run("ToCIEXYZ", func(t *testing.T) {
+		xyz, err := p.ToCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, xyz, 3)
+		// white maps to D50 PCS illuminant...
+		assert.InDelta(t, 0.9642, xyz[0], 0.001)
+		assert.InDelta(t, 1.0, xyz[1], 0.001)
+		assert.InDelta(t, 0.8249, xyz[2], 0.001)
+		xyz, err = p.ToCIEXYZ(1, 0, 0)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5151, xyz[0], 0.001)
+		assert.InDelta(t, 0.2412, xyz[1], 0.001)
+		assert.InDelta(t, -0.0011, xyz[2], 0.001)
+		xyz, err = p.ToCIEXYZ(0, 0, 0)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.0, xyz[0], 0.0001)
+		assert.InDelta(t, 0.0, xyz[1], 0.0001)
+		assert.InDelta(t, 0.0, xyz[2], 0.0001)
+	})
+	t.Run("Round Trip", func(t *testing.T) {
+		for _, rgb := range [][]float64{{0.2, 0.4, 0.6}, {0.9, 0.1, 0.5}, {0.01, 0.02, 0.03}, {1, 1, 1}} {
+			xyz, err := p.ToCIEXYZ(rgb...)
+			require.NoError(t, err)
+			back, err := p.FromCIEXYZ(xyz...)
+			require.NoError(t, err)
+			require.Len(t, back, 3)
+			for i := range rgb {
+				assert.InDelta(t, rgb[i], back[i], 0.0001)
+			}
+		}
+	})
+	t.Run("Cached", func(t *testing.T) {
+		shaper, err := p.findMatrixShaper()
+		require.NoError(t, err)
+		require.NotNil(t, shaper)
+		again, err := p.findMatrixShaper()
+		require.NoError(t, err)
+		assert.Same(t, shaper, again)
+	})
+}
+
+func TestNewMatrixShaper(t *testing.T) {
+	identity := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	gamma := &CurveTag{Type: CurveTypeGamma, Gamma: 2.0}
+	t.Run("Success", func(t *testing.T) {
+		m, err := NewMatrixShaper(identity, [3]ChannelTransformer{gamma, gamma, gamma})
+		require.NoError(t, err)
+		xyz, err := m.ToCIEXYZ(0.5, 0.5, 0.5)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.25, xyz[0], 0.0001)
+		rgb, err := m.FromCIEXYZ(0.25, 0.25, 0.25)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, rgb[0], 0.0001)
+	})
+	t.Run("Singular Matrix", func(t *testing.T) {
+		_, err := NewMatrixShaper([3][3]float64{}, [3]ChannelTransformer{gamma, gamma, gamma})
+		assert.ErrorContains(t, err, "invalid colorant matrix")
+	})
+	t.Run("Invalid Curve", func(t *testing.T) {
+		_, err := NewMatrixShaper(identity, [3]ChannelTransformer{gamma, nil, gamma})
+		assert.ErrorContains(t, err, "invalid TRC curve 1")
+	})
+}
+
+func TestMatrixShaper_Errors(t *testing.T) {
+	gamma := &CurveTag{Type: CurveTypeGamma, Gamma: 2.0}
+	t.Run("ToCIEXYZ wrong channels", func(t *testing.T) {
+		m := &MatrixShaper{}
+		_, err := m.ToCIEXYZ(1, 2)
+		assert.ErrorContains(t, err, "matrix/TRC expects 3 input channels, got 2")
+	})
+	t.Run("ToCIEXYZ missing curve", func(t *testing.T) {
+		m := &MatrixShaper{Curves: [3]ChannelTransformer{gamma, nil, gamma}}
+		_, err := m.ToCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "matrix/TRC missing curve 1")
+	})
+	t.Run("ToCIEXYZ curve fails", func(t *testing.T) {
+		m := &MatrixShaper{Curves: [3]ChannelTransformer{&mockFailTransformer{}, gamma, gamma}}
+		_, err := m.ToCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "failed processing matrix/TRC curve 0")
+	})
+	t.Run("FromCIEXYZ wrong channels", func(t *testing.T) {
+		m := &MatrixShaper{}
+		_, err := m.FromCIEXYZ(1)
+		assert.ErrorContains(t, err, "matrix/TRC expects 3 input channels, got 1")
+	})
+	t.Run("FromCIEXYZ no inverse curves", func(t *testing.T) {
+		m := &MatrixShaper{}
+		_, err := m.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "matrix/TRC has no inverse curves")
+	})
+	t.Run("FromCIEXYZ inverse curve fails", func(t *testing.T) {
+		m := &MatrixShaper{inverseCurves: [3]ChannelTransformer{gamma, &mockFailTransformer{}, gamma}}
+		_, err := m.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "failed processing matrix/TRC inverse curve 1")
+	})
+}
+
+func TestProfile_findMatrixShaper(t *testing.T) {
+	xyzTag := func(x, y, z float64) *Tag {
+		return &Tag{Name: TagXYZ, value: []XYZNumber{{X: x, Y: y, Z: z}}}
+	}
+	gammaTag := &Tag{Name: TagCurve, value: &CurveTag{Type: CurveTypeGamma, Gamma: 2.2}}
+	tags := func() map[TagHeaderName]*Tag {
+		return map[TagHeaderName]*Tag{
+			TagHeaderRedMatrixColumn:   xyzTag(0.4361, 0.2225, 0.0139),
+			TagHeaderGreenMatrixColumn: xyzTag(0.3851, 0.7169, 0.0971),
+			TagHeaderBlueMatrixColumn:  xyzTag(0.1431, 0.0606, 0.7141),
+			TagHeaderRedTRC:            gammaTag,
+			TagHeaderGreenTRC:          gammaTag,
+			TagHeaderBlueTRC:           gammaTag,
+		}
+	}
+	t.Run("Success", func(t *testing.T) {
+		p := &Profile{tagsByHeader: tags()}
+		xyz, err := p.ToCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9643, xyz[0], 0.0001)
+		assert.InDelta(t, 1.0, xyz[1], 0.0001)
+		assert.InDelta(t, 0.8251, xyz[2], 0.0001)
+		rgb, err := p.FromCIEXYZ(xyz...)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, rgb[0], 0.0001)
+	})
+	t.Run("Missing tag", func(t *testing.T) {
+		hdrs := tags()
+		delete(hdrs, TagHeaderGreenTRC)
+		p := &Profile{tagsByHeader: hdrs}
+		shaper, err := p.findMatrixShaper()
+		require.NoError(t, err)
+		assert.Nil(t, shaper)
+	})
+	t.Run("Colorant fails to decode", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderRedMatrixColumn] = &Tag{error: errors.New("foo")}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.ToCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "failed to decode rXYZ tag")
+	})
+	t.Run("Colorant not XYZ", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderRedMatrixColumn] = &Tag{value: "foo"}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.FromCIEXYZ(1, 1, 1)
+		assert.ErrorContains(t, err, "rXYZ tag is not an XYZ tag")
+	})
+	t.Run("TRC fails to decode", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderBlueTRC] = &Tag{error: errors.New("foo")}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findMatrixShaper()
+		assert.ErrorContains(t, err, "failed to decode bTRC tag")
+	})
+	t.Run("TRC not a curve", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderBlueTRC] = &Tag{value: "foo"}
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findMatrixShaper()
+		assert.ErrorContains(t, err, "bTRC tag does not implement interface ChannelTransformer")
+	})
+	t.Run("Singular colorants", func(t *testing.T) {
+		hdrs := tags()
+		hdrs[TagHeaderGreenMatrixColumn] = xyzTag(0.4361, 0.2225, 0.0139)
+		p := &Profile{tagsByHeader: hdrs}
+		_, err := p.findMatrixShaper()
+		assert.ErrorContains(t, err, "invalid colorant matrix")
+	})
+}
@@ -0,0 +1,131 @@
+package iccarus
+
+import "math"
+
+const (
+	labEpsilon = 216.0 / 24389.0
+	labKappa   = 24389.0 / 27.0
+)
+
+// d50 is the ICC PCS illuminant
+var d50 = [3]float64{0.9642, 1.0, 0.8249}
+
+// pcsEncoding is the encoding of PCS values at the boundary of an A2Bx/B2Ax transform
+type pcsEncoding uint8
+
+const (
+	// pcsXYZ is actual CIE XYZ (as used by matrix/TRC and gray TRC)
+	pcsXYZ pcsEncoding = iota
+	// pcsXYZNormalized is u1Fixed15 XYZ normalized to 0..1 (i.e. 1.0 represents 65535/32768)
+	pcsXYZNormalized
+	// pcsLab is v4 Lab normalized to 0..1 (L* 0..100, a*/b* -128..127)
+	pcsLab
+	// pcsLabLegacy is v2 16-bit Lab normalized to 0..1 (0xFF00 represents L* 100 and a*/b* 127)
+	pcsLabLegacy
+)
+
+// decodePCS decodes PCS values in the given encoding to CIE XYZ (white is the PCS illuminant for Lab)
+func decodePCS(enc pcsEncoding, values []float64, white [3]float64) [3]float64 {
+	switch enc {
+	case pcsXYZNormalized:
+		const scale = 65535.0 / 32768.0
+		return [3]float64{values[0] * scale, values[1] * scale, values[2] * scale}
+	case pcsLab:
+		return labToXYZ(decodeLabPCS(values), white)
+	case pcsLabLegacy:
+		const scale = 65535.0 / 65280.0
+		return labToXYZ(decodeLabPCS([]float64{values[0] * scale, values[1] * scale, values[2] * scale}), white)
+	}
+	return [3]float64{values[0], values[1], values[2]}
+}
+
+// encodePCS encodes CIE XYZ to PCS values in the given encoding (white is the PCS illuminant for Lab)
+func encodePCS(enc pcsEncoding, xyz [3]float64, white [3]float64) []float64 {
+	switch enc {
+	case pcsXYZNormalized:
+		const scale = 32768.0 / 65535.0
+		return []float64{xyz[0] * scale, xyz[1] * scale, xyz[2] * scale}
+	case pcsLab:
+		return encodeLabPCS(xyzToLab(xyz, white))
+	case pcsLabLegacy:
+		const scale = 65280.0 / 65535.0
+		v := encodeLabPCS(xyzToLab(xyz, white))
+		return []float64{v[0] * scale, v[1] * scale, v[2] * scale}
+	}
+	return []float64{xyz[0], xyz[1], xyz[2]}
+}
+
+// pcsEncodingOf determines the PCS encoding used by an A2Bx/B2Ax transform (tag or shaper)
+//
+// lut16Type (mft2) always uses the legacy 16-bit Lab encoding
+func (p *Profile) pcsEncodingOf(transform any) pcsEncoding {
+	switch t := transform.(type) {
+	case *MatrixShaper:
+		return pcsXYZ
+	case *GrayTRC:
+		if t.LabPCS {
+			return pcsLab
+		}
+		return pcsXYZ
+	case *MFT2Tag:
+		if p.Header.PCS == "Lab" {
+			return pcsLabLegacy
+		}
+	}
+	if p.Header.PCS == "Lab" {
+		return pcsLab
+	}
+	return pcsXYZNormalized
+}
+
+// pcsIlluminant returns the profile header PCS illuminant (defaulting to D50 when not set)
+func (p *Profile) pcsIlluminant() [3]float64 {
+	if ill := p.Header.Illuminant; ill[0] > 0 && ill[1] > 0 && ill[2] > 0 {
+		return ill
+	}
+	return d50
+}
+
+// labToXYZ converts CIE Lab to CIE XYZ relative to the given white point
+func labToXYZ(lab [3]float64, white [3]float64) [3]float64 {
+	fy := (lab[0] + 16) / 116
+	fx := fy + lab[1]/500
+	fz := fy - lab[2]/200
+	finv := func(f float64) float64 {
+		if f3 := f * f * f; f3 > labEpsilon {
+			return f3
+		}
+		return (116*f - 16) / labKappa
+	}
+	var y float64
+	if lab[0] > labKappa*labEpsilon {
+		y = fy * fy * fy
+	} else {
+		y = lab[0] / labKappa
+	}
+	return [3]float64{finv(fx) * white[0], y * white[1], finv(fz) * white[2]}
+}
+
+// xyzToLab converts CIE XYZ to CIE Lab relative to the given white point
+func xyzToLab(xyz [3]float64, white [3]float64) [3]float64 {
+	f := func(t float64) float64 {
+		if t > labEpsilon {
+			return math.Cbrt(t)
+		}
+		return (labKappa*t + 16) / 116
+	}
+	fx := f(xyz[0] / white[0])
+	fy := f(xyz[1] / white[1])
+	fz := f(xyz[2] / white[2])
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// decodeLabPCS decodes normalized (0..1) Lab PCS values to CIE Lab
+func decodeLabPCS(values []float64) [3]float64 {
+	return [3]float64{values[0] * 100, values[1]*255 - 128, values[2]*255 - 128}
+}
+
+// encodeLabPCS encodes CIE Lab to normalized (0..1) Lab PCS values
+func encodeLabPCS(lab [3]float64) []float64 {
+	return []float64{lab[0] / 100, (lab[1] + 128) / 255, (lab[2] + 128) / 255}
+}
@@ -0,0 +1,81 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLabXYZ(t *testing.T) {
+	d50 := [3]float64{0.9642, 1.0, 0.8249}
+	t.Run("White", func(t *testing.T) {
+		lab := xyzToLab(d50, d50)
+		assert.InDelta(t, 100.0, lab[0], 0.0001)
+		assert.InDelta(t, 0.0, lab[1], 0.0001)
+		assert.InDelta(t, 0.0, lab[2], 0.0001)
+	})
+	t.Run("Round Trip", func(t *testing.T) {
+		for _, xyz := range [][3]float64{{0.2, 0.3, 0.1}, {0.001, 0.002, 0.003}, {0.5, 0.4, 0.7}, {0, 0, 0}} {
+			back := labToXYZ(xyzToLab(xyz, d50), d50)
+			for i := range xyz {
+				assert.InDelta(t, xyz[i], back[i], 0.000001)
+			}
+		}
+	})
+	t.Run("Known Value", func(t *testing.T) {
+		lab := xyzToLab([3]float64{0.2, 0.3, 0.1}, d50)
+		assert.InDelta(t, 61.654, lab[0], 0.001)
+		assert.InDelta(t, -38.740, lab[1], 0.001)
+		assert.InDelta(t, 34.903, lab[2], 0.001)
+	})
+}
+
+func TestLabPCSEncoding(t *testing.T) {
+	lab := decodeLabPCS([]float64{1, 128.0 / 255, 1})
+	assert.InDelta(t, 100.0, lab[0], 0.0001)
+	assert.InDelta(t, 0.0, lab[1], 0.0001)
+	assert.InDelta(t, 127.0, lab[2], 0.0001)
+	back := encodeLabPCS(lab)
+	assert.InDelta(t, 1.0, back[0], 0.0001)
+	assert.InDelta(t, 128.0/255, back[1], 0.0001)
+	assert.InDelta(t, 1.0, back[2], 0.0001)
+}
+
+func TestPCSEncoding(t *testing.T) {
+	xyz := [3]float64{0.3, 0.4, 0.5}
+	for _, enc := range []pcsEncoding{pcsXYZ, pcsXYZNormalized, pcsLab, pcsLabLegacy} {
+		back := decodePCS(enc, encodePCS(enc, xyz, d50), d50)
+		for i := range xyz {
+			assert.InDelta(t, xyz[i], back[i], 0.000001)
+		}
+	}
+	t.Run("XYZ Normalized", func(t *testing.T) {
+		v := encodePCS(pcsXYZNormalized, [3]float64{1, 1, 1}, d50)
+		assert.InDelta(t, 0x8000/65535.0, v[0], 0.000001)
+	})
+	t.Run("Lab Legacy", func(t *testing.T) {
+		// L* 100 is 0xFF00...
+		v := encodePCS(pcsLabLegacy, d50, d50)
+		assert.InDelta(t, 0xFF00/65535.0, v[0], 0.000001)
+		assert.InDelta(t, 0x8000/65535.0, v[1], 0.000001)
+		assert.InDelta(t, 0x8000/65535.0, v[2], 0.000001)
+	})
+}
+
+func TestProfile_pcsEncodingOf(t *testing.T) {
+	lab := &Profile{Header: Header{PCS: "Lab"}}
+	xyz := &Profile{Header: Header{PCS: "XYZ"}}
+	assert.Equal(t, pcsXYZ, xyz.pcsEncodingOf(&MatrixShaper{}))
+	assert.Equal(t, pcsXYZ, xyz.pcsEncodingOf(&GrayTRC{}))
+	assert.Equal(t, pcsLab, lab.pcsEncodingOf(&GrayTRC{LabPCS: true}))
+	assert.Equal(t, pcsXYZNormalized, xyz.pcsEncodingOf(&MFT2Tag{}))
+	assert.Equal(t, pcsXYZNormalized, xyz.pcsEncodingOf(&ModularTag{}))
+	assert.Equal(t, pcsLabLegacy, lab.pcsEncodingOf(&MFT2Tag{}))
+	assert.Equal(t, pcsLab, lab.pcsEncodingOf(&MFT1Tag{}))
+	assert.Equal(t, pcsLab, lab.pcsEncodingOf(&ModularTag{}))
+}
+
+func TestProfile_pcsIlluminant(t *testing.T) {
+	assert.Equal(t, d50, (&Profile{}).pcsIlluminant())
+	p := &Profile{Header: Header{Illuminant: [3]float64{0.95, 1, 1.09}}}
+	assert.Equal(t, [3]float64{0.95, 1, 1.09}, p.pcsIlluminant())
+}
@@ -1,8 +1,10 @@
 package iccarus
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"slices"
 )
 
 type ParseMode uint8
@@ -39,6 +41,11 @@ type ParseOptions struct {
 	ErrorOnTagDecode bool
 	// TagDecoders allows you to provide custom tag decoders (or override default tag decoders)
 	TagDecoders map[string]func(raw []byte) (any, error)
+	// VerifyProfileID determines whether the profile ID (MD5 checksum) in the header is verified
+	//
+	// if set, a profile ID that does not match the computed profile ID causes a parse error (profiles with a zero
+	// profile ID are not verified) - note that this requires the entire profile to be read (regardless of Mode)
+	VerifyProfileID bool
 }
 
 // Profile represents the contents of an ICC Profile file
@@ -52,9 +59,11 @@ type Profile struct {
 	// tagsByHeader is a lookup of tags by header tag
 	tagsByHeader map[TagHeaderName]*Tag
 	// tagsByName is a lookup of actual tags
-	tagsByName map[TagName][]*Tag
-	a2b0       ToCIEXYZ
-	b2a0       FromCIEXYZ
+	tagsByName   map[TagName][]*Tag
+	a2b          [3]ToCIEXYZ
+	b2a          [3]FromCIEXYZ
+	matrixShaper *MatrixShaper
+	grayTRC      *GrayTRC
 }
 
 // TagByHeader retrieves the Tag associated with a given TagHeaderName
@@ -82,6 +91,42 @@ func (p *Profile) TagValue(name TagHeaderName) (any, error) {
 	return nil, fmt.Errorf("tag %q not found", name)
 }
 
+// SetTag sets (adds or replaces) the tag value for a given TagHeaderName
+//
+// typeName is the TagName (tag type signature) used to encode the value when the profile is written - the value
+// must be of the type returned by the decoder for that TagName (e.g. *CurveTag for TagCurve) or the type expected by
+// a custom encoder (see WriteOptions.TagEncoders)
+//
+// if the tag header was sharing a tag block with other tag headers, those other tag headers are unaffected
+func (p *Profile) SetTag(name TagHeaderName, typeName TagName, value any) {
+	if existing, ok := p.tagsByHeader[name]; ok {
+		existing.Headers = slices.DeleteFunc(existing.Headers, func(hdr TagHeader) bool {
+			return hdr.Name == name
+		})
+		if len(existing.Headers) == 0 {
+			p.TagBlocks = slices.DeleteFunc(p.TagBlocks, func(tag *Tag) bool {
+				return tag == existing
+			})
+		} else if i := slices.Index(p.TagBlocks, existing); i >= 0 && slices.Index(p.TagBlocks[i+1:], existing) >= 0 {
+			// (the shared block is listed once per tag header - so drop one listing but keep the block)
+			p.TagBlocks = slices.Delete(p.TagBlocks, i, i+1)
+		}
+	}
+	p.TagBlocks = append(p.TagBlocks, &Tag{
+		Headers: []TagHeader{{Name: name}},
+		Name:    typeName,
+		value:   value,
+	})
+	p.mapTags()
+	// the profile ID no longer matches the profile content...
+	p.Header.ProfileID = [16]byte{}
+	// clear cached transforms (which may have been derived from the previous tag)...
+	p.a2b = [3]ToCIEXYZ{}
+	p.b2a = [3]FromCIEXYZ{}
+	p.matrixShaper = nil
+	p.grayTRC = nil
+}
+
 func (p *Profile) mapTags() {
 	p.tagsByHeader = make(map[TagHeaderName]*Tag)
 	p.tagsByName = make(map[TagName][]*Tag)
@@ -102,6 +147,13 @@ func ParseProfile(r io.Reader, options *ParseOptions) (result *Profile, err erro
 			Mode: ParseFull,
 		}
 	}
+	var data []byte
+	if options.VerifyProfileID {
+		if data, err = io.ReadAll(r); err != nil {
+			return &Profile{}, err
+		}
+		r = bytes.NewReader(data)
+	}
 	result = &Profile{}
 	if result.Header, err = parseHeader(r); err == nil && options.Mode < ParseHeaderOnly {
 		if result.TagHeaderTable, err = parseTagHeaders(r); err == nil && options.Mode < ParseHeaderAndTagHeaderTable {
@@ -109,5 +161,8 @@ func ParseProfile(r io.Reader, options *ParseOptions) (result *Profile, err erro
 			result.mapTags()
 		}
 	}
+	if err == nil && options.VerifyProfileID {
+		err = verifyProfileID(data, result.Header.ProfileID)
+	}
 	return result, err
 }
@@ -0,0 +1,293 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RGBProfileBuilder builds matrix/TRC RGB display profiles from chromaticities and a transfer function
+//
+// example:
+//
+//	p, err := (&RGBProfileBuilder{
+//		Red:         XyY{X: 0.64, Y: 0.33},
+//		Green:       XyY{X: 0.30, Y: 0.60},
+//		Blue:        XyY{X: 0.15, Y: 0.06},
+//		White:       XyY{X: 0.3127, Y: 0.3290},
+//		Gamma:       2.2,
+//		Description: "My display",
+//	}).Build()
+type RGBProfileBuilder struct {
+	// Red is the xy chromaticity of the red primary (Luminance is ignored)
+	Red XyY
+	// Green is the xy chromaticity of the green primary (Luminance is ignored)
+	Green XyY
+	// Blue is the xy chromaticity of the blue primary (Luminance is ignored)
+	Blue XyY
+	// White is the xy chromaticity of the white point (Luminance is ignored)
+	White XyY
+	// Gamma is the transfer function gamma (only used if TRC is nil)
+	Gamma float64
+	// TRC is the transfer function as a parametric curve (e.g. the sRGB curve)
+	//
+	// v2 profiles do not support parametric curves - so for v2 the curve is sampled
+	TRC *ParametricCurveTag
+	// Description is the profile description (desc tag)
+	Description string
+	// Copyright is the profile copyright (cprt tag)
+	Copyright string
+	// Version is the profile version - only major versions 2 & 4 are supported (defaults to 4.3.0)
+	Version Version
+	// Created is the profile creation date/time (defaults to now)
+	Created time.Time
+}
+
+// sampledCurvePoints is the number of points used when sampling a parametric curve for v2 profiles
+const sampledCurvePoints = 1024
+
+// Build builds the profile
+//
+// the colorants (rXYZ, gXYZ, bXYZ) are computed from the chromaticities and chromatically adapted (Bradford)
+// from the white point to the PCS illuminant (D50) - the chad tag records the adaptation matrix
+//
+// for v4 profiles, the media white point (wtpt) is the PCS illuminant (D50) and the desc & cprt tags are
+// multi-localized (mluc) - for v2 profiles, the media white point is the actual white point and the desc & cprt
+// tags are desc & text respectively
+func (b *RGBProfileBuilder) Build() (*Profile, error) {
+	version, err := builderVersion(b.Version)
+	if err != nil {
+		return nil, err
+	}
+	white, err := chromaticityXYZ(b.White, "white point")
+	if err != nil {
+		return nil, err
+	}
+	colorants, err := b.colorants(white)
+	if err != nil {
+		return nil, err
+	}
+	adaptation, err := AdaptationBradford.AdaptationMatrix(white, D50)
+	if err != nil {
+		return nil, err
+	}
+	colorants = multiply3x3(adaptation, colorants)
+	trc, err := builderTRC(b.Gamma, b.TRC, version)
+	if err != nil {
+		return nil, err
+	}
+	specs := builderCommonTags(version, b.Description, b.Copyright, white, adaptation)
+	for i, hdr := range []TagHeaderName{TagHeaderRedMatrixColumn, TagHeaderGreenMatrixColumn, TagHeaderBlueMatrixColumn} {
+		xyz := XYZNumber{X: colorants[0][i], Y: colorants[1][i], Z: colorants[2][i]}
+		specs = append(specs, builderTag{TagXYZ, []XYZNumber{xyz}, []TagHeaderName{hdr}})
+	}
+	// the red, green & blue TRCs share the same tag block...
+	trc.headers = []TagHeaderName{TagHeaderRedTRC, TagHeaderGreenTRC, TagHeaderBlueTRC}
+	specs = append(specs, trc)
+	return buildProfile("RGB", version, b.Created, specs)
+}
+
+// GrayProfileBuilder builds gray TRC (monochrome display) profiles from a white point and a transfer function
+type GrayProfileBuilder struct {
+	// White is the xy chromaticity of the white point (Luminance is ignored)
+	White XyY
+	// Gamma is the transfer function gamma (only used if TRC is nil)
+	Gamma float64
+	// TRC is the transfer function as a parametric curve
+	//
+	// v2 profiles do not support parametric curves - so for v2 the curve is sampled
+	TRC *ParametricCurveTag
+	// Description is the profile description (desc tag)
+	Description string
+	// Copyright is the profile copyright (cprt tag)
+	Copyright string
+	// Version is the profile version - only major versions 2 & 4 are supported (defaults to 4.3.0)
+	Version Version
+	// Created is the profile creation date/time (defaults to now)
+	Created time.Time
+}
+
+// Build builds the profile
+//
+// see RGBProfileBuilder.Build for details of the desc, cprt, wtpt & chad tags
+func (b *GrayProfileBuilder) Build() (*Profile, error) {
+	version, err := builderVersion(b.Version)
+	if err != nil {
+		return nil, err
+	}
+	white, err := chromaticityXYZ(b.White, "white point")
+	if err != nil {
+		return nil, err
+	}
+	adaptation, err := AdaptationBradford.AdaptationMatrix(white, D50)
+	if err != nil {
+		return nil, err
+	}
+	trc, err := builderTRC(b.Gamma, b.TRC, version)
+	if err != nil {
+		return nil, err
+	}
+	trc.headers = []TagHeaderName{TagHeaderKTRC}
+	return buildProfile("GRAY", version, b.Created, append(builderCommonTags(version, b.Description, b.Copyright, white, adaptation), trc))
+}
+
+// colorants computes the RGB to XYZ matrix (each column is the XYZ of a primary) for the white point
+func (b *RGBProfileBuilder) colorants(white XYZNumber) ([3][3]float64, error) {
+	var primaries [3][3]float64
+	for i, c := range []struct {
+		xy   XyY
+		name string
+	}{{b.Red, "red"}, {b.Green, "green"}, {b.Blue, "blue"}} {
+		xyz, err := chromaticityXYZ(c.xy, c.name+" primary")
+		if err != nil {
+			return primaries, err
+		}
+		primaries[0][i], primaries[1][i], primaries[2][i] = xyz.X, xyz.Y, xyz.Z
+	}
+	inverse, err := invert3x3(primaries)
+	if err != nil {
+		return primaries, errors.New("invalid primaries: not linearly independent")
+	}
+	scale := apply3x3(inverse, white.array())
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			primaries[row][col] *= scale[col]
+		}
+	}
+	return primaries, nil
+}
+
+// builderTag is a tag (and the tag headers that use it) to be built
+type builderTag struct {
+	typeName TagName
+	value    any
+	headers  []TagHeaderName
+}
+
+// builderTRC returns the TRC tag type & value for the version
+func builderTRC(gamma float64, trc *ParametricCurveTag, version Version) (builderTag, error) {
+	if trc == nil {
+		if gamma <= 0 {
+			return builderTag{}, errors.New("no transfer function (gamma or TRC) specified")
+		}
+		return builderTag{typeName: TagCurve, value: &CurveTag{Type: CurveTypeGamma, Gamma: gamma}}, nil
+	}
+	if expected, ok := parametricParameterCount(trc.FunctionType); !ok || len(trc.Parameters) != expected {
+		return builderTag{}, fmt.Errorf("invalid TRC parametric curve (function type %d)", trc.FunctionType)
+	}
+	if version.Major >= 4 {
+		return builderTag{typeName: TagParametricCurve, value: trc}, nil
+	}
+	points := make([]uint16, sampledCurvePoints)
+	for i := range points {
+		v, err := trc.Transform(float64(i) / (sampledCurvePoints - 1))
+		if err != nil {
+			return builderTag{}, fmt.Errorf("invalid TRC parametric curve: %w", err)
+		}
+		points[i] = encodeNormalizedUint16(v[0])
+	}
+	return builderTag{typeName: TagCurve, value: &CurveTag{Type: CurveTypePoints, Points: points}}, nil
+}
+
+// builderVersion validates the builder profile version (defaulting to 4.3.0)
+func builderVersion(version Version) (Version, error) {
+	if version == (Version{}) {
+		version = Version{Major: 4, Minor: 3}
+	}
+	if version.Major != 2 && version.Major != 4 {
+		return version, fmt.Errorf("unsupported profile version %s", version)
+	}
+	return version, nil
+}
+
+// builderCommonTags returns the desc, cprt, wtpt & chad tags
+func builderCommonTags(version Version, description string, copyright string, white XYZNumber, adaptation [3][3]float64) []builderTag {
+	mediaWhite := D50
+	if version.Major == 2 {
+		mediaWhite = white
+	}
+	return []builderTag{
+		builderText(version, description, TagHeaderDescription),
+		builderText(version, copyright, TagHeaderCopyright),
+		{TagXYZ, []XYZNumber{mediaWhite}, []TagHeaderName{TagHeaderMediaWhitePointTag}},
+		{TagS15Fixed16ArrayType, flatten3x3(adaptation), []TagHeaderName{TagHeaderChromaticAdaptationMatrix}},
+	}
+}
+
+// buildProfile builds a display profile (with an XYZ PCS) from the tags
+func buildProfile(colorSpace string, version Version, created time.Time, tags []builderTag) (*Profile, error) {
+	if created.IsZero() {
+		created = time.Now().UTC().Truncate(time.Second)
+	}
+	result := &Profile{
+		Header: Header{
+			VersionRaw:  version.raw(),
+			Version:     version,
+			DeviceClass: "mntr",
+			ColorSpace:  colorSpace,
+			PCS:         "XYZ",
+			Created:     created,
+			Signature:   "acsp",
+			Illuminant:  d50,
+		},
+	}
+	for _, spec := range tags {
+		tag, err := newEncodedTag(spec.typeName, spec.value, spec.headers...)
+		if err != nil {
+			return nil, err
+		}
+		for range spec.headers {
+			result.TagBlocks = append(result.TagBlocks, tag)
+		}
+	}
+	result.mapTags()
+	return result, nil
+}
+
+// builderText returns the text tag (desc or cprt) for the version
+func builderText(version Version, text string, header TagHeaderName) (result builderTag) {
+	result.headers = []TagHeaderName{header}
+	switch {
+	case version.Major >= 4:
+		result.typeName = TagMultiLocalizedUnicode
+		result.value = &MultiLocalizedTag{Strings: []LocalizedString{{Language: "en", Country: "US", Value: text}}}
+	case header == TagHeaderDescription:
+		result.typeName = TagDescription
+		result.value = &DescriptionTag{ASCII: text}
+	default:
+		result.typeName = TagText
+		result.value = text
+	}
+	return result
+}
+
+// chromaticityXYZ converts an xy chromaticity to XYZ (with Y = 1)
+func chromaticityXYZ(xy XyY, name string) (XYZNumber, error) {
+	if xy.Y <= 0 || xy.X < 0 || xy.X+xy.Y > 1 {
+		return XYZNumber{}, fmt.Errorf("invalid %s chromaticity (%v, %v)", name, xy.X, xy.Y)
+	}
+	return XyY{X: xy.X, Y: xy.Y, Luminance: 1}.ToXYZ(), nil
+}
+
+func flatten3x3(m [3][3]float64) []float64 {
+	return []float64{m[0][0], m[0][1], m[0][2], m[1][0], m[1][1], m[1][2], m[2][0], m[2][1], m[2][2]}
+}
+
+// newEncodedTag creates a tag (for the given tag headers) - encoding the value and then decoding the raw data
+// (so that the tag value reflects the encoded precision)
+func newEncodedTag(typeName TagName, value any, headers ...TagHeaderName) (*Tag, error) {
+	encoder, ok := defaultEncoders[typeName]
+	if !ok {
+		return nil, fmt.Errorf("no encoder for tag %q", typeName)
+	}
+	raw, err := encoder(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tag %q: %w", typeName, err)
+	}
+	result := &Tag{Name: typeName, Raw: raw, decoder: defaultDecoders[typeName]}
+	for _, hdr := range headers {
+		result.Headers = append(result.Headers, TagHeader{Name: hdr})
+	}
+	result.value, result.error = result.decoder(raw)
+	return result, result.error
+}
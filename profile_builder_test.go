@@ -0,0 +1,229 @@
+package iccarus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testSRGBBuilder() *RGBProfileBuilder {
+	return &RGBProfileBuilder{
+		Red:         XyY{X: 0.64, Y: 0.33},
+		Green:       XyY{X: 0.30, Y: 0.60},
+		Blue:        XyY{X: 0.15, Y: 0.06},
+		White:       XyY{X: 0.3127, Y: 0.3290},
+		Gamma:       2.2,
+		Description: "Test RGB",
+		Copyright:   "No copyright",
+		Created:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestRGBProfileBuilder_Build(t *testing.T) {
+	t.Run("v4", func(t *testing.T) {
+		p, err := testSRGBBuilder().Build()
+		require.NoError(t, err)
+		assert.Equal(t, Version{Major: 4, Minor: 3}, p.Header.Version)
+		assert.Equal(t, "mntr", p.Header.DeviceClass)
+		assert.Equal(t, "RGB", p.Header.ColorSpace)
+		assert.Equal(t, "XYZ", p.Header.PCS)
+		assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), p.Header.Created)
+		// colorants (Bradford adapted to D50)...
+		expected := map[TagHeaderName]XYZNumber{
+			TagHeaderRedMatrixColumn:    {X: 0.4361, Y: 0.2225, Z: 0.0139},
+			TagHeaderGreenMatrixColumn:  {X: 0.3851, Y: 0.7169, Z: 0.0971},
+			TagHeaderBlueMatrixColumn:   {X: 0.1431, Y: 0.0606, Z: 0.7141},
+			TagHeaderMediaWhitePointTag: D50,
+		}
+		for hdr, xyz := range expected {
+			val, err := p.TagValue(hdr)
+			require.NoError(t, err)
+			values := val.([]XYZNumber)
+			require.Len(t, values, 1)
+			assert.InDelta(t, xyz.X, values[0].X, 0.0002, hdr)
+			assert.InDelta(t, xyz.Y, values[0].Y, 0.0002, hdr)
+			assert.InDelta(t, xyz.Z, values[0].Z, 0.0002, hdr)
+		}
+		chad, ok, err := p.ChromaticAdaptation()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.InDelta(t, 1.0479, chad[0][0], 0.0002)
+		assert.InDelta(t, 0.7517, chad[2][2], 0.0002)
+		// shared TRC...
+		red, ok := p.TagByHeader(TagHeaderRedTRC)
+		require.True(t, ok)
+		blue, ok := p.TagByHeader(TagHeaderBlueTRC)
+		require.True(t, ok)
+		assert.Same(t, red, blue)
+		trc, err := red.Value()
+		require.NoError(t, err)
+		assert.Equal(t, &CurveTag{Type: CurveTypeGamma, Gamma: 2.19921875}, trc)
+		desc, err := p.TagValue(TagHeaderDescription)
+		require.NoError(t, err)
+		assert.Equal(t, &MultiLocalizedTag{Strings: []LocalizedString{{Language: "en", Country: "US", Value: "Test RGB"}}}, desc)
+		cprt, err := p.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, &MultiLocalizedTag{Strings: []LocalizedString{{Language: "en", Country: "US", Value: "No copyright"}}}, cprt)
+		// white maps to D50 & the original white point is D65...
+		xyz, err := p.ToCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, d50[:], xyz, 0.0005)
+		wp, err := p.OriginalWhitePoint()
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9505, wp.X, 0.0005)
+		assert.InDelta(t, 1.0891, wp.Z, 0.0005)
+	})
+	t.Run("v4 Parametric", func(t *testing.T) {
+		b := testSRGBBuilder()
+		b.TRC = &ParametricCurveTag{FunctionType: SplitFunction, Parameters: []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}}
+		p, err := b.Build()
+		require.NoError(t, err)
+		trc, ok := p.TagByHeader(TagHeaderGreenTRC)
+		require.True(t, ok)
+		assert.Equal(t, TagParametricCurve, trc.Name)
+	})
+	t.Run("v2", func(t *testing.T) {
+		b := testSRGBBuilder()
+		b.Version = Version{Major: 2, Minor: 1}
+		b.TRC = &ParametricCurveTag{FunctionType: SplitFunction, Parameters: []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}}
+		p, err := b.Build()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0x02100000), p.Header.VersionRaw)
+		trc, err := p.TagValue(TagHeaderRedTRC)
+		require.NoError(t, err)
+		curve := trc.(*CurveTag)
+		assert.Equal(t, CurveTypePoints, curve.Type)
+		assert.Len(t, curve.Points, sampledCurvePoints)
+		mid, err := curve.Transform(0.5)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.2140, mid[0], 0.0005)
+		desc, err := p.TagValue(TagHeaderDescription)
+		require.NoError(t, err)
+		assert.Equal(t, &DescriptionTag{ASCII: "Test RGB"}, desc)
+		cprt, err := p.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, "No copyright", cprt)
+		wtpt, err := p.TagValue(TagHeaderMediaWhitePointTag)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.9505, wtpt.([]XYZNumber)[0].X, 0.0005)
+	})
+	t.Run("Round Trip", func(t *testing.T) {
+		p, err := testSRGBBuilder().Build()
+		require.NoError(t, err)
+		data, err := p.MarshalWithOptions(&WriteOptions{ComputeProfileID: true})
+		require.NoError(t, err)
+		p2, err := ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true, ErrorOnTagDecode: true})
+		require.NoError(t, err)
+		assert.Len(t, p2.TagHeaderTable.Entries, 10)
+		assert.Equal(t, "acsp", p2.Header.Signature)
+		for _, block := range p.TagBlocks {
+			for _, hdr := range block.Headers {
+				tag, ok := p2.TagByHeader(hdr.Name)
+				require.True(t, ok)
+				assert.Equal(t, block.Raw, tag.Raw)
+			}
+		}
+		in := []float64{0.2, 0.5, 0.8}
+		xyz, err := p.ToCIEXYZ(in...)
+		require.NoError(t, err)
+		xyz2, err := p2.ToCIEXYZ(in...)
+		require.NoError(t, err)
+		assert.Equal(t, xyz, xyz2)
+	})
+	t.Run("Default Created", func(t *testing.T) {
+		b := testSRGBBuilder()
+		b.Created = time.Time{}
+		p, err := b.Build()
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), p.Header.Created, time.Minute)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		testCases := map[string]struct {
+			modify   func(b *RGBProfileBuilder)
+			expected string
+		}{
+			"Version": {
+				modify:   func(b *RGBProfileBuilder) { b.Version = Version{Major: 3} },
+				expected: "unsupported profile version 3.0.0",
+			},
+			"White": {
+				modify:   func(b *RGBProfileBuilder) { b.White = XyY{X: 0.3127} },
+				expected: "invalid white point chromaticity (0.3127, 0)",
+			},
+			"Green": {
+				modify:   func(b *RGBProfileBuilder) { b.Green = XyY{X: 0.6, Y: 0.6} },
+				expected: "invalid green primary chromaticity (0.6, 0.6)",
+			},
+			"Collinear": {
+				modify:   func(b *RGBProfileBuilder) { b.Green = XyY{X: 0.395, Y: 0.195} },
+				expected: "invalid primaries: not linearly independent",
+			},
+			"No Transfer Function": {
+				modify:   func(b *RGBProfileBuilder) { b.Gamma = 0 },
+				expected: "no transfer function (gamma or TRC) specified",
+			},
+			"Invalid TRC": {
+				modify:   func(b *RGBProfileBuilder) { b.TRC = &ParametricCurveTag{FunctionType: SplitFunction} },
+				expected: "invalid TRC parametric curve (function type 3)",
+			},
+			"Invalid Gamma": {
+				modify:   func(b *RGBProfileBuilder) { b.Gamma = 300 },
+				expected: `failed to encode tag "curv": curv gamma 300 out of range`,
+			},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				b := testSRGBBuilder()
+				tc.modify(b)
+				_, err := b.Build()
+				assert.ErrorContains(t, err, tc.expected)
+			})
+		}
+	})
+}
+
+func TestGrayProfileBuilder_Build(t *testing.T) {
+	t.Run("v4", func(t *testing.T) {
+		p, err := (&GrayProfileBuilder{
+			White:       XyY{X: 0.3457, Y: 0.3585},
+			Gamma:       2.2,
+			Description: "Test Gray",
+		}).Build()
+		require.NoError(t, err)
+		assert.Equal(t, "GRAY", p.Header.ColorSpace)
+		assert.Equal(t, "XYZ", p.Header.PCS)
+		trc, err := p.TagValue(TagHeaderKTRC)
+		require.NoError(t, err)
+		assert.Equal(t, &CurveTag{Type: CurveTypeGamma, Gamma: 2.19921875}, trc)
+		xyz, err := p.ToCIEXYZ(1)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, d50[:], xyz, 0.0005)
+		xyz, err = p.ToCIEXYZ(0.5)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.2179, xyz[1], 0.0005)
+	})
+	t.Run("v2", func(t *testing.T) {
+		p, err := (&GrayProfileBuilder{
+			White:   XyY{X: 0.3127, Y: 0.3290},
+			TRC:     &ParametricCurveTag{FunctionType: SimpleGammaFunction, Parameters: []float64{1.8}},
+			Version: Version{Major: 2, Minor: 1},
+		}).Build()
+		require.NoError(t, err)
+		trc, err := p.TagValue(TagHeaderKTRC)
+		require.NoError(t, err)
+		assert.Len(t, trc.(*CurveTag).Points, sampledCurvePoints)
+		desc, err := p.TagValue(TagHeaderDescription)
+		require.NoError(t, err)
+		assert.Equal(t, &DescriptionTag{}, desc)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := (&GrayProfileBuilder{White: XyY{X: 0.3457, Y: 0.3585}, Gamma: 2.2, Version: Version{Major: 5}}).Build()
+		assert.ErrorContains(t, err, "unsupported profile version 5.0.0")
+		_, err = (&GrayProfileBuilder{Gamma: 2.2}).Build()
+		assert.ErrorContains(t, err, "invalid white point chromaticity (0, 0)")
+		_, err = (&GrayProfileBuilder{White: XyY{X: 0.3457, Y: 0.3585}}).Build()
+		assert.ErrorContains(t, err, "no transfer function (gamma or TRC) specified")
+	})
+}
@@ -0,0 +1,544 @@
+package iccarus
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+)
+
+const (
+	jpegICCSignature = "ICC_PROFILE\x00"
+	// jpegMaxICCChunk is the maximum ICC data per APP2 segment (65535 - 2 length bytes - 14 signature/sequence bytes)
+	jpegMaxICCChunk = 65519
+	pngICCName      = "ICC Profile"
+	tiffTagICC      = 34675
+	webpFlagICC     = 0x20
+	webpFlagAlpha   = 0x10
+)
+
+// EmbedInJPEG copies a .jpeg image (from r to w) with the ICC profile embedded
+//
+// see EmbedRawInJPEG for details
+func EmbedInJPEG(w io.Writer, r io.Reader, profile *Profile) error {
+	data, err := embedProfileData(profile)
+	if err != nil {
+		return err
+	}
+	return rewriteJPEG(w, r, data)
+}
+
+// EmbedRawInJPEG copies a .jpeg image (from r to w) with the ICC profile data embedded
+//
+// the profile data is split into APP2 ICC_PROFILE segments (with sequence numbers) inserted after any
+// APP0/APP1 (JFIF/Exif) segments - any existing ICC profile segments are dropped
+func EmbedRawInJPEG(w io.Writer, r io.Reader, data []byte) error {
+	if err := checkEmbedData(data); err != nil {
+		return err
+	}
+	return rewriteJPEG(w, r, data)
+}
+
+// EmbedInPNG copies a .png image (from r to w) with the ICC profile embedded
+//
+// see EmbedRawInPNG for details
+func EmbedInPNG(w io.Writer, r io.Reader, profile *Profile) error {
+	data, err := embedProfileData(profile)
+	if err != nil {
+		return err
+	}
+	return rewritePNG(w, r, data)
+}
+
+// EmbedRawInPNG copies a .png image (from r to w) with the ICC profile data embedded
+//
+// the profile data is written as a (zlib compressed) iCCP chunk immediately after the IHDR chunk - any existing
+// iCCP chunk and any sRGB chunk (which must not be present alongside an iCCP chunk) are dropped
+func EmbedRawInPNG(w io.Writer, r io.Reader, data []byte) error {
+	if err := checkEmbedData(data); err != nil {
+		return err
+	}
+	return rewritePNG(w, r, data)
+}
+
+// EmbedInTIFF copies a .tif image (from r to w) with the ICC profile embedded
+//
+// see EmbedRawInTIFF for details
+func EmbedInTIFF(w io.Writer, r io.Reader, profile *Profile) error {
+	data, err := embedProfileData(profile)
+	if err != nil {
+		return err
+	}
+	return rewriteTIFF(w, r, data)
+}
+
+// EmbedRawInTIFF copies a .tif image (from r to w) with the ICC profile data embedded
+//
+// every IFD (page) in the main IFD chain is rewritten (at the end of the file) with an ICC profile tag (34675)
+// pointing to the profile data - any existing ICC profile data is zeroed
+//
+// note: the entire image is read into memory (TIFF offsets are absolute)
+func EmbedRawInTIFF(w io.Writer, r io.Reader, data []byte) error {
+	if err := checkEmbedData(data); err != nil {
+		return err
+	}
+	return rewriteTIFF(w, r, data)
+}
+
+// EmbedInWebP copies a .webp image (from r to w) with the ICC profile embedded
+//
+// see EmbedRawInWebP for details
+func EmbedInWebP(w io.Writer, r io.Reader, profile *Profile) error {
+	data, err := embedProfileData(profile)
+	if err != nil {
+		return err
+	}
+	return rewriteWebP(w, r, data)
+}
+
+// EmbedRawInWebP copies a .webp image (from r to w) with the ICC profile data embedded
+//
+// the profile data is written as an ICCP chunk immediately after the VP8X chunk (with the VP8X ICC flag set) - for
+// simple (VP8/VP8L) images, a VP8X chunk is created from the image dimensions - any existing ICCP chunk is dropped
+//
+// note: the entire image is read into memory (the RIFF size must be known before writing)
+func EmbedRawInWebP(w io.Writer, r io.Reader, data []byte) error {
+	if err := checkEmbedData(data); err != nil {
+		return err
+	}
+	return rewriteWebP(w, r, data)
+}
+
+// StripFromJPEG copies a .jpeg image (from r to w) with any embedded ICC profile removed
+//
+// the APP2 ICC_PROFILE segments are dropped - all other segments (and the image data) are copied as is
+//
+// to replace an embedded ICC profile, use EmbedInJPEG (or EmbedRawInJPEG)
+func StripFromJPEG(w io.Writer, r io.Reader) error {
+	return rewriteJPEG(w, r, nil)
+}
+
+// StripFromPNG copies a .png image (from r to w) with any embedded ICC profile removed
+//
+// the iCCP chunk is dropped - all other chunks are copied as is
+//
+// to replace an embedded ICC profile, use EmbedInPNG (or EmbedRawInPNG)
+func StripFromPNG(w io.Writer, r io.Reader) error {
+	return rewritePNG(w, r, nil)
+}
+
+// StripFromTIFF copies a .tif image (from r to w) with any embedded ICC profile removed
+//
+// the ICC profile tag (34675) is removed from every IFD (page) in the main IFD chain (rewritten in place) and the
+// profile data is zeroed
+//
+// to replace an embedded ICC profile, use EmbedInTIFF (or EmbedRawInTIFF)
+//
+// note: the entire image is read into memory (TIFF offsets are absolute)
+func StripFromTIFF(w io.Writer, r io.Reader) error {
+	return rewriteTIFF(w, r, nil)
+}
+
+// StripFromWebP copies a .webp image (from r to w) with any embedded ICC profile removed
+//
+// the ICCP chunk is dropped and the VP8X ICC flag cleared - all other chunks are copied as is
+//
+// to replace an embedded ICC profile, use EmbedInWebP (or EmbedRawInWebP)
+//
+// note: the entire image is read into memory (the RIFF size must be known before writing)
+func StripFromWebP(w io.Writer, r io.Reader) error {
+	return rewriteWebP(w, r, nil)
+}
+
+func embedProfileData(profile *Profile) ([]byte, error) {
+	if profile == nil {
+		return nil, errors.New("no profile to embed")
+	}
+	return profile.Marshal()
+}
+
+func checkEmbedData(data []byte) error {
+	if len(data) < 128 {
+		return errors.New("profile data too short")
+	}
+	return nil
+}
+
+// rewriteJPEG copies a JPEG stream - dropping any ICC profile segments and inserting the ICC profile
+// data (if not nil) after any APP0/APP1 segments
+func rewriteJPEG(w io.Writer, r io.Reader, iccData []byte) error {
+	segments, err := jpegICCSegments(iccData)
+	if err != nil {
+		return err
+	}
+	var marker [2]byte
+	if _, err = io.ReadFull(r, marker[:]); err != nil {
+		return fmt.Errorf("failed to read JPEG SOI: %w", err)
+	} else if marker[0] != 0xFF || marker[1] != 0xD8 {
+		return errors.New("not a JPEG file")
+	}
+	if _, err = w.Write(marker[:]); err != nil {
+		return err
+	}
+	insert := func() error {
+		for _, segment := range segments {
+			if _, err := w.Write(segment); err != nil {
+				return err
+			}
+		}
+		segments = nil
+		return nil
+	}
+	for {
+		if _, err = io.ReadFull(r, marker[:]); err != nil {
+			return fmt.Errorf("failed to read JPEG marker: %w", err)
+		} else if marker[0] != 0xFF {
+			return errors.New("invalid JPEG marker")
+		}
+		switch {
+		case marker[1] == 0xD9:
+			// EOI (with no image data)...
+			if err = insert(); err == nil {
+				_, err = w.Write(marker[:])
+			}
+			return err
+		case marker[1] == 0x01 || (marker[1] >= 0xD0 && marker[1] <= 0xD7):
+			// standalone markers (no length)...
+			if _, err = w.Write(marker[:]); err != nil {
+				return err
+			}
+			continue
+		}
+		var length uint16
+		if err = binary.Read(r, binary.BigEndian, &length); err != nil {
+			return fmt.Errorf("failed to read JPEG segment length: %w", err)
+		} else if length < 2 {
+			return errors.New("invalid JPEG segment length")
+		}
+		data := make([]byte, length-2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("failed to read JPEG segment: %w", err)
+		}
+		if marker[1] == 0xE2 && bytes.HasPrefix(data, []byte(jpegICCSignature)) {
+			continue
+		}
+		if marker[1] != 0xE0 && marker[1] != 0xE1 {
+			if err = insert(); err != nil {
+				return err
+			}
+		}
+		if _, err = w.Write(append(append(marker[:], byte(length>>8), byte(length)), data...)); err != nil {
+			return err
+		}
+		if marker[1] == 0xDA {
+			// SOS - the remainder is entropy coded image data...
+			_, err = io.Copy(w, r)
+			return err
+		}
+	}
+}
+
+// jpegICCSegments splits ICC profile data into APP2 ICC_PROFILE segments
+func jpegICCSegments(data []byte) ([][]byte, error) {
+	if data == nil {
+		return nil, nil
+	}
+	count := (len(data) + jpegMaxICCChunk - 1) / jpegMaxICCChunk
+	if count > 255 {
+		return nil, fmt.Errorf("ICC profile too large for JPEG (%d bytes)", len(data))
+	}
+	result := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		chunk := data[i*jpegMaxICCChunk : min((i+1)*jpegMaxICCChunk, len(data))]
+		length := 2 + len(jpegICCSignature) + 2 + len(chunk)
+		segment := make([]byte, 0, 2+length)
+		segment = append(segment, 0xFF, 0xE2, byte(length>>8), byte(length))
+		segment = append(segment, jpegICCSignature...)
+		segment = append(segment, byte(i+1), byte(count))
+		result = append(result, append(segment, chunk...))
+	}
+	return result, nil
+}
+
+// rewritePNG copies a PNG stream - dropping any iCCP chunk and inserting the ICC profile data (if not nil)
+// as an iCCP chunk after the IHDR chunk (also dropping any sRGB chunk)
+func rewritePNG(w io.Writer, r io.Reader, iccData []byte) error {
+	sig := make([]byte, 8)
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return fmt.Errorf("failed to read PNG signature: %w", err)
+	}
+	if !bytes.Equal(sig, []byte{137, 80, 78, 71, 13, 10, 26, 10}) {
+		return errors.New("not a valid PNG file")
+	}
+	if _, err := w.Write(sig); err != nil {
+		return err
+	}
+	header := make([]byte, 8)
+	for first := true; ; first = false {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return fmt.Errorf("failed to read chunk header: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		chunkType := string(header[4:8])
+		if length > math.MaxInt32 {
+			return fmt.Errorf("invalid PNG chunk %q length", chunkType)
+		} else if first && chunkType != "IHDR" {
+			return errors.New("invalid PNG file (first chunk is not IHDR)")
+		}
+		chunk := make([]byte, 8+length+4)
+		copy(chunk, header)
+		if _, err := io.ReadFull(r, chunk[8:]); err != nil {
+			return fmt.Errorf("failed to read chunk %q: %w", chunkType, err)
+		}
+		if chunkType == "iCCP" || (chunkType == "sRGB" && iccData != nil) {
+			continue
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		switch chunkType {
+		case "IHDR":
+			if iccData != nil {
+				if err := writePNGICCChunk(w, iccData); err != nil {
+					return err
+				}
+			}
+		case "IEND":
+			return nil
+		}
+	}
+}
+
+func writePNGICCChunk(w io.Writer, iccData []byte) error {
+	var data bytes.Buffer
+	data.WriteString(pngICCName)
+	data.Write([]byte{0, 0}) // name terminator & compression method
+	zw := zlib.NewWriter(&data)
+	if _, err := zw.Write(iccData); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(data.Len()))
+	chunk = append(chunk, "iCCP"...)
+	chunk = append(chunk, data.Bytes()...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	_, err := w.Write(chunk)
+	return err
+}
+
+// rewriteTIFF copies a TIFF image - zeroing any existing ICC profile data and rewriting every IFD in the main IFD
+// chain (at the end of the file) with an ICC profile tag pointing to the ICC profile data - or, if the ICC profile
+// data is nil, rewriting each IFD that has an ICC profile tag in place without the tag
+func rewriteTIFF(w io.Writer, r io.Reader, iccData []byte) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read TIFF: %w", err)
+	}
+	type tiffIFD struct {
+		offset  int64
+		end     int64
+		entries [][]byte
+		next    []byte
+		hasICC  bool
+	}
+	var bo binary.ByteOrder
+	var ifds []*tiffIFD
+	var profiles [][2]int64
+	err = walkTIFF(data, func(ifdBo binary.ByteOrder, _ int, offset int64, entries []byte) (bool, error) {
+		bo = ifdBo
+		entriesEnd := offset + 2 + int64(len(entries))
+		ifd := &tiffIFD{offset: offset, end: min(entriesEnd+4, int64(len(data))), next: make([]byte, 4)}
+		copy(ifd.next, data[entriesEnd:ifd.end])
+		for i := 0; i < len(entries); i += 12 {
+			entry := slices.Clone(entries[i : i+12])
+			if bo.Uint16(entry[0:2]) != tiffTagICC {
+				ifd.entries = append(ifd.entries, entry)
+				continue
+			}
+			ifd.hasICC = true
+			// (ICC profile tag is type UNDEFINED - so count is the byte size)
+			if size, offset := int64(bo.Uint32(entry[4:8])), int64(bo.Uint32(entry[8:12])); size > 4 && offset+size <= int64(len(data)) {
+				profiles = append(profiles, [2]int64{offset, offset + size})
+			}
+		}
+		ifds = append(ifds, ifd)
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	// zero the existing profile data (once all IFDs have been read)...
+	for _, profile := range profiles {
+		clear(data[profile[0]:profile[1]])
+	}
+	encodeIFD := func(ifd *tiffIFD, next []byte) []byte {
+		result := make([]byte, 2, 2+12*len(ifd.entries)+4)
+		bo.PutUint16(result, uint16(len(ifd.entries)))
+		for _, entry := range ifd.entries {
+			result = append(result, entry...)
+		}
+		return append(result, next...)
+	}
+	if iccData == nil {
+		// stripping - the (now smaller) IFDs are rewritten in place...
+		for _, ifd := range ifds {
+			if ifd.hasICC {
+				clear(data[ifd.offset:ifd.end])
+				copy(data[ifd.offset:], encodeIFD(ifd, ifd.next))
+			}
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if len(data)%2 != 0 {
+		data = append(data, 0)
+	}
+	// the new IFDs (each with an added ICC profile tag) are written at the end - followed by the profile data...
+	newIFDsOffset := int64(len(data))
+	iccOffset := newIFDsOffset
+	for _, ifd := range ifds {
+		iccOffset += 2 + 12*int64(len(ifd.entries)+1) + 4
+	}
+	if iccOffset+int64(len(iccData)) > math.MaxUint32 {
+		return errors.New("TIFF file too large")
+	}
+	entry := make([]byte, 12)
+	bo.PutUint16(entry[0:2], tiffTagICC)
+	bo.PutUint16(entry[2:4], 7) // UNDEFINED
+	bo.PutUint32(entry[4:8], uint32(len(iccData)))
+	bo.PutUint32(entry[8:12], uint32(iccOffset))
+	for _, ifd := range ifds {
+		at, _ := slices.BinarySearchFunc(ifd.entries, uint16(tiffTagICC), func(e []byte, tag uint16) int {
+			return int(bo.Uint16(e[0:2])) - int(tag)
+		})
+		ifd.entries = slices.Insert(ifd.entries, at, entry)
+	}
+	for i, ifd := range ifds {
+		next := make([]byte, 4)
+		if i < len(ifds)-1 {
+			bo.PutUint32(next, uint32(int64(len(data))+2+12*int64(len(ifd.entries))+4))
+		}
+		data = append(data, encodeIFD(ifd, next)...)
+	}
+	data = append(data, iccData...)
+	bo.PutUint32(data[4:8], uint32(newIFDsOffset))
+	_, err = w.Write(data)
+	return err
+}
+
+// rewriteWebP copies a WebP image - dropping any ICCP chunk and inserting the ICC profile data (if not nil)
+// as an ICCP chunk after the VP8X chunk (creating a VP8X chunk for simple images) - the VP8X ICC flag is updated
+func rewriteWebP(w io.Writer, r io.Reader, iccData []byte) error {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if !bytes.Equal(header[:4], []byte("RIFF")) || !bytes.Equal(header[8:12], []byte("WEBP")) {
+		return errors.New("not a valid WebP (missing RIFF/WEBP headers)")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read WebP: %w", err)
+	}
+	var body bytes.Buffer
+	writeICCP := func() {
+		if iccData != nil {
+			body.Write(webpChunk("ICCP", iccData))
+		}
+	}
+	for pos, first := 0, true; pos < len(data); first = false {
+		if pos+8 > len(data) {
+			return errors.New("failed to read chunk header")
+		}
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if pos+8+size > len(data) {
+			return fmt.Errorf("invalid WebP chunk %q (truncated)", chunkType)
+		}
+		chunkData := data[pos+8 : pos+8+size]
+		pos = min(pos+8+size+size%2, len(data))
+		switch {
+		case chunkType == "ICCP":
+			continue
+		case first && chunkType == "VP8X":
+			if size < 10 {
+				return errors.New("invalid WebP VP8X chunk")
+			}
+			vp8x := slices.Clone(chunkData)
+			if iccData != nil {
+				vp8x[0] |= webpFlagICC
+			} else {
+				vp8x[0] &^= webpFlagICC
+			}
+			body.Write(webpChunk(chunkType, vp8x))
+			writeICCP()
+			continue
+		case first && iccData != nil:
+			vp8x, err := webpVP8X(chunkType, chunkData)
+			if err != nil {
+				return err
+			}
+			body.Write(webpChunk("VP8X", vp8x))
+			writeICCP()
+		}
+		body.Write(webpChunk(chunkType, chunkData))
+	}
+	if uint64(body.Len())+4 > math.MaxUint32 {
+		return errors.New("WebP file too large")
+	}
+	binary.LittleEndian.PutUint32(header[4:8], uint32(body.Len()+4))
+	if _, err = w.Write(header); err == nil {
+		_, err = w.Write(body.Bytes())
+	}
+	return err
+}
+
+// webpVP8X creates VP8X chunk data (with the ICC flag set) from a simple (VP8/VP8L) image chunk
+func webpVP8X(chunkType string, data []byte) ([]byte, error) {
+	var width, height uint32
+	var flags byte = webpFlagICC
+	switch chunkType {
+	case "VP8 ":
+		if len(data) < 10 || !bytes.Equal(data[3:6], []byte{0x9D, 0x01, 0x2A}) {
+			return nil, errors.New("invalid WebP VP8 chunk")
+		}
+		width = uint32(binary.LittleEndian.Uint16(data[6:8]) & 0x3FFF)
+		height = uint32(binary.LittleEndian.Uint16(data[8:10]) & 0x3FFF)
+	case "VP8L":
+		if len(data) < 5 || data[0] != 0x2F {
+			return nil, errors.New("invalid WebP VP8L chunk")
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		width, height = bits&0x3FFF+1, (bits>>14)&0x3FFF+1
+		if bits&(1<<28) != 0 {
+			flags |= webpFlagAlpha
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WebP image chunk %q", chunkType)
+	}
+	if width == 0 || height == 0 {
+		return nil, errors.New("invalid WebP image dimensions")
+	}
+	result := []byte{flags, 0, 0, 0}
+	result = append(result, byte(width-1), byte((width-1)>>8), byte((width-1)>>16))
+	return append(result, byte(height-1), byte((height-1)>>8), byte((height-1)>>16)), nil
+}
+
+// webpChunk returns a RIFF chunk (header, data & padding)
+func webpChunk(chunkType string, data []byte) []byte {
+	result := make([]byte, 0, 8+len(data)+1)
+	result = append(result, chunkType...)
+	result = binary.LittleEndian.AppendUint32(result, uint32(len(data)))
+	result = append(result, data...)
+	if len(data)%2 != 0 {
+		result = append(result, 0)
+	}
+	return result
+}
@@ -0,0 +1,500 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/go-andiamo/iccarus/_test_data/images"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const testEmbedProfile = "default/display-p3-v4-with-v2-desc.icc"
+
+func testImageBytes(t *testing.T, name string) []byte {
+	f, err := images.Open(name)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return data
+}
+
+func testEncodedImage(t *testing.T, encode func(w io.Writer, img image.Image) error) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 32), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, encode(&buf, img))
+	return buf.Bytes()
+}
+
+func testLargeProfile(t *testing.T) ([]byte, string) {
+	text := strings.Repeat("0123456789", 15000)
+	p := testProfile(t, testEmbedProfile)
+	p.SetTag(TagHeaderCopyright, TagText, text)
+	data, err := p.Marshal()
+	require.NoError(t, err)
+	return data, text
+}
+
+func assertEmbedded(t *testing.T, extract func(io.Reader, *ParseOptions) (*Profile, error), data []byte, expect *Profile) *Profile {
+	p, err := extract(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, expect.Header.ProfileID, p.Header.ProfileID)
+	assert.Equal(t, expect.TagHeaderTable, p.TagHeaderTable)
+	return p
+}
+
+func TestEmbedInJPEG(t *testing.T) {
+	profile := testProfile(t, testEmbedProfile)
+	t.Run("Encoded Image", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedInJPEG(&buf, bytes.NewReader(testEncodedImage(t, func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, nil)
+		})), profile)
+		require.NoError(t, err)
+		assertEmbedded(t, ExtractFromJPEG, buf.Bytes(), profile)
+		_, err = jpeg.Decode(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+	})
+	t.Run("Replaces Existing", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedInJPEG(&buf, bytes.NewReader(testImageBytes(t, "marrow_icc.jpeg")), profile)
+		require.NoError(t, err)
+		assertEmbedded(t, ExtractFromJPEG, buf.Bytes(), profile)
+		assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte(jpegICCSignature)))
+		// inserted after the APP0 (JFIF) segment...
+		assert.Equal(t, []byte{0xFF, 0xE0}, buf.Bytes()[2:4])
+		assert.Equal(t, []byte{0xFF, 0xE2}, buf.Bytes()[20:22])
+		_, err = jpeg.Decode(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+	})
+	t.Run("Multiple Segments", func(t *testing.T) {
+		data, text := testLargeProfile(t)
+		var buf bytes.Buffer
+		err := EmbedRawInJPEG(&buf, bytes.NewReader(testImageBytes(t, "marrow_icc.jpeg")), data)
+		require.NoError(t, err)
+		assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte(jpegICCSignature)))
+		p, err := ExtractFromJPEG(bytes.NewReader(buf.Bytes()), nil)
+		require.NoError(t, err)
+		cprt, err := p.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, text, cprt)
+	})
+	t.Run("No Image Data", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedRawInJPEG(&buf, bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xD9}), testProfileBytes(t, testEmbedProfile))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xFF, 0xD8, 0xFF, 0xE2}, buf.Bytes()[:4])
+		assert.Equal(t, []byte{0xFF, 0xD9}, buf.Bytes()[buf.Len()-2:])
+		assertEmbedded(t, ExtractFromJPEG, buf.Bytes(), profile)
+	})
+	t.Run("Too Large", func(t *testing.T) {
+		err := EmbedRawInJPEG(io.Discard, bytes.NewReader([]byte{0xFF, 0xD8}), make([]byte, jpegMaxICCChunk*255+1))
+		assert.ErrorContains(t, err, "ICC profile too large for JPEG")
+	})
+	t.Run("Multi-page", func(t *testing.T) {
+		cmykData := testProfileBytes(t, testPDFOutputProfile)
+		original := testMultiPageTIFF(binary.BigEndian, cmykData, nil, cmykData)
+		var buf bytes.Buffer
+		err := EmbedInTIFF(&buf, bytes.NewReader(original), profile)
+		require.NoError(t, err)
+		// every page has the (same) new profile & the old profile data is zeroed...
+		result, err := ExtractAllFromImage(bytes.NewReader(buf.Bytes()), nil)
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+		for i, extracted := range result {
+			assert.Equal(t, fmt.Sprintf("IFD %d", i), extracted.Location)
+			assert.Equal(t, profile.Header, extracted.Profile.Header)
+		}
+		assert.False(t, bytes.Contains(buf.Bytes(), cmykData[128:]))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		data := testProfileBytes(t, testEmbedProfile)
+		tests := map[string]struct {
+			data    []byte
+			wantErr string
+		}{
+			"Empty":             {[]byte{}, "failed to read JPEG SOI"},
+			"Not a JPEG":        {[]byte{0x00, 0x00}, "not a JPEG file"},
+			"Truncated":         {[]byte{0xFF, 0xD8}, "failed to read JPEG marker"},
+			"Invalid Marker":    {[]byte{0xFF, 0xD8, 0x00, 0x00}, "invalid JPEG marker"},
+			"Missing Length":    {[]byte{0xFF, 0xD8, 0xFF, 0xE0}, "failed to read JPEG segment length"},
+			"Invalid Length":    {[]byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01}, "invalid JPEG segment length"},
+			"Truncated Segment": {[]byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10}, "failed to read JPEG segment"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				err := EmbedRawInJPEG(io.Discard, bytes.NewReader(tt.data), data)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+		err := EmbedRawInJPEG(io.Discard, bytes.NewReader([]byte{0xFF, 0xD8}), data[:100])
+		assert.ErrorContains(t, err, "profile data too short")
+		err = EmbedInJPEG(io.Discard, bytes.NewReader([]byte{0xFF, 0xD8}), nil)
+		assert.ErrorContains(t, err, "no profile to embed")
+	})
+}
+
+func TestEmbedInPNG(t *testing.T) {
+	profile := testProfile(t, testEmbedProfile)
+	t.Run("Encoded Image", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedInPNG(&buf, bytes.NewReader(testEncodedImage(t, png.Encode)), profile)
+		require.NoError(t, err)
+		assertEmbedded(t, ExtractFromPNG, buf.Bytes(), profile)
+		// iCCP immediately follows IHDR...
+		assert.Equal(t, "iCCP", string(buf.Bytes()[8+25+4:8+25+8]))
+		// decoding verifies the chunk CRCs...
+		_, err = png.Decode(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+	})
+	t.Run("Replaces Existing", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedInPNG(&buf, bytes.NewReader(testImageBytes(t, "marrow_icc.png")), profile)
+		require.NoError(t, err)
+		assertEmbedded(t, ExtractFromPNG, buf.Bytes(), profile)
+		assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("iCCP")))
+	})
+	t.Run("Drops sRGB", func(t *testing.T) {
+		encoded := testEncodedImage(t, png.Encode)
+		var src bytes.Buffer
+		src.Write(encoded[:8+25])
+		writeChunk(&src, "sRGB", []byte{0})
+		src.Write(encoded[8+25:])
+		var buf bytes.Buffer
+		err := EmbedRawInPNG(&buf, &src, testProfileBytes(t, testEmbedProfile))
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(buf.Bytes(), []byte("sRGB")))
+		assertEmbedded(t, ExtractFromPNG, buf.Bytes(), profile)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		data := testProfileBytes(t, testEmbedProfile)
+		tests := map[string]struct {
+			data    []byte
+			wantErr string
+		}{
+			"Empty":        {[]byte{}, "failed to read PNG signature"},
+			"Not a PNG":    {[]byte("not a png"), "not a valid PNG file"},
+			"No Chunks":    {validPNGHeader(), "failed to read chunk header"},
+			"Missing IHDR": {append(validPNGHeader(), 0, 0, 0, 0, 'I', 'D', 'A', 'T'), "first chunk is not IHDR"},
+			"Bad Length":   {append(validPNGHeader(), 0xFF, 0, 0, 0, 'I', 'H', 'D', 'R'), `invalid PNG chunk "IHDR" length`},
+			"Truncated":    {append(validPNGHeader(), 0, 0, 0, 13, 'I', 'H', 'D', 'R'), `failed to read chunk "IHDR"`},
+			"Missing IEND": {testEncodedImage(t, png.Encode)[:8+25], "failed to read chunk header"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				err := EmbedRawInPNG(io.Discard, bytes.NewReader(tt.data), data)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+		err := EmbedRawInPNG(io.Discard, bytes.NewReader(validPNGHeader()), nil)
+		assert.ErrorContains(t, err, "profile data too short")
+	})
+}
+
+func testMinimalTIFF(bo binary.ByteOrder) []byte {
+	var buf bytes.Buffer
+	if bo == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	_ = binary.Write(&buf, bo, uint16(42))
+	_ = binary.Write(&buf, bo, uint32(8))
+	// IFD with 2 entries (ImageWidth & ImageLength)...
+	_ = binary.Write(&buf, bo, uint16(2))
+	for _, tag := range []uint16{256, 257} {
+		_ = binary.Write(&buf, bo, []uint16{tag, 3})
+		_ = binary.Write(&buf, bo, []uint32{1, 1})
+	}
+	_ = binary.Write(&buf, bo, uint32(0))
+	buf.WriteByte(0xAB)
+	return buf.Bytes()
+}
+
+func TestEmbedInTIFF(t *testing.T) {
+	profile := testProfile(t, testEmbedProfile)
+	t.Run("Replaces Existing", func(t *testing.T) {
+		original := testImageBytes(t, "marrow_icc.tif")
+		existing, err := ExtractFromTIFF(bytes.NewReader(original), nil)
+		require.NoError(t, err)
+		existingData, err := existing.Marshal()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		err = EmbedInTIFF(&buf, bytes.NewReader(original), profile)
+		require.NoError(t, err)
+		assertEmbedded(t, ExtractFromTIFF, buf.Bytes(), profile)
+		// existing profile data is zeroed...
+		assert.False(t, bytes.Contains(buf.Bytes(), existingData[128:]))
+	})
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run("Minimal "+bo.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := EmbedRawInTIFF(&buf, bytes.NewReader(testMinimalTIFF(bo)), testProfileBytes(t, testEmbedProfile))
+			require.NoError(t, err)
+			data := buf.Bytes()
+			assertEmbedded(t, ExtractFromTIFF, data, profile)
+			// new IFD is written (word aligned) at the end, with entries in tag order...
+			ifd := bo.Uint32(data[4:8])
+			assert.Equal(t, uint32(40), ifd)
+			assert.Equal(t, uint16(3), bo.Uint16(data[ifd:]))
+			assert.Equal(t, uint16(256), bo.Uint16(data[ifd+2:]))
+			assert.Equal(t, uint16(257), bo.Uint16(data[ifd+14:]))
+			assert.Equal(t, uint16(34675), bo.Uint16(data[ifd+26:]))
+			assert.Equal(t, uint16(7), bo.Uint16(data[ifd+28:]))
+			assert.Equal(t, uint32(548), bo.Uint32(data[ifd+30:]))
+			assert.Equal(t, ifd+2+36+4, bo.Uint32(data[ifd+34:]))
+			assert.Len(t, data, int(ifd)+2+36+4+548)
+		})
+	}
+	t.Run("Errors", func(t *testing.T) {
+		data := testProfileBytes(t, testEmbedProfile)
+		tests := map[string]struct {
+			data    []byte
+			wantErr string
+		}{
+			"Empty":          {[]byte{}, "failed to read TIFF header"},
+			"Bad Byte Order": {[]byte("XX*\x00\x08\x00\x00\x00"), "invalid TIFF byte order"},
+			"Missing 42":     {[]byte("II\x00\x00\x08\x00\x00\x00"), "missing 42"},
+			"Bad IFD Offset": {[]byte("II*\x00\xFF\x00\x00\x00"), "failed to seek to IFD"},
+			"Truncated IFD":  {[]byte("II*\x00\x08\x00\x00\x00\x01\x00"), "failed to read IFD entry"},
+			"Circular IFDs":  {[]byte("II*\x00\x08\x00\x00\x00\x00\x00\x08\x00\x00\x00"), "circular TIFF IFD chain"},
+			"Read Error":     {nil, "failed to read TIFF"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				var r io.Reader = bytes.NewReader(tt.data)
+				if tt.data == nil {
+					r = iotest.ErrReader(io.ErrUnexpectedEOF)
+				}
+				err := EmbedRawInTIFF(io.Discard, r, data)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+		err := EmbedRawInTIFF(io.Discard, bytes.NewReader(testMinimalTIFF(binary.LittleEndian)), data[:10])
+		assert.ErrorContains(t, err, "profile data too short")
+	})
+}
+
+func testWebP(chunks ...[]byte) []byte {
+	buf := bytes.NewBuffer(webpHeader())
+	for _, chunk := range chunks {
+		buf.Write(chunk)
+	}
+	return buf.Bytes()
+}
+
+func TestEmbedInWebP(t *testing.T) {
+	profile := testProfile(t, testEmbedProfile)
+	data := testProfileBytes(t, testEmbedProfile)
+	t.Run("Replaces Existing", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EmbedInWebP(&buf, bytes.NewReader(testImageBytes(t, "marrow_icc.webp")), profile)
+		require.NoError(t, err)
+		out := buf.Bytes()
+		assertEmbedded(t, ExtractFromWebP, out, profile)
+		assert.Equal(t, uint32(len(out)-8), binary.LittleEndian.Uint32(out[4:8]))
+		assert.Equal(t, "VP8X", string(out[12:16]))
+		assert.Equal(t, "ICCP", string(out[30:34]))
+		assert.Equal(t, 1, bytes.Count(out, []byte("ICCP")))
+	})
+	t.Run("Sets VP8X Flag", func(t *testing.T) {
+		var buf bytes.Buffer
+		src := testWebP(webpChunk("VP8X", []byte{0x10, 0, 0, 0, 9, 0, 0, 4, 0, 0}), webpChunk("VP8L", []byte{0x2F, 1, 2, 3, 4}))
+		err := EmbedRawInWebP(&buf, bytes.NewReader(src), data)
+		require.NoError(t, err)
+		out := buf.Bytes()
+		assertEmbedded(t, ExtractFromWebP, out, profile)
+		assert.Equal(t, []byte{0x30, 0, 0, 0, 9, 0, 0, 4, 0, 0}, out[20:30])
+		assert.Equal(t, "ICCP", string(out[30:34]))
+	})
+	t.Run("Creates VP8X (VP8L)", func(t *testing.T) {
+		var buf bytes.Buffer
+		// 100 x 50 with alpha...
+		bits := uint32(99) | uint32(49)<<14 | 1<<28
+		vp8l := binary.LittleEndian.AppendUint32([]byte{0x2F}, bits)
+		err := EmbedRawInWebP(&buf, bytes.NewReader(testWebP(webpChunk("VP8L", append(vp8l, 0)))), data)
+		require.NoError(t, err)
+		out := buf.Bytes()
+		assertEmbedded(t, ExtractFromWebP, out, profile)
+		assert.Equal(t, uint32(len(out)-8), binary.LittleEndian.Uint32(out[4:8]))
+		assert.Equal(t, "VP8X", string(out[12:16]))
+		assert.Equal(t, []byte{0x30, 0, 0, 0, 99, 0, 0, 49, 0, 0}, out[20:30])
+		assert.Equal(t, "ICCP", string(out[30:34]))
+		// original image chunk (with padding) goes last...
+		assert.Equal(t, webpChunk("VP8L", append(vp8l, 0)), out[len(out)-14:])
+	})
+	t.Run("Creates VP8X (VP8)", func(t *testing.T) {
+		var buf bytes.Buffer
+		// 640 x 480...
+		vp8 := []byte{0, 0, 0, 0x9D, 0x01, 0x2A, 0x80, 0x02, 0xE0, 0x01}
+		err := EmbedRawInWebP(&buf, bytes.NewReader(testWebP(webpChunk("VP8 ", vp8))), data)
+		require.NoError(t, err)
+		out := buf.Bytes()
+		assertEmbedded(t, ExtractFromWebP, out, profile)
+		assert.Equal(t, []byte{0x20, 0, 0, 0, 0x7F, 0x02, 0, 0xDF, 0x01, 0}, out[20:30])
+	})
+	t.Run("Errors", func(t *testing.T) {
+		tests := map[string]struct {
+			data    []byte
+			wantErr string
+		}{
+			"Empty":             {[]byte{}, "failed to read RIFF header"},
+			"Not a WebP":        {[]byte("RIFFxxxxXXXX"), "not a valid WebP"},
+			"Short Chunk":       {testWebP([]byte{1, 2, 3}), "failed to read chunk header"},
+			"Truncated Chunk":   {testWebP([]byte("VP8L\x10\x00\x00\x00")), `invalid WebP chunk "VP8L" (truncated)`},
+			"Short VP8X":        {testWebP(webpChunk("VP8X", []byte{0})), "invalid WebP VP8X chunk"},
+			"Invalid VP8":       {testWebP(webpChunk("VP8 ", []byte{0})), "invalid WebP VP8 chunk"},
+			"Invalid VP8L":      {testWebP(webpChunk("VP8L", []byte{0})), "invalid WebP VP8L chunk"},
+			"Zero Dimensions":   {testWebP(webpChunk("VP8 ", []byte{0, 0, 0, 0x9D, 0x01, 0x2A, 0, 0, 0, 0})), "invalid WebP image dimensions"},
+			"Unknown Chunk":     {testWebP(webpChunk("ANIM", []byte{0})), `unsupported WebP image chunk "ANIM"`},
+			"Read Error (RIFF)": {nil, "failed to read WebP"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				var r io.Reader = bytes.NewReader(tt.data)
+				if tt.data == nil {
+					r = io.MultiReader(bytes.NewReader(webpHeader()), iotest.ErrReader(io.ErrUnexpectedEOF))
+				}
+				err := EmbedRawInWebP(io.Discard, r, data)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+		err := EmbedRawInWebP(io.Discard, bytes.NewReader(testWebP()), nil)
+		assert.ErrorContains(t, err, "profile data too short")
+	})
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		image   string
+		strip   func(io.Writer, io.Reader) error
+		extract func(io.Reader, *ParseOptions) (*Profile, error)
+		check   func(t *testing.T, original, stripped []byte)
+	}{
+		{
+			image:   "marrow_icc.jpeg",
+			strip:   StripFromJPEG,
+			extract: ExtractFromJPEG,
+			check: func(t *testing.T, original, stripped []byte) {
+				assert.False(t, bytes.Contains(stripped, []byte(jpegICCSignature)))
+				_, err := jpeg.Decode(bytes.NewReader(stripped))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			image:   "marrow_icc.png",
+			strip:   StripFromPNG,
+			extract: ExtractFromPNG,
+			check: func(t *testing.T, original, stripped []byte) {
+				assert.False(t, bytes.Contains(stripped, []byte("iCCP")))
+				_, err := png.Decode(bytes.NewReader(stripped))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			image:   "marrow_icc.tif",
+			strip:   StripFromTIFF,
+			extract: ExtractFromTIFF,
+			check: func(t *testing.T, original, stripped []byte) {
+				// same size (IFD rewritten in place)...
+				require.Len(t, stripped, len(original))
+				ifd := binary.LittleEndian.Uint32(stripped[4:8])
+				assert.Equal(t, binary.LittleEndian.Uint32(original[4:8]), ifd)
+				assert.Equal(t, binary.LittleEndian.Uint16(original[ifd:])-1, binary.LittleEndian.Uint16(stripped[ifd:]))
+				existing, err := ExtractFromTIFF(bytes.NewReader(original), nil)
+				require.NoError(t, err)
+				data, err := existing.Marshal()
+				require.NoError(t, err)
+				assert.False(t, bytes.Contains(stripped, data[128:]))
+			},
+		},
+		{
+			image:   "marrow_icc.webp",
+			strip:   StripFromWebP,
+			extract: ExtractFromWebP,
+			check: func(t *testing.T, original, stripped []byte) {
+				assert.False(t, bytes.Contains(stripped, []byte("ICCP")))
+				assert.Equal(t, uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:8]))
+				assert.Equal(t, "VP8X", string(stripped[12:16]))
+				assert.Equal(t, byte(0), stripped[20]&webpFlagICC)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			original := testImageBytes(t, tt.image)
+			var buf bytes.Buffer
+			err := tt.strip(&buf, bytes.NewReader(original))
+			require.NoError(t, err)
+			_, err = tt.extract(bytes.NewReader(buf.Bytes()), nil)
+			assert.ErrorContains(t, err, "no ICC profile found")
+			tt.check(t, original, buf.Bytes())
+			// stripping again is a no-op...
+			var again bytes.Buffer
+			err = tt.strip(&again, bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, buf.Bytes(), again.Bytes())
+		})
+	}
+	t.Run("Multi-page TIFF", func(t *testing.T) {
+		rgbData := testProfileBytes(t, testEmbedProfile)
+		original := testMultiPageTIFF(binary.LittleEndian, rgbData, nil, rgbData)
+		var buf bytes.Buffer
+		err := StripFromTIFF(&buf, bytes.NewReader(original))
+		require.NoError(t, err)
+		stripped := buf.Bytes()
+		require.Len(t, stripped, len(original))
+		_, err = ExtractAllFromImage(bytes.NewReader(stripped), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		assert.False(t, bytes.Contains(stripped, rgbData[128:]))
+		// the chain is intact (three IFDs with the 2 remaining entries)...
+		pages := 0
+		err = walkTIFF(stripped, func(bo binary.ByteOrder, ifd int, offset int64, entries []byte) (bool, error) {
+			pages++
+			assert.Len(t, entries, 24)
+			return true, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, pages)
+	})
+	t.Run("Keeps PNG sRGB", func(t *testing.T) {
+		encoded := testEncodedImage(t, png.Encode)
+		var src bytes.Buffer
+		src.Write(encoded[:8+25])
+		writeChunk(&src, "sRGB", []byte{0})
+		src.Write(encoded[8+25:])
+		var buf bytes.Buffer
+		err := StripFromPNG(&buf, bytes.NewReader(src.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, src.Bytes(), buf.Bytes())
+	})
+	t.Run("Keeps Simple WebP", func(t *testing.T) {
+		src := testWebP(webpChunk("VP8L", []byte{0x2F, 1, 2, 3, 4}))
+		var buf bytes.Buffer
+		err := StripFromWebP(&buf, bytes.NewReader(src))
+		require.NoError(t, err)
+		assert.Equal(t, webpChunk("VP8L", []byte{0x2F, 1, 2, 3, 4}), buf.Bytes()[12:])
+	})
+	t.Run("Errors", func(t *testing.T) {
+		assert.ErrorContains(t, StripFromJPEG(io.Discard, bytes.NewReader(nil)), "failed to read JPEG SOI")
+		assert.ErrorContains(t, StripFromPNG(io.Discard, bytes.NewReader(nil)), "failed to read PNG signature")
+		assert.ErrorContains(t, StripFromTIFF(io.Discard, bytes.NewReader(nil)), "failed to read TIFF header")
+		assert.ErrorContains(t, StripFromWebP(io.Discard, bytes.NewReader(nil)), "failed to read RIFF header")
+	})
+}
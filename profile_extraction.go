@@ -49,7 +49,7 @@ func ExtractFromJPEG(r io.Reader, options *ParseOptions) (result *Profile, err e
 	}
 	if err == nil {
 		if len(chunks) == 0 {
-			return nil, errors.New("no ICC profile found")
+			return nil, ErrNoICCProfile
 		}
 		combined := make([]byte, 0, totalLen)
 		for i := 1; i <= len(chunks); i++ {
@@ -65,58 +65,102 @@ func ExtractFromJPEG(r io.Reader, options *ParseOptions) (result *Profile, err e
 }
 
 // ExtractFromTIFF extracts ICC profile from a .tif image
-func ExtractFromTIFF(r io.Reader, options *ParseOptions) (*Profile, error) {
-	const (
-		tagICCProfile = 34675
-	)
-	header := make([]byte, 8)
-	if _, err := io.ReadFull(r, header); err != nil {
-		return nil, fmt.Errorf("failed to read TIFF header: %w", err)
+//
+// the profile is taken from the first IFD (page) that has an ICC profile - use ExtractAllFromImage to extract the
+// profiles of all IFDs
+//
+// note: the entire image is read into memory (TIFF offsets are absolute)
+func ExtractFromTIFF(r io.Reader, options *ParseOptions) (result *Profile, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TIFF: %w", err)
+	}
+	err = readTIFFProfiles(data, func(ifd int, iccData []byte) (bool, error) {
+		result, err = ParseProfile(bytes.NewReader(iccData), options)
+		return false, err
+	})
+	if err == nil && result == nil {
+		err = ErrNoICCProfile
+	}
+	return result, err
+}
+
+// walkTIFF walks the main IFD chain of a TIFF - calling fn with the byte order, IFD index, IFD offset and the IFD
+// entries (12 bytes each) of each IFD (the walk stops when fn returns false or an error)
+func walkTIFF(data []byte, fn func(bo binary.ByteOrder, ifd int, offset int64, entries []byte) (bool, error)) error {
+	if len(data) < 8 {
+		return errors.New("failed to read TIFF header")
 	}
 	var bo binary.ByteOrder
-	switch string(header[:2]) {
+	switch string(data[:2]) {
 	case "II":
 		bo = binary.LittleEndian
 	case "MM":
 		bo = binary.BigEndian
 	default:
-		return nil, errors.New("invalid TIFF byte order")
-	}
-	if bo.Uint16(header[2:4]) != 42 {
-		return nil, errors.New("not a valid TIFF file (missing 42)")
+		return errors.New("invalid TIFF byte order")
 	}
-	ifdOffset := bo.Uint32(header[4:8])
-	if _, err := io.CopyN(io.Discard, r, int64(ifdOffset-8)); err != nil {
-		return nil, fmt.Errorf("failed to seek to IFD: %w", err)
+	if bo.Uint16(data[2:4]) != 42 {
+		return errors.New("not a valid TIFF file (missing 42)")
 	}
-	var count uint16
-	if err := binary.Read(r, bo, &count); err != nil {
-		return nil, fmt.Errorf("failed to read IFD entry count: %w", err)
-	}
-	var iccOffset, iccLength uint32
-	entry := make([]byte, 12)
-	for i := 0; i < int(count); i++ {
-		if _, err := io.ReadFull(r, entry); err != nil {
-			return nil, fmt.Errorf("failed to read IFD entry: %w", err)
+	size := int64(len(data))
+	visited := make(map[uint32]bool)
+	for ifd, offset := 0, bo.Uint32(data[4:8]); offset != 0; ifd++ {
+		if visited[offset] {
+			return fmt.Errorf("circular TIFF IFD chain (IFD %d)", ifd)
 		}
-		tag := bo.Uint16(entry[0:2])
-		if tag == tagICCProfile {
-			iccLength = bo.Uint32(entry[4:8])
-			iccOffset = bo.Uint32(entry[8:12])
-			break
+		visited[offset] = true
+		if int64(offset) > size {
+			return fmt.Errorf("failed to seek to IFD: %w", io.ErrUnexpectedEOF)
+		} else if int64(offset)+2 > size {
+			return fmt.Errorf("failed to read IFD entry count: %w", io.ErrUnexpectedEOF)
 		}
+		entriesEnd := int64(offset) + 2 + 12*int64(bo.Uint16(data[offset:]))
+		if entriesEnd > size {
+			return fmt.Errorf("failed to read IFD entry: %w", io.ErrUnexpectedEOF)
+		}
+		// (tolerate a missing next IFD offset)
+		next := uint32(0)
+		if entriesEnd+4 <= size {
+			next = bo.Uint32(data[entriesEnd:])
+		}
+		if more, err := fn(bo, ifd, int64(offset), data[offset+2:entriesEnd]); err != nil || !more {
+			return err
+		}
+		offset = next
 	}
-	if iccLength == 0 {
-		return nil, errors.New("no ICC profile found")
-	}
-	if _, err := io.CopyN(io.Discard, r, int64(iccOffset)-(int64(ifdOffset)+2+12*int64(count))); err != nil {
-		return nil, fmt.Errorf("failed to seek to ICC profile: %w", err)
-	}
-	iccData := make([]byte, iccLength)
-	if _, err := io.ReadFull(r, iccData); err != nil {
-		return nil, fmt.Errorf("failed to read ICC profile: %w", err)
-	}
-	return ParseProfile(bytes.NewReader(iccData), options)
+	return nil
+}
+
+// readTIFFProfiles walks the main IFD chain of a TIFF - calling fn with the IFD index and ICC profile data of each
+// IFD that has an ICC profile (the walk stops when fn returns false or an error)
+func readTIFFProfiles(data []byte, fn func(ifd int, iccData []byte) (bool, error)) error {
+	size := int64(len(data))
+	return walkTIFF(data, func(bo binary.ByteOrder, ifd int, _ int64, entries []byte) (bool, error) {
+		for i := 0; i+12 <= len(entries); i += 12 {
+			entry := entries[i : i+12]
+			if bo.Uint16(entry[0:2]) != tiffTagICC {
+				continue
+			}
+			iccLength := int64(bo.Uint32(entry[4:8]))
+			if iccLength == 0 {
+				break
+			}
+			// (values of 4 bytes or less are held in the entry)
+			iccData := entry[8:12]
+			if iccLength > 4 {
+				iccOffset := int64(bo.Uint32(entry[8:12]))
+				if iccOffset > size {
+					return false, fmt.Errorf("failed to seek to ICC profile: %w", io.ErrUnexpectedEOF)
+				} else if iccOffset+iccLength > size {
+					return false, fmt.Errorf("failed to read ICC profile: %w", io.ErrUnexpectedEOF)
+				}
+				iccData = data[iccOffset : iccOffset+iccLength]
+			}
+			return fn(ifd, iccData[:iccLength])
+		}
+		return true, nil
+	})
 }
 
 // ExtractFromPNG extracts ICC profile from a .png image
@@ -161,7 +205,7 @@ func ExtractFromPNG(r io.Reader, options *ParseOptions) (*Profile, error) {
 			break
 		}
 	}
-	return nil, errors.New("no ICC profile found")
+	return nil, ErrNoICCProfile
 }
 
 func decompressZlib(data []byte) ([]byte, error) {
@@ -211,5 +255,5 @@ func ExtractFromWebP(r io.Reader, options *ParseOptions) (*Profile, error) {
 			return nil, fmt.Errorf("failed to skip chunk %q: %w", chunkType, err)
 		}
 	}
-	return nil, errors.New("no ICC profile found")
+	return nil, ErrNoICCProfile
 }
@@ -0,0 +1,142 @@
+package iccarus
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ExtractedProfile is an ICC profile extracted by ExtractAllFromImage - along with where it was found
+type ExtractedProfile struct {
+	Profile *Profile
+	// Format is the image format (e.g. "tiff", "heif", "pdf")
+	Format string
+	// Location is where in the image the profile was found (e.g. "IFD 1", "property 3", "object 12") - empty for
+	// formats that can only carry a single profile
+	Location string
+	// Index is the index of the profile within the image
+	Index int
+}
+
+// multiProfileExtractors are the extractors for image formats that can contain more than one ICC profile (keyed by
+// image format name)
+var multiProfileExtractors = map[string]func(r io.Reader, options *ParseOptions) ([]*ExtractedProfile, error){
+	"tiff": extractAllFromTIFF,
+	"heif": extractAllFromHEIF,
+}
+
+// ExtractAllFromImage extracts all ICC profiles from an image - detecting the image format from its magic bytes
+//
+// for multi-page .tif images the profile of every IFD (page) is extracted, for .heic/.heif/.avif images the profile
+// of every item, and for PDF documents every ICCBased/output intent profile - for all other formats (including
+// those added using RegisterImageFormat) there is at most one profile
+//
+// OpenEXR images are not supported - OpenEXR has no standard attribute for an ICC profile (color is described by the
+// chromaticities attribute)
+//
+// returns ErrUnsupportedImageFormat if the image format is not recognised, or ErrNoICCProfile if the image
+// does not contain any ICC profiles (use errors.Is to check)
+func ExtractAllFromImage(r io.Reader, options *ParseOptions) ([]*ExtractedProfile, error) {
+	br := bufio.NewReader(r)
+	if b, _ := br.Peek(1024); isPDF(b) {
+		return extractAllFromPDF(br, options)
+	}
+	format, ok := sniffImageFormat(br)
+	if !ok {
+		return nil, ErrUnsupportedImageFormat
+	}
+	if extract, ok := multiProfileExtractors[format.name]; ok {
+		return extract(br, options)
+	}
+	p, err := format.extract(br, options)
+	if err != nil {
+		return nil, err
+	}
+	return []*ExtractedProfile{{Profile: p, Format: format.name}}, nil
+}
+
+func extractAllFromPDF(r io.Reader, options *ParseOptions) ([]*ExtractedProfile, error) {
+	profiles, err := ExtractFromPDF(r, options)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ExtractedProfile, 0, len(profiles))
+	for i, p := range profiles {
+		result = append(result, &ExtractedProfile{
+			Profile:  p.Profile,
+			Format:   "pdf",
+			Location: fmt.Sprintf("object %d", p.ObjectNumber),
+			Index:    i,
+		})
+	}
+	return result, nil
+}
+
+// extractAllFromTIFF extracts the ICC profiles from every IFD in the main IFD chain of a .tif image
+//
+// note: the entire image is read into memory (TIFF offsets are absolute)
+func extractAllFromTIFF(r io.Reader, options *ParseOptions) ([]*ExtractedProfile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TIFF: %w", err)
+	}
+	result := make([]*ExtractedProfile, 0)
+	err = readTIFFProfiles(data, func(ifd int, iccData []byte) (bool, error) {
+		p, err := ParseProfile(bytes.NewReader(iccData), options)
+		if err != nil {
+			return false, fmt.Errorf("TIFF IFD %d: %w", ifd, err)
+		}
+		result = append(result, &ExtractedProfile{Profile: p, Format: "tiff", Location: fmt.Sprintf("IFD %d", ifd), Index: len(result)})
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	} else if len(result) == 0 {
+		return nil, ErrNoICCProfile
+	}
+	return result, nil
+}
+
+// extractAllFromHEIF extracts the ICC profiles from every colr property of a .heic/.heif or .avif image
+func extractAllFromHEIF(r io.Reader, options *ParseOptions) ([]*ExtractedProfile, error) {
+	meta, err := readHEIFMeta(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta) < 4 {
+		return nil, errors.New("invalid HEIF meta box")
+	}
+	boxes, err := isoBoxes(meta[4:]) // skip FullBox version & flags
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEIF meta box: %w", err)
+	}
+	result := make([]*ExtractedProfile, 0)
+	for _, box := range boxes {
+		if box.typ != "iprp" {
+			continue
+		}
+		properties, _, err := heifProperties(box.data)
+		if err != nil {
+			return nil, err
+		}
+		for i, property := range properties {
+			if property.typ != "colr" || len(property.data) < 4 {
+				continue
+			}
+			if colorType := string(property.data[:4]); colorType == "prof" || colorType == "rICC" {
+				p, err := ParseProfile(bytes.NewReader(property.data[4:]), options)
+				if err != nil {
+					return nil, fmt.Errorf("HEIF property %d: %w", i+1, err)
+				}
+				// (property numbers are 1-based - as used by ipma associations)
+				result = append(result, &ExtractedProfile{Profile: p, Format: "heif", Location: fmt.Sprintf("property %d", i+1), Index: len(result)})
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrNoICCProfile
+	}
+	return result, nil
+}
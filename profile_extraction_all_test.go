@@ -0,0 +1,207 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"testing"
+)
+
+// testMultiPageTIFF builds a TIFF with an IFD (page) for each of the profiles (a nil profile is a page without
+// an ICC profile tag)
+func testMultiPageTIFF(bo binary.ByteOrder, profiles ...[]byte) []byte {
+	var buf bytes.Buffer
+	if bo == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	_ = binary.Write(&buf, bo, uint16(42))
+	_ = binary.Write(&buf, bo, uint32(8))
+	for i, profile := range profiles {
+		count := uint16(2)
+		if profile != nil {
+			count++
+		}
+		ifdEnd := buf.Len() + 2 + 12*int(count) + 4
+		_ = binary.Write(&buf, bo, count)
+		for _, tag := range []uint16{256, 257} {
+			_ = binary.Write(&buf, bo, []uint16{tag, 3})
+			_ = binary.Write(&buf, bo, []uint32{1, 1})
+		}
+		next := ifdEnd
+		if profile != nil {
+			_ = binary.Write(&buf, bo, []uint16{tiffTagICC, 7})
+			_ = binary.Write(&buf, bo, []uint32{uint32(len(profile)), uint32(ifdEnd)})
+			next += len(profile)
+		}
+		if i == len(profiles)-1 {
+			next = 0
+		}
+		_ = binary.Write(&buf, bo, uint32(next))
+		buf.Write(profile)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractAllFromImage(t *testing.T) {
+	rgbData := testProfileBytes(t, testEmbedProfile)
+	cmykData := testProfileBytes(t, testPDFOutputProfile)
+	rgb := testProfile(t, testEmbedProfile)
+	cmyk := testProfile(t, testPDFOutputProfile)
+	t.Run("Multi-page TIFF", func(t *testing.T) {
+		for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			t.Run(bo.String(), func(t *testing.T) {
+				data := testMultiPageTIFF(bo, rgbData, nil, cmykData)
+				result, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+				require.NoError(t, err)
+				require.Len(t, result, 2)
+				assert.Equal(t, "tiff", result[0].Format)
+				assert.Equal(t, "IFD 0", result[0].Location)
+				assert.Equal(t, 0, result[0].Index)
+				assert.Equal(t, rgb.Header, result[0].Profile.Header)
+				assert.Equal(t, "tiff", result[1].Format)
+				assert.Equal(t, "IFD 2", result[1].Location)
+				assert.Equal(t, 1, result[1].Index)
+				assert.Equal(t, cmyk.Header, result[1].Profile.Header)
+				// ExtractFromTIFF returns the first IFD with a profile...
+				p, err := ExtractFromTIFF(bytes.NewReader(data), nil)
+				require.NoError(t, err)
+				assert.Equal(t, rgb.Header, p.Header)
+			})
+		}
+	})
+	t.Run("TIFF First Page Without Profile", func(t *testing.T) {
+		data := testMultiPageTIFF(binary.BigEndian, nil, cmykData, rgbData)
+		p, err := ExtractFromTIFF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, cmyk.Header, p.Header)
+	})
+	t.Run("TIFF Circular IFD Chain", func(t *testing.T) {
+		data := testMultiPageTIFF(binary.LittleEndian, nil)
+		binary.LittleEndian.PutUint32(data[len(data)-4:], 8)
+		_, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+		assert.ErrorContains(t, err, "circular TIFF IFD chain (IFD 1)")
+		_, err = ExtractFromTIFF(bytes.NewReader(data), nil)
+		assert.ErrorContains(t, err, "circular TIFF IFD chain (IFD 1)")
+	})
+	t.Run("TIFF Inline Profile Data", func(t *testing.T) {
+		// (a profile can't be 4 bytes or less - but the value is read inline)
+		data := testMultiPageTIFF(binary.LittleEndian, nil)
+		data = append(data[:8], 3, 0)
+		data = binary.LittleEndian.AppendUint16(data, tiffTagICC)
+		data = binary.LittleEndian.AppendUint16(data, 7)
+		data = append(data, 4, 0, 0, 0, 'a', 'c', 's', 'p')
+		data = append(data, make([]byte, 12*2+4)...)
+		_, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+		assert.ErrorContains(t, err, "TIFF IFD 0")
+	})
+	t.Run("HEIF Items", func(t *testing.T) {
+		data := testHEIF("heic", 1, [][]byte{
+			testHEIFColr("nclx", testNCLX),
+			testHEIFColr("prof", rgbData),
+			testHEIFColr("rICC", cmykData),
+		}, map[uint16][]byte{1: {1, 2}, 2: {3}})
+		result, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "heif", result[0].Format)
+		assert.Equal(t, "property 2", result[0].Location)
+		assert.Equal(t, rgb.Header, result[0].Profile.Header)
+		assert.Equal(t, "property 3", result[1].Location)
+		assert.Equal(t, 1, result[1].Index)
+		assert.Equal(t, cmyk.Header, result[1].Profile.Header)
+	})
+	t.Run("PDF", func(t *testing.T) {
+		result, err := ExtractAllFromImage(bytes.NewReader(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...)), nil)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "pdf", result[0].Format)
+		assert.Equal(t, "object 4", result[0].Location)
+		assert.Equal(t, rgb.Header, result[0].Profile.Header)
+		assert.Equal(t, "object 5", result[1].Location)
+		assert.Equal(t, 1, result[1].Index)
+		assert.Equal(t, cmyk.Header, result[1].Profile.Header)
+	})
+	t.Run("PDF Leading Junk", func(t *testing.T) {
+		// (same detection as ExtractFromPDF - %PDF- anywhere in the first 1024 bytes)
+		data := append([]byte("junk\n"), testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...)...)
+		result, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "object 4", result[0].Location)
+		_, err = ExtractAllFromImage(bytes.NewReader(append(bytes.Repeat([]byte(" "), 1024), testPDF()...)), nil)
+		assert.ErrorIs(t, err, ErrUnsupportedImageFormat)
+	})
+	t.Run("Single Profile Formats", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"jpeg": testImageBytes(t, "marrow_icc.jpeg"),
+			"png":  testImageBytes(t, "marrow_icc.png"),
+			"webp": testImageBytes(t, "marrow_icc.webp"),
+			"gif":  testGIF(t, testGIFApplication(gifICCApplication, rgbData, 255)),
+			"bmp":  testBMP(BMPProfileEmbedded, rgbData),
+		} {
+			t.Run(name, func(t *testing.T) {
+				result, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+				require.NoError(t, err)
+				require.Len(t, result, 1)
+				assert.Equal(t, name, result[0].Format)
+				assert.Empty(t, result[0].Location)
+				assert.Equal(t, 0, result[0].Index)
+				assert.NotNil(t, result[0].Profile)
+			})
+		}
+	})
+	t.Run("Options", func(t *testing.T) {
+		result, err := ExtractAllFromImage(bytes.NewReader(testMultiPageTIFF(binary.LittleEndian, rgbData, rgbData)), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Empty(t, result[1].Profile.TagBlocks)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"tiff": testMultiPageTIFF(binary.BigEndian, nil, nil),
+			"heif": testHEIF("avif", 1, [][]byte{testHEIFColr("nclx", testNCLX)}, map[uint16][]byte{1: {1}}),
+			"pdf":  testPDF("<< /Type /Catalog >>"),
+			"png":  testEncodedImage(t, png.Encode),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+				assert.ErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := ExtractAllFromImage(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")), nil)
+		assert.ErrorIs(t, err, ErrUnsupportedImageFormat)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		valid := testMultiPageTIFF(binary.LittleEndian, rgbData, rgbData)
+		secondIFD := 8 + 2 + 12*3 + 4 + len(rgbData)
+		bad := func(pos int, v uint32) []byte {
+			data := bytes.Clone(valid)
+			binary.LittleEndian.PutUint32(data[pos:], v)
+			return data
+		}
+		for name, data := range map[string][]byte{
+			"TIFF Short Header":    valid[:6],
+			"TIFF Bad IFD Offset":  bad(4, 0xffff),
+			"TIFF Truncated IFD":   valid[:20],
+			"TIFF Circular":        bad(8+2+12*3, 8),
+			"TIFF Bad ICC Offset":  bad(8+2+12*2+8, 0xffffff),
+			"TIFF Invalid Profile": bad(secondIFD+2+12*2+4, 200),
+			"HEIF Invalid Profile": testHEIF("heic", 1, [][]byte{testHEIFColr("prof", rgbData[:100])}, map[uint16][]byte{1: {1}}),
+			"HEIF No Meta":         testISOBox("ftyp", []byte("heic"), []byte{0, 0, 0, 0}),
+			"PDF Invalid Profile":  testPDF("<< /CS [/ICCBased 2 0 R] >>", testPDFStream("", []byte("not a profile"), false)),
+			"JPEG Invalid":         {0xff, 0xd8, 0xff, 0xe2},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractAllFromImage(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	bmpSignature        = "BM"
+	bmpFileHeaderLength = 14
+	bmpV5HeaderLength   = 124
+)
+
+// BMPProfileKind is the kind of color profile in a BITMAPV5HEADER (the bV5CSType value)
+type BMPProfileKind uint32
+
+const (
+	// BMPProfileEmbedded is PROFILE_EMBEDDED - the profile data is embedded in the file
+	BMPProfileEmbedded BMPProfileKind = 0x4D424544 // 'MBED'
+	// BMPProfileLinked is PROFILE_LINKED - the profile data is the file name of a linked profile
+	BMPProfileLinked BMPProfileKind = 0x4C494E4B // 'LINK'
+)
+
+func (k BMPProfileKind) String() string {
+	switch k {
+	case BMPProfileEmbedded:
+		return "Embedded"
+	case BMPProfileLinked:
+		return "Linked"
+	}
+	return fmt.Sprintf("Unknown (0x%08x)", uint32(k))
+}
+
+// BMPProfile is the color profile of a .bmp image (with a BITMAPV5HEADER)
+type BMPProfile struct {
+	Kind BMPProfileKind
+	// Profile is the embedded ICC profile (nil if the profile is linked)
+	Profile *Profile
+	// LinkedFile is the file name of the linked profile (empty if the profile is embedded) - decoded from code page 1252
+	LinkedFile string
+}
+
+// ExtractFromBMP extracts the color profile from a .bmp image with a BITMAPV5HEADER
+//
+// an embedded profile (PROFILE_EMBEDDED) is parsed - for a linked profile (PROFILE_LINKED) only the linked file
+// name is reported
+//
+// returns ErrNoICCProfile if the image does not have a BITMAPV5HEADER or the header specifies neither an
+// embedded nor a linked profile
+func ExtractFromBMP(r io.Reader, options *ParseOptions) (*BMPProfile, error) {
+	header := make([]byte, bmpFileHeaderLength+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read BMP header: %w", err)
+	}
+	if string(header[:2]) != bmpSignature {
+		return nil, errors.New("not a valid BMP (missing BM signature)")
+	}
+	infoSize := binary.LittleEndian.Uint32(header[bmpFileHeaderLength:])
+	if infoSize < bmpV5HeaderLength {
+		// (only BITMAPV5HEADER has profile information)
+		return nil, ErrNoICCProfile
+	}
+	info := make([]byte, bmpV5HeaderLength)
+	copy(info, header[bmpFileHeaderLength:])
+	if _, err := io.ReadFull(r, info[4:]); err != nil {
+		return nil, fmt.Errorf("failed to read BMP info header: %w", err)
+	}
+	kind := BMPProfileKind(binary.LittleEndian.Uint32(info[56:]))
+	if kind != BMPProfileEmbedded && kind != BMPProfileLinked {
+		return nil, ErrNoICCProfile
+	}
+	// (the profile offset is relative to the start of the info header)
+	profileOffset := int64(binary.LittleEndian.Uint32(info[112:]))
+	profileSize := int64(binary.LittleEndian.Uint32(info[116:]))
+	if profileSize == 0 {
+		return nil, errors.New("invalid BMP profile size")
+	} else if profileOffset < bmpV5HeaderLength {
+		return nil, errors.New("invalid BMP profile offset")
+	}
+	if _, err := io.CopyN(io.Discard, r, profileOffset-bmpV5HeaderLength); err != nil {
+		return nil, fmt.Errorf("failed to skip to BMP profile data: %w", err)
+	}
+	// (read through a limit reader rather than allocating the untrusted profile size up front)
+	data, err := io.ReadAll(io.LimitReader(r, profileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BMP profile data: %w", err)
+	} else if int64(len(data)) < profileSize {
+		return nil, fmt.Errorf("failed to read BMP profile data: %w", io.ErrUnexpectedEOF)
+	}
+	result := &BMPProfile{Kind: kind}
+	if kind == BMPProfileLinked {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			data = data[:i]
+		}
+		result.LinkedFile = decodeCP1252(data)
+		return result, nil
+	}
+	if result.Profile, err = ParseProfile(bytes.NewReader(data), options); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extractProfileFromBMP is the ImageExtractor for .bmp images - a linked profile is reported as ErrNoICCProfile
+func extractProfileFromBMP(r io.Reader, options *ParseOptions) (*Profile, error) {
+	result, err := ExtractFromBMP(r, options)
+	if err != nil {
+		return nil, err
+	} else if result.Profile == nil {
+		return nil, fmt.Errorf("%w (linked profile %q)", ErrNoICCProfile, result.LinkedFile)
+	}
+	return result.Profile, nil
+}
+
+// cp1252High maps the code page 1252 bytes 0x80-0x9F to runes (undefined bytes map to the equivalent C1 control)
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeCP1252 decodes code page 1252 (Windows Latin 1) text - all bytes outside 0x80-0x9F map to the same code point
+func decodeCP1252(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		if b >= 0x80 && b <= 0x9F {
+			runes[i] = cp1252High[b-0x80]
+		} else {
+			runes[i] = rune(b)
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,129 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// testBMP builds a 1x1 24-bit .bmp image with a BITMAPV5HEADER - with the profile data following the pixel data
+func testBMP(kind BMPProfileKind, profileData []byte) []byte {
+	const pixelsOffset = bmpFileHeaderLength + bmpV5HeaderLength
+	pixels := []byte{0x80, 0x40, 0x20, 0}
+	var buf bytes.Buffer
+	buf.WriteString(bmpSignature)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(pixelsOffset+len(pixels)+len(profileData)))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(pixelsOffset))
+	info := make([]byte, bmpV5HeaderLength)
+	binary.LittleEndian.PutUint32(info[0:], bmpV5HeaderLength)
+	binary.LittleEndian.PutUint32(info[4:], 1)  // width
+	binary.LittleEndian.PutUint32(info[8:], 1)  // height
+	binary.LittleEndian.PutUint16(info[12:], 1) // planes
+	binary.LittleEndian.PutUint16(info[14:], 24)
+	binary.LittleEndian.PutUint32(info[20:], uint32(len(pixels)))
+	binary.LittleEndian.PutUint32(info[56:], uint32(kind))
+	binary.LittleEndian.PutUint32(info[108:], 4) // LCS_GM_IMAGES
+	if profileData != nil {
+		binary.LittleEndian.PutUint32(info[112:], uint32(bmpV5HeaderLength+len(pixels)))
+		binary.LittleEndian.PutUint32(info[116:], uint32(len(profileData)))
+	}
+	buf.Write(info)
+	buf.Write(pixels)
+	buf.Write(profileData)
+	return buf.Bytes()
+}
+
+func TestExtractFromBMP(t *testing.T) {
+	iccData := testProfileBytes(t, testEmbedProfile)
+	expect := testProfile(t, testEmbedProfile)
+	t.Run("Embedded", func(t *testing.T) {
+		result, err := ExtractFromBMP(bytes.NewReader(testBMP(BMPProfileEmbedded, iccData)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, BMPProfileEmbedded, result.Kind)
+		assert.Empty(t, result.LinkedFile)
+		require.NotNil(t, result.Profile)
+		assert.Equal(t, expect.Header, result.Profile.Header)
+		assert.Equal(t, expect.TagHeaderTable, result.Profile.TagHeaderTable)
+	})
+	t.Run("Linked", func(t *testing.T) {
+		result, err := ExtractFromBMP(bytes.NewReader(testBMP(BMPProfileLinked, []byte("C:\\Windows\\System32\\spool\\drivers\\color\\sRGB.icm\x00\x00"))), nil)
+		require.NoError(t, err)
+		assert.Equal(t, BMPProfileLinked, result.Kind)
+		assert.Nil(t, result.Profile)
+		assert.Equal(t, "C:\\Windows\\System32\\spool\\drivers\\color\\sRGB.icm", result.LinkedFile)
+	})
+	t.Run("Linked Code Page 1252", func(t *testing.T) {
+		result, err := ExtractFromBMP(bytes.NewReader(testBMP(BMPProfileLinked, []byte("C:\\Profils\\caf\xe9 \x80 \x96 \x9f.icc\x00"))), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "C:\\Profils\\café € – Ÿ.icc", result.LinkedFile)
+	})
+	t.Run("Options", func(t *testing.T) {
+		result, err := ExtractFromBMP(bytes.NewReader(testBMP(BMPProfileEmbedded, iccData)), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		assert.Empty(t, result.Profile.TagBlocks)
+	})
+	t.Run("ExtractFromImage", func(t *testing.T) {
+		p, err := ExtractFromImage(bytes.NewReader(testBMP(BMPProfileEmbedded, iccData)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+		_, err = ExtractFromImage(bytes.NewReader(testBMP(BMPProfileLinked, []byte("profile.icc"))), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		assert.ErrorContains(t, err, `"profile.icc"`)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		infoHeader := testBMP(BMPProfileEmbedded, nil)
+		binary.LittleEndian.PutUint32(infoHeader[bmpFileHeaderLength:], 40) // BITMAPINFOHEADER
+		for name, data := range map[string][]byte{
+			"sRGB":             testBMP(0x73524742, nil),
+			"Calibrated":       testBMP(0, nil),
+			"BITMAPINFOHEADER": infoHeader[:bmpFileHeaderLength+40],
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromBMP(bytes.NewReader(data), nil)
+				assert.ErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		valid := testBMP(BMPProfileEmbedded, iccData)
+		bad := func(pos int, v uint32) []byte {
+			data := bytes.Clone(valid)
+			binary.LittleEndian.PutUint32(data[pos:], v)
+			return data
+		}
+		for name, data := range map[string][]byte{
+			"Empty":           {},
+			"Short Header":    valid[:10],
+			"Not BMP":         append([]byte("BA"), valid[2:]...),
+			"Short Info":      valid[:bmpFileHeaderLength+60],
+			"Zero Size":       bad(bmpFileHeaderLength+116, 0),
+			"Bad Offset":      bad(bmpFileHeaderLength+112, 100),
+			"Offset Past End": bad(bmpFileHeaderLength+112, 10000),
+			"Short Profile":   valid[:len(valid)-10],
+			"Huge Profile":    bad(bmpFileHeaderLength+116, 0xffffffff),
+			"Invalid Profile": testBMP(BMPProfileEmbedded, iccData[:100]),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromBMP(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
+
+func TestBMPProfileKind_String(t *testing.T) {
+	assert.Equal(t, "Embedded", BMPProfileEmbedded.String())
+	assert.Equal(t, "Linked", BMPProfileLinked.String())
+	assert.Equal(t, "Unknown (0x73524742)", BMPProfileKind(0x73524742).String())
+}
+
+func TestDecodeCP1252(t *testing.T) {
+	assert.Equal(t, "", decodeCP1252(nil))
+	assert.Equal(t, "abc", decodeCP1252([]byte("abc")))
+	assert.Equal(t, "\u0081\u008d\u008f\u0090\u009d", decodeCP1252([]byte{0x81, 0x8d, 0x8f, 0x90, 0x9d}))
+	assert.Equal(t, "ÿ ñ ‰", decodeCP1252([]byte{0xff, ' ', 0xf1, ' ', 0x89}))
+}
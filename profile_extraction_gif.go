@@ -0,0 +1,143 @@
+package iccarus
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	gifSignature87a     = "GIF87a"
+	gifSignature89a     = "GIF89a"
+	gifICCApplication   = "ICCRGBG1012"
+	gifExtension        = 0x21
+	gifImageDescriptor  = 0x2C
+	gifTrailer          = 0x3B
+	gifApplicationLabel = 0xFF
+)
+
+// ExtractFromGIF extracts ICC profile from a .gif image
+//
+// the profile is taken from the ICCRGBG1012 application extension (with the profile data split across its sub-blocks)
+func ExtractFromGIF(r io.Reader, options *ParseOptions) (*Profile, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read GIF header: %w", err)
+	}
+	if sig := string(header[:6]); sig != gifSignature87a && sig != gifSignature89a {
+		return nil, errors.New("not a valid GIF (missing GIF87a/GIF89a signature)")
+	}
+	if err := skipGIFColorTable(br, header[10]); err != nil {
+		return nil, err
+	}
+	for {
+		introducer, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				// (tolerate a missing trailer)
+				break
+			}
+			return nil, fmt.Errorf("failed to read GIF block: %w", err)
+		}
+		switch introducer {
+		case gifTrailer:
+			return nil, ErrNoICCProfile
+		case gifExtension:
+			label, err := br.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read GIF extension label: %w", err)
+			}
+			if label == gifApplicationLabel {
+				var appId []byte
+				if appId, err = readGIFSubBlock(br); err != nil {
+					return nil, fmt.Errorf("failed to read GIF application extension: %w", err)
+				}
+				if string(appId) == gifICCApplication {
+					iccData, err := readGIFSubBlocks(br)
+					if err != nil {
+						return nil, fmt.Errorf("failed to read GIF ICC profile: %w", err)
+					}
+					return ParseProfile(bytes.NewReader(iccData), options)
+				} else if len(appId) == 0 {
+					// (the application block was only a terminator)
+					continue
+				}
+			}
+			if err = skipGIFSubBlocks(br); err != nil {
+				return nil, fmt.Errorf("failed to skip GIF extension: %w", err)
+			}
+		case gifImageDescriptor:
+			descriptor := make([]byte, 9)
+			if _, err = io.ReadFull(br, descriptor); err != nil {
+				return nil, fmt.Errorf("failed to read GIF image descriptor: %w", err)
+			}
+			if err = skipGIFColorTable(br, descriptor[8]); err != nil {
+				return nil, err
+			}
+			// LZW minimum code size & image data...
+			if _, err = br.ReadByte(); err != nil {
+				return nil, fmt.Errorf("failed to read GIF image data: %w", err)
+			}
+			if err = skipGIFSubBlocks(br); err != nil {
+				return nil, fmt.Errorf("failed to skip GIF image data: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid GIF block introducer 0x%02x", introducer)
+		}
+	}
+	return nil, ErrNoICCProfile
+}
+
+// skipGIFColorTable skips the (global or local) color table - if the packed fields indicate one is present
+func skipGIFColorTable(r io.Reader, packed byte) error {
+	if packed&0x80 != 0 {
+		if _, err := io.CopyN(io.Discard, r, 3<<((packed&0x07)+1)); err != nil {
+			return fmt.Errorf("failed to skip GIF color table: %w", err)
+		}
+	}
+	return nil
+}
+
+// readGIFSubBlock reads a single data sub-block (an empty result is the block terminator)
+func readGIFSubBlock(r *bufio.Reader) ([]byte, error) {
+	size, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readGIFSubBlocks reads and concatenates data sub-blocks (up to the block terminator)
+func readGIFSubBlocks(r *bufio.Reader) ([]byte, error) {
+	var result []byte
+	for {
+		data, err := readGIFSubBlock(r)
+		if err != nil {
+			return nil, err
+		} else if len(data) == 0 {
+			return result, nil
+		}
+		result = append(result, data...)
+	}
+}
+
+func skipGIFSubBlocks(r *bufio.Reader) error {
+	for {
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		} else if size == 0 {
+			return nil
+		}
+		if _, err = r.Discard(int(size)); err != nil {
+			return err
+		}
+	}
+}
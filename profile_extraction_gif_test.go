@@ -0,0 +1,132 @@
+package iccarus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/gif"
+	"io"
+	"testing"
+)
+
+// testGIFSubBlocks splits data into sub-blocks (of at most blockSize) followed by the block terminator
+func testGIFSubBlocks(data []byte, blockSize int) []byte {
+	var buf bytes.Buffer
+	for len(data) > 0 {
+		n := min(len(data), blockSize)
+		buf.WriteByte(byte(n))
+		buf.Write(data[:n])
+		data = data[n:]
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+// testGIFApplication builds an application extension block
+func testGIFApplication(appId string, data []byte, blockSize int) []byte {
+	return append(append([]byte{gifExtension, gifApplicationLabel, byte(len(appId))}, appId...), testGIFSubBlocks(data, blockSize)...)
+}
+
+// testGIF encodes a GIF image and inserts the extension blocks immediately after the header (and global color table)
+func testGIF(t *testing.T, blocks ...[]byte) []byte {
+	encode := func(w io.Writer, img image.Image) error {
+		return gif.Encode(w, img, &gif.Options{NumColors: 16})
+	}
+	data := testEncodedImage(t, encode)
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << ((data[10] & 0x07) + 1)
+	}
+	result := bytes.Clone(data[:pos])
+	for _, block := range blocks {
+		result = append(result, block...)
+	}
+	return append(result, data[pos:]...)
+}
+
+func TestExtractFromGIF(t *testing.T) {
+	iccData := testProfileBytes(t, testEmbedProfile)
+	expect := testProfile(t, testEmbedProfile)
+	netscape := testGIFApplication("NETSCAPE2.0", []byte{1, 0, 0}, 255)
+	comment := []byte{gifExtension, 0xFE, 5, 'h', 'e', 'l', 'l', 'o', 0}
+	t.Run("Extract", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"Sub-blocks 255": testGIF(t, testGIFApplication(gifICCApplication, iccData, 255)),
+			"Sub-blocks 1":   testGIF(t, testGIFApplication(gifICCApplication, iccData, 1)),
+			"After Others":   testGIF(t, netscape, comment, testGIFApplication(gifICCApplication, iccData, 100)),
+		} {
+			t.Run(name, func(t *testing.T) {
+				p, err := ExtractFromGIF(bytes.NewReader(data), nil)
+				require.NoError(t, err)
+				assert.Equal(t, expect.Header, p.Header)
+				assert.Equal(t, expect.TagHeaderTable, p.TagHeaderTable)
+			})
+		}
+	})
+	t.Run("After Image", func(t *testing.T) {
+		data := testGIF(t)
+		data = append(data[:len(data)-1], testGIFApplication(gifICCApplication, iccData, 255)...)
+		data = append(data, gifTrailer)
+		p, err := ExtractFromGIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("GIF87a & Local Color Table", func(t *testing.T) {
+		data := []byte("GIF87a\x01\x00\x01\x00\x00\x00\x00")
+		data = append(data, gifImageDescriptor, 0, 0, 0, 0, 1, 0, 1, 0, 0x80, 0, 0, 0, 0xff, 0xff, 0xff, 2, 2, 0x4c, 0x01, 0)
+		data = append(data, testGIFApplication(gifICCApplication, iccData, 255)...)
+		p, err := ExtractFromGIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("Options", func(t *testing.T) {
+		p, err := ExtractFromGIF(bytes.NewReader(testGIF(t, testGIFApplication(gifICCApplication, iccData, 255))), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		assert.Empty(t, p.TagBlocks)
+	})
+	t.Run("ExtractFromImage", func(t *testing.T) {
+		p, err := ExtractFromImage(bytes.NewReader(testGIF(t, netscape, testGIFApplication(gifICCApplication, iccData, 255))), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		noTrailer := testGIF(t, netscape)
+		for name, data := range map[string][]byte{
+			"Plain":      testGIF(t),
+			"Others":     testGIF(t, netscape, comment, []byte{gifExtension, gifApplicationLabel, 0}),
+			"No Trailer": noTrailer[:len(noTrailer)-1],
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromGIF(bytes.NewReader(data), nil)
+				assert.ErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		valid := testGIF(t, comment, testGIFApplication(gifICCApplication, iccData, 255))
+		pos := bytes.Index(valid, comment)
+		for name, data := range map[string][]byte{
+			"Empty":              {},
+			"Short Header":       valid[:10],
+			"Not GIF":            []byte("GIF90a\x01\x00\x01\x00\x00\x00\x00"),
+			"Short Color Table":  valid[:20],
+			"Bad Introducer":     append(bytes.Clone(valid[:pos]), 0x99),
+			"Short Extension":    valid[:pos+1],
+			"Short Comment":      valid[:pos+4],
+			"Short App Id":       valid[:pos+len(comment)+5],
+			"Short ICC":          valid[:pos+len(comment)+100],
+			"Invalid Profile":    testGIF(t, testGIFApplication(gifICCApplication, iccData[:100], 255)),
+			"Short Image Desc":   append([]byte("GIF87a\x01\x00\x01\x00\x00\x00\x00"), gifImageDescriptor, 0, 0),
+			"Short Local Colors": append([]byte("GIF87a\x01\x00\x01\x00\x00\x00\x00"), gifImageDescriptor, 0, 0, 0, 0, 1, 0, 1, 0, 0x80, 0),
+			"Short Image LZW":    append([]byte("GIF87a\x01\x00\x01\x00\x00\x00\x00"), gifImageDescriptor, 0, 0, 0, 0, 1, 0, 1, 0, 0),
+			"Short Image Data":   append([]byte("GIF87a\x01\x00\x01\x00\x00\x00\x00"), gifImageDescriptor, 0, 0, 0, 0, 1, 0, 1, 0, 0, 2, 5, 1),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromGIF(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
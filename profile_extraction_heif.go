@@ -0,0 +1,286 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// NCLXColor is the nclx (CICP) color information of a HEIF/AVIF image
+//
+// the code points are as defined in ISO/IEC 23091-2 (e.g. ColorPrimaries 1 is BT.709, TransferCharacteristics 13 is sRGB)
+type NCLXColor struct {
+	ColorPrimaries          uint16
+	TransferCharacteristics uint16
+	MatrixCoefficients      uint16
+	FullRange               bool
+}
+
+// HEIFColor is the color information of a HEIF/HEIC or AVIF image
+type HEIFColor struct {
+	// Profile is the embedded ICC profile (nil if the image has no ICC profile)
+	Profile *Profile
+	// NCLX is the nclx color information (nil if the image has no nclx color information)
+	NCLX *NCLXColor
+}
+
+// heifBrands are the ftyp major brands of HEIF/HEIC & AVIF images
+var heifBrands = []string{"heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif", "avis"}
+
+// ExtractFromHEIF extracts ICC profile from a .heic/.heif or .avif image
+//
+// returns ErrNoICCProfile if the image has no ICC profile - use ExtractColorFromHEIF to obtain nclx color
+// information for such images
+func ExtractFromHEIF(r io.Reader, options *ParseOptions) (*Profile, error) {
+	color, err := ExtractColorFromHEIF(r, options)
+	if err != nil {
+		return nil, err
+	} else if color.Profile == nil {
+		return nil, ErrNoICCProfile
+	}
+	return color.Profile, nil
+}
+
+// ExtractColorFromHEIF extracts the color information (ICC profile and/or nclx) from a .heic/.heif or .avif image
+//
+// the color information is read from the colr boxes (in meta/iprp/ipco) associated with the primary item - if
+// there is no primary item (pitm) box, the first colr boxes found are used
+//
+// returns ErrNoICCProfile if the image has neither an ICC profile nor nclx color information
+func ExtractColorFromHEIF(r io.Reader, options *ParseOptions) (*HEIFColor, error) {
+	meta, err := readHEIFMeta(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta) < 4 {
+		return nil, errors.New("invalid HEIF meta box")
+	}
+	boxes, err := isoBoxes(meta[4:]) // skip FullBox version & flags
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEIF meta box: %w", err)
+	}
+	var primary uint32
+	hasPrimary := false
+	var properties []isoBox
+	var associations map[uint32][]int
+	for _, box := range boxes {
+		switch box.typ {
+		case "pitm":
+			if primary, err = heifPrimaryItem(box.data); err != nil {
+				return nil, err
+			}
+			hasPrimary = true
+		case "iprp":
+			if properties, associations, err = heifProperties(box.data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	candidates := properties
+	if hasPrimary {
+		// (a primary item with no property associations has no color information - the properties of other items,
+		// e.g. thumbnails, are not used)
+		indices := associations[primary]
+		candidates = make([]isoBox, 0, len(indices))
+		for _, index := range indices {
+			if index > 0 && index <= len(properties) {
+				candidates = append(candidates, properties[index-1])
+			}
+		}
+	}
+	result := &HEIFColor{}
+	for _, box := range candidates {
+		if box.typ != "colr" || len(box.data) < 4 {
+			continue
+		}
+		switch string(box.data[:4]) {
+		case "prof", "rICC":
+			if result.Profile == nil {
+				if result.Profile, err = ParseProfile(bytes.NewReader(box.data[4:]), options); err != nil {
+					return nil, err
+				}
+			}
+		case "nclx":
+			if result.NCLX == nil {
+				if len(box.data) < 11 {
+					return nil, errors.New("invalid HEIF nclx colr box")
+				}
+				result.NCLX = &NCLXColor{
+					ColorPrimaries:          binary.BigEndian.Uint16(box.data[4:6]),
+					TransferCharacteristics: binary.BigEndian.Uint16(box.data[6:8]),
+					MatrixCoefficients:      binary.BigEndian.Uint16(box.data[8:10]),
+					FullRange:               box.data[10]&0x80 != 0,
+				}
+			}
+		}
+	}
+	if result.Profile == nil && result.NCLX == nil {
+		return nil, ErrNoICCProfile
+	}
+	return result, nil
+}
+
+// readHEIFMeta reads the top-level boxes of a HEIF image (the first must be ftyp) - returning the meta box data
+func readHEIFMeta(r io.Reader) ([]byte, error) {
+	for first := true; ; first = false {
+		typ, size, err := readISOBoxHeader(r)
+		if err == io.EOF && !first {
+			return nil, errors.New("invalid HEIF file (no meta box)")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read box header: %w", err)
+		}
+		if first && typ != "ftyp" {
+			return nil, errors.New("not a valid HEIF file (missing ftyp box)")
+		}
+		if typ == "meta" {
+			if size < 0 {
+				size = math.MaxInt64
+			}
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil || (size != math.MaxInt64 && int64(len(data)) != size) {
+				return nil, errors.New("failed to read meta box")
+			}
+			return data, nil
+		} else if size < 0 {
+			return nil, errors.New("invalid HEIF file (no meta box)")
+		}
+		if _, err = io.CopyN(io.Discard, r, size); err != nil {
+			return nil, fmt.Errorf("failed to skip box %q: %w", typ, err)
+		}
+	}
+}
+
+// heifPrimaryItem returns the item ID of a pitm box
+func heifPrimaryItem(data []byte) (uint32, error) {
+	switch {
+	case len(data) >= 6 && data[0] == 0:
+		return uint32(binary.BigEndian.Uint16(data[4:6])), nil
+	case len(data) >= 8 && data[0] != 0:
+		return binary.BigEndian.Uint32(data[4:8]), nil
+	}
+	return 0, errors.New("invalid HEIF pitm box")
+}
+
+// heifProperties returns the properties (ipco children) and the item property associations (ipma, 1-based
+// property indices by item ID) of an iprp box
+func heifProperties(data []byte) (properties []isoBox, associations map[uint32][]int, err error) {
+	boxes, err := isoBoxes(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid HEIF iprp box: %w", err)
+	}
+	associations = make(map[uint32][]int)
+	for _, box := range boxes {
+		switch box.typ {
+		case "ipco":
+			if properties, err = isoBoxes(box.data); err != nil {
+				return nil, nil, fmt.Errorf("invalid HEIF ipco box: %w", err)
+			}
+		case "ipma":
+			if err = heifAssociations(box.data, associations); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return properties, associations, nil
+}
+
+// heifAssociations reads the item property associations of an ipma box
+func heifAssociations(data []byte, associations map[uint32][]int) error {
+	invalid := errors.New("invalid HEIF ipma box")
+	if len(data) < 8 {
+		return invalid
+	}
+	version, flags := data[0], data[3]
+	count := binary.BigEndian.Uint32(data[4:8])
+	pos := 8
+	for i := uint32(0); i < count; i++ {
+		var item uint32
+		if version < 1 {
+			if pos+3 > len(data) {
+				return invalid
+			}
+			item = uint32(binary.BigEndian.Uint16(data[pos:]))
+			pos += 2
+		} else {
+			if pos+5 > len(data) {
+				return invalid
+			}
+			item = binary.BigEndian.Uint32(data[pos:])
+			pos += 4
+		}
+		n := int(data[pos])
+		pos++
+		for j := 0; j < n; j++ {
+			if flags&1 != 0 {
+				if pos+2 > len(data) {
+					return invalid
+				}
+				associations[item] = append(associations[item], int(binary.BigEndian.Uint16(data[pos:])&0x7FFF))
+				pos += 2
+			} else {
+				if pos+1 > len(data) {
+					return invalid
+				}
+				associations[item] = append(associations[item], int(data[pos]&0x7F))
+				pos++
+			}
+		}
+	}
+	return nil
+}
+
+// isoBox is an ISO base media file format (ISO-BMFF) box
+type isoBox struct {
+	typ  string
+	data []byte
+}
+
+// readISOBoxHeader reads an ISO-BMFF box header - returning the box type and the box data size
+// (-1 if the box extends to the end of the file)
+func readISOBoxHeader(r io.Reader) (string, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", 0, err
+	}
+	typ := string(header[4:8])
+	size := int64(binary.BigEndian.Uint32(header[0:4]))
+	switch size {
+	case 0:
+		return typ, -1, nil
+	case 1:
+		if _, err := io.ReadFull(r, header); err != nil {
+			return "", 0, err
+		}
+		if size = int64(binary.BigEndian.Uint64(header)); size < 16 {
+			return "", 0, fmt.Errorf("invalid box %q size", typ)
+		}
+		return typ, size - 16, nil
+	}
+	if size < 8 {
+		return "", 0, fmt.Errorf("invalid box %q size", typ)
+	}
+	return typ, size - 8, nil
+}
+
+// isoBoxes splits data into ISO-BMFF boxes
+func isoBoxes(data []byte) ([]isoBox, error) {
+	result := make([]isoBox, 0)
+	for r := bytes.NewReader(data); r.Len() > 0; {
+		typ, size, err := readISOBoxHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			size = int64(r.Len())
+		} else if size > int64(r.Len()) {
+			return nil, fmt.Errorf("box %q exceeds its container", typ)
+		}
+		pos := len(data) - r.Len()
+		result = append(result, isoBox{typ: typ, data: data[pos : pos+int(size)]})
+		_, _ = r.Seek(size, io.SeekCurrent)
+	}
+	return result, nil
+}
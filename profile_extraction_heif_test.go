@@ -0,0 +1,185 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func testISOBox(typ string, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(body))), append([]byte(typ), body...)...)
+}
+
+func testHEIFColr(colorType string, data []byte) []byte {
+	return testISOBox("colr", []byte(colorType), data)
+}
+
+var testNCLX = []byte{0, 12, 0, 16, 0, 0, 0x80}
+
+// testHEIF builds a HEIF image - with properties (ipco) and associations of item ID to 1-based property indices
+// (there is no pitm box if primary is 0)
+func testHEIF(brand string, primary uint16, properties [][]byte, associations map[uint16][]byte) []byte {
+	ipma := []byte{0, 0, 0, 0}
+	ipma = binary.BigEndian.AppendUint32(ipma, uint32(len(associations)))
+	for item := uint16(1); item <= uint16(len(associations)); item++ {
+		ipma = binary.BigEndian.AppendUint16(ipma, item)
+		ipma = append(append(ipma, byte(len(associations[item]))), associations[item]...)
+	}
+	var pitm []byte
+	if primary != 0 {
+		pitm = testISOBox("pitm", []byte{0, 0, 0, 0}, binary.BigEndian.AppendUint16(nil, primary))
+	}
+	meta := testISOBox("meta",
+		[]byte{0, 0, 0, 0},
+		testISOBox("hdlr", make([]byte, 24)),
+		pitm,
+		testISOBox("iprp", testISOBox("ipco", properties...), testISOBox("ipma", ipma)),
+	)
+	return bytes.Join([][]byte{
+		testISOBox("ftyp", []byte(brand), []byte{0, 0, 0, 0}, []byte("mif1"), []byte(brand)),
+		meta,
+		testISOBox("mdat", []byte{1, 2, 3, 4}),
+	}, nil)
+}
+
+func TestExtractFromHEIF(t *testing.T) {
+	iccData := testProfileBytes(t, testEmbedProfile)
+	t.Run("Profile", func(t *testing.T) {
+		data := testHEIF("heic", 1, [][]byte{testISOBox("ispe", make([]byte, 12)), testHEIFColr("prof", iccData)}, map[uint16][]byte{1: {0x81, 2}})
+		p, err := ExtractFromHEIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, testProfile(t, testEmbedProfile).Header, p.Header)
+		// format detection...
+		p, err = ExtractFromImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "RGB", p.Header.ColorSpace)
+	})
+	t.Run("Restricted Profile (AVIF)", func(t *testing.T) {
+		data := testHEIF("avif", 1, [][]byte{testHEIFColr("rICC", iccData)}, map[uint16][]byte{1: {1}})
+		p, err := ExtractFromImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "RGB", p.Header.ColorSpace)
+	})
+	t.Run("Profile & NCLX", func(t *testing.T) {
+		data := testHEIF("heic", 1, [][]byte{testHEIFColr("nclx", testNCLX), testHEIFColr("prof", iccData)}, map[uint16][]byte{1: {1, 2}})
+		color, err := ExtractColorFromHEIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		require.NotNil(t, color.Profile)
+		assert.Equal(t, &NCLXColor{ColorPrimaries: 12, TransferCharacteristics: 16, MatrixCoefficients: 0, FullRange: true}, color.NCLX)
+	})
+	t.Run("NCLX Only", func(t *testing.T) {
+		data := testHEIF("avif", 1, [][]byte{testHEIFColr("nclx", []byte{0, 1, 0, 13, 0, 6, 0})}, map[uint16][]byte{1: {1}})
+		color, err := ExtractColorFromHEIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Nil(t, color.Profile)
+		assert.Equal(t, &NCLXColor{ColorPrimaries: 1, TransferCharacteristics: 13, MatrixCoefficients: 6}, color.NCLX)
+		_, err = ExtractFromHEIF(bytes.NewReader(data), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+	})
+	t.Run("Primary Item", func(t *testing.T) {
+		// item 1 (thumbnail) has an nclx, item 2 (primary) has the profile...
+		properties := [][]byte{testHEIFColr("nclx", testNCLX), testHEIFColr("prof", iccData)}
+		data := testHEIF("heic", 2, properties, map[uint16][]byte{1: {1}, 2: {0x82}})
+		color, err := ExtractColorFromHEIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.NotNil(t, color.Profile)
+		assert.Nil(t, color.NCLX)
+		data = testHEIF("heic", 1, properties, map[uint16][]byte{1: {1}, 2: {0x82}})
+		_, err = ExtractFromHEIF(bytes.NewReader(data), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		// primary item without associations - other items' colr boxes are not used...
+		data = testHEIF("heic", 3, properties, map[uint16][]byte{1: {1}, 2: {0x82}})
+		_, err = ExtractColorFromHEIF(bytes.NewReader(data), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		// no primary item - first colr boxes...
+		data = testHEIF("heic", 0, properties, map[uint16][]byte{1: {1}, 2: {0x82}})
+		color, err = ExtractColorFromHEIF(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.NotNil(t, color.Profile)
+		assert.NotNil(t, color.NCLX)
+	})
+	t.Run("Large & Open Ended Boxes", func(t *testing.T) {
+		data := testHEIF("heic", 1, [][]byte{testHEIFColr("prof", iccData)}, map[uint16][]byte{1: {1}})
+		ftypSize := binary.BigEndian.Uint32(data)
+		var buf bytes.Buffer
+		// ftyp with 64-bit size...
+		buf.Write([]byte{0, 0, 0, 1})
+		buf.Write(data[4:8])
+		_ = binary.Write(&buf, binary.BigEndian, uint64(ftypSize+8))
+		buf.Write(data[8:ftypSize])
+		// meta extending to end of file...
+		meta := data[ftypSize:]
+		metaSize := binary.BigEndian.Uint32(meta)
+		buf.Write([]byte{0, 0, 0, 0})
+		buf.Write(meta[4:metaSize])
+		p, err := ExtractFromHEIF(&buf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "RGB", p.Header.ColorSpace)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		ftyp := testISOBox("ftyp", []byte("heic\x00\x00\x00\x00"))
+		meta := func(boxes ...[]byte) []byte {
+			return append(append([]byte{}, ftyp...), testISOBox("meta", append([][]byte{{0, 0, 0, 0}}, boxes...)...)...)
+		}
+		tests := map[string]struct {
+			data    []byte
+			wantErr string
+		}{
+			"Empty":             {[]byte{}, "failed to read box header"},
+			"Missing ftyp":      {testISOBox("moov"), "missing ftyp box"},
+			"Invalid Box Size":  {[]byte{0, 0, 0, 4, 'f', 't', 'y', 'p'}, `invalid box "ftyp" size`},
+			"Invalid Large":     {[]byte{0, 0, 0, 1, 'f', 't', 'y', 'p', 0, 0, 0, 0, 0, 0, 0, 8}, `invalid box "ftyp" size`},
+			"Truncated Large":   {[]byte{0, 0, 0, 1, 'f', 't', 'y', 'p', 0}, "failed to read box header"},
+			"No meta":           {append(append([]byte{}, ftyp...), testISOBox("mdat")...), "no meta box"},
+			"No meta (to end)":  {append(append([]byte{}, ftyp...), 0, 0, 0, 0, 'm', 'd', 'a', 't'), "no meta box"},
+			"Truncated Box":     {append(append([]byte{}, ftyp...), 0, 0, 0, 20, 'm', 'd', 'a', 't'), `failed to skip box "mdat"`},
+			"Truncated meta":    {append(append([]byte{}, ftyp...), 0, 0, 0, 20, 'm', 'e', 't', 'a'), "failed to read meta box"},
+			"Short meta":        {append(append([]byte{}, ftyp...), testISOBox("meta", []byte{0})...), "invalid HEIF meta box"},
+			"Bad meta Children": {meta([]byte{0, 0, 0, 20, 'h', 'd', 'l', 'r'}), `invalid HEIF meta box: box "hdlr" exceeds its container`},
+			"Bad pitm":          {meta(testISOBox("pitm", []byte{0})), "invalid HEIF pitm box"},
+			"Bad iprp":          {meta(testISOBox("iprp", []byte{0})), "invalid HEIF iprp box"},
+			"Bad ipco":          {meta(testISOBox("iprp", testISOBox("ipco", []byte{0}))), "invalid HEIF ipco box"},
+			"Bad ipma":          {meta(testISOBox("iprp", testISOBox("ipma", []byte{0}))), "invalid HEIF ipma box"},
+			"Truncated ipma":    {meta(testISOBox("iprp", testISOBox("ipma", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 1, 2}))), "invalid HEIF ipma box"},
+			"Bad nclx":          {meta(testISOBox("iprp", testISOBox("ipco", testHEIFColr("nclx", []byte{0})))), "invalid HEIF nclx colr box"},
+			"Bad Profile":       {meta(testISOBox("iprp", testISOBox("ipco", testHEIFColr("prof", []byte{0})))), "EOF"},
+			"No Color":          {meta(testISOBox("iprp", testISOBox("ipco", testISOBox("ispe")))), "no ICC profile found"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromHEIF(bytes.NewReader(tt.data), nil)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+	})
+}
+
+func TestHEIFAssociations(t *testing.T) {
+	t.Run("Version 1 & Large Indices", func(t *testing.T) {
+		data := []byte{1, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 7, 2, 0x80, 0x01, 0x01, 0x02}
+		associations := make(map[uint32][]int)
+		require.NoError(t, heifAssociations(data, associations))
+		assert.Equal(t, map[uint32][]int{7: {1, 258}}, associations)
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		for _, data := range [][]byte{
+			{1, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0},
+			{1, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 7, 1, 0x80},
+		} {
+			assert.Error(t, heifAssociations(data, make(map[uint32][]int)))
+		}
+	})
+}
+
+func TestReadISOBoxHeader(t *testing.T) {
+	_, _, err := readISOBoxHeader(bytes.NewReader(nil))
+	assert.Equal(t, io.EOF, err)
+	typ, size, err := readISOBoxHeader(bytes.NewReader([]byte{0, 0, 0, 0, 'm', 'd', 'a', 't'}))
+	require.NoError(t, err)
+	assert.Equal(t, "mdat", typ)
+	assert.Equal(t, int64(-1), size)
+}
@@ -0,0 +1,643 @@
+package iccarus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	jxlCodestreamSignature = "\xff\x0a"
+	jxlContainerSignature  = "\x00\x00\x00\x0cJXL \r\n\x87\n"
+	// jxlMaxEncodedICCSize limits the size of the (entropy coded) ICC stream
+	jxlMaxEncodedICCSize = 1 << 24
+)
+
+// ExtractFromJXL extracts ICC profile from a JPEG XL (.jxl) image - either a naked codestream or an ISO-BMFF container
+//
+// the ICC profile is decoded from the (entropy coded) ICC stream that follows the codestream image header
+//
+// returns ErrNoICCProfile if the image has no ICC profile (i.e. the color encoding is described by enumerated values)
+func ExtractFromJXL(r io.Reader, options *ParseOptions) (*Profile, error) {
+	br := bufio.NewReader(r)
+	sig, err := br.Peek(len(jxlContainerSignature))
+	var codestream io.ByteReader
+	switch {
+	case len(sig) >= 2 && string(sig[:2]) == jxlCodestreamSignature:
+		codestream = br
+	case err == nil && string(sig) == jxlContainerSignature:
+		_, _ = br.Discard(len(jxlContainerSignature))
+		codestream = bufio.NewReader(&jxlCodestreamReader{r: br})
+	default:
+		return nil, errors.New("not a valid JPEG XL file")
+	}
+	data, err := readJXLICC(newJXLBitReader(codestream))
+	if err != nil {
+		return nil, err
+	}
+	return ParseProfile(bytes.NewReader(data), options)
+}
+
+// jxlCodestreamReader reads the codestream from the jxlc (or jxlp partial codestream) boxes of a JPEG XL container
+type jxlCodestreamReader struct {
+	r         io.Reader
+	remaining int64 // -1 = to end of file
+	last      bool
+}
+
+func (c *jxlCodestreamReader) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.last {
+			return 0, io.EOF
+		}
+		typ, size, err := readISOBoxHeader(c.r)
+		if err == io.EOF {
+			return 0, io.EOF
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read box header: %w", err)
+		}
+		switch typ {
+		case "jxlc":
+			c.remaining, c.last = size, true
+		case "jxlp":
+			var index [4]byte
+			if _, err = io.ReadFull(c.r, index[:]); err != nil {
+				return 0, errors.New("invalid JPEG XL jxlp box")
+			}
+			c.last = binary.BigEndian.Uint32(index[:])&0x80000000 != 0
+			if c.remaining = size; size >= 4 {
+				c.remaining = size - 4
+			}
+		default:
+			if size < 0 {
+				return 0, io.EOF
+			} else if _, err = io.CopyN(io.Discard, c.r, size); err != nil {
+				return 0, fmt.Errorf("failed to skip box %q: %w", typ, err)
+			}
+		}
+	}
+	if c.remaining > 0 && int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	if c.remaining > 0 {
+		c.remaining -= int64(n)
+		if err == io.EOF && c.remaining > 0 {
+			err = io.ErrUnexpectedEOF
+		} else if err == io.EOF {
+			err = nil
+		}
+	}
+	return n, err
+}
+
+// readJXLICC reads the codestream headers and, if the image has an ICC profile, decodes the ICC stream
+func readJXLICC(br *jxlBitReader) ([]byte, error) {
+	if br.bits(16) != 0x0aff {
+		return nil, errors.New("not a valid JPEG XL codestream")
+	}
+	wantICC, err := readJXLHeaders(br)
+	if err != nil {
+		return nil, err
+	} else if !wantICC {
+		return nil, ErrNoICCProfile
+	}
+	encSize := br.u64()
+	if encSize > jxlMaxEncodedICCSize {
+		return nil, errors.New("invalid JPEG XL ICC stream (too large)")
+	}
+	code, err := readJXLEntropyCode(br, 41, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JPEG XL ICC stream: %w", err)
+	}
+	reader := newJXLSymbolReader(code, br)
+	enc := make([]byte, 0, min(encSize, 1<<16))
+	for i := 0; i < int(encSize) && br.err() == nil; i++ {
+		var b1, b2 byte
+		if i > 0 {
+			b1 = enc[i-1]
+		}
+		if i > 1 {
+			b2 = enc[i-2]
+		}
+		v := reader.readUint(jxlICCContext(i, b1, b2))
+		if v > 255 {
+			return nil, errors.New("invalid JPEG XL ICC stream (value out of range)")
+		}
+		enc = append(enc, byte(v))
+	}
+	if err = br.err(); err != nil {
+		return nil, fmt.Errorf("failed to read JPEG XL ICC stream: %w", err)
+	} else if !reader.finalStateOK() {
+		return nil, errors.New("invalid JPEG XL ICC stream (ANS final state)")
+	}
+	return jxlUnpredictICC(enc)
+}
+
+// readJXLHeaders reads the SizeHeader & ImageMetadata (and, if wanted, the transform data that precedes the ICC stream)
+func readJXLHeaders(br *jxlBitReader) (wantICC bool, err error) {
+	readJXLSizeHeader(br)
+	if br.bool() {
+		// all default (sRGB)
+		return false, br.err()
+	}
+	extraFields := br.bool()
+	if extraFields {
+		br.bits(3) // orientation
+		if br.bool() {
+			readJXLSizeHeader(br) // intrinsic size
+		}
+		if br.bool() {
+			// preview...
+			div8 := br.bool()
+			readSize := func() {
+				if div8 {
+					br.u32(jxlDist{0, 16}, jxlDist{0, 32}, jxlDist{5, 1}, jxlDist{9, 33})
+				} else {
+					br.u32(jxlDist{6, 1}, jxlDist{8, 65}, jxlDist{10, 321}, jxlDist{12, 1345})
+				}
+			}
+			readSize()
+			if br.bits(3) == 0 {
+				readSize()
+			}
+		}
+		if br.bool() {
+			// animation...
+			br.u32(jxlDist{0, 100}, jxlDist{0, 1000}, jxlDist{10, 1}, jxlDist{30, 1})
+			br.u32(jxlDist{0, 1}, jxlDist{0, 1001}, jxlDist{8, 1}, jxlDist{10, 1})
+			br.u32(jxlDist{0, 0}, jxlDist{3, 0}, jxlDist{16, 0}, jxlDist{32, 0})
+			br.bool()
+		}
+	}
+	readJXLBitDepth(br)
+	br.bool() // modular 16-bit buffers
+	numExtraChannels := br.u32(jxlDist{0, 0}, jxlDist{0, 1}, jxlDist{4, 2}, jxlDist{12, 1})
+	for i := uint32(0); i < numExtraChannels && br.err() == nil; i++ {
+		if err = readJXLExtraChannelInfo(br); err != nil {
+			return false, err
+		}
+	}
+	xybEncoded := br.bool()
+	// color encoding...
+	if br.bool() || !br.bool() {
+		// all default or no ICC profile
+		return false, br.err()
+	}
+	br.enum() // color space
+	if extraFields && !br.bool() {
+		// tone mapping...
+		br.bits(16 + 16 + 1 + 16)
+	}
+	skipJXLExtensions(br)
+	// transform data...
+	if !br.bool() {
+		if xybEncoded && !br.bool() {
+			// opsin inverse matrix (9 + 3 + 4 F16s)
+			for i := 0; i < 16; i++ {
+				br.bits(16)
+			}
+		}
+		mask := br.bits(3)
+		for i, n := range []int{15, 55, 210} {
+			if mask&(1<<i) != 0 {
+				for j := 0; j < n; j++ {
+					br.bits(16)
+				}
+			}
+		}
+	}
+	if err = br.err(); err != nil {
+		return false, fmt.Errorf("failed to read JPEG XL image header: %w", err)
+	}
+	return true, nil
+}
+
+func readJXLSizeHeader(br *jxlBitReader) {
+	small := br.bool()
+	readSize := func() {
+		if small {
+			br.bits(5)
+		} else {
+			br.u32(jxlDist{9, 1}, jxlDist{13, 1}, jxlDist{18, 1}, jxlDist{30, 1})
+		}
+	}
+	readSize()
+	if br.bits(3) == 0 {
+		readSize()
+	}
+}
+
+func readJXLBitDepth(br *jxlBitReader) {
+	if br.bool() {
+		// float samples
+		br.u32(jxlDist{0, 32}, jxlDist{0, 16}, jxlDist{0, 24}, jxlDist{6, 1})
+		br.bits(4)
+	} else {
+		br.u32(jxlDist{0, 8}, jxlDist{0, 10}, jxlDist{0, 12}, jxlDist{6, 1})
+	}
+}
+
+func readJXLExtraChannelInfo(br *jxlBitReader) error {
+	if br.bool() {
+		// all default (alpha)
+		return nil
+	}
+	typ := br.enum()
+	readJXLBitDepth(br)
+	br.u32(jxlDist{0, 0}, jxlDist{0, 3}, jxlDist{0, 4}, jxlDist{3, 1}) // dim shift
+	nameLen := br.u32(jxlDist{0, 0}, jxlDist{4, 0}, jxlDist{5, 16}, jxlDist{10, 48})
+	for i := uint32(0); i < nameLen; i++ {
+		br.bits(8)
+	}
+	switch typ {
+	case 0:
+		// alpha
+		br.bool()
+	case 2:
+		// spot color
+		br.bits(16)
+		br.bits(16)
+		br.bits(16)
+		br.bits(16)
+	case 5:
+		// CFA
+		br.u32(jxlDist{0, 1}, jxlDist{2, 0}, jxlDist{4, 3}, jxlDist{8, 19})
+	case 1, 3, 4, 6, 15, 16:
+		// depth, selection mask, black, thermal, unknown & optional
+	default:
+		return errors.New("invalid JPEG XL extra channel type")
+	}
+	return nil
+}
+
+func skipJXLExtensions(br *jxlBitReader) {
+	extensions := br.u64()
+	var total uint64
+	for i := 0; i < 64; i++ {
+		if extensions&(1<<i) != 0 {
+			total += br.u64()
+		}
+	}
+	for ; total > 0 && br.err() == nil; total-- {
+		br.bits(1)
+	}
+}
+
+// jxlICCContext is the context for the i-th byte of the encoded ICC stream (based on the kinds of the previous two bytes)
+func jxlICCContext(i int, b1, b2 byte) int {
+	if i <= 128 {
+		return 0
+	}
+	isText := func(b byte) (letter bool, digit bool) {
+		return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z'), (b >= '0' && b <= '9') || b == '.' || b == ','
+	}
+	var p1, p2 int
+	switch letter, digit := isText(b1); {
+	case letter:
+		p1 = 0
+	case digit:
+		p1 = 1
+	case b1 <= 1:
+		p1 = 2 + int(b1)
+	case b1 < 16:
+		p1 = 4
+	case b1 == 255:
+		p1 = 6
+	case b1 > 240:
+		p1 = 5
+	default:
+		p1 = 7
+	}
+	switch letter, digit := isText(b2); {
+	case letter:
+		p2 = 0
+	case digit:
+		p2 = 1
+	case b2 < 16:
+		p2 = 2
+	case b2 > 240:
+		p2 = 3
+	default:
+		p2 = 4
+	}
+	return 1 + p1 + p2*8
+}
+
+const (
+	jxlICCCommandInsert     = 1
+	jxlICCCommandShuffle2   = 2
+	jxlICCCommandShuffle4   = 3
+	jxlICCCommandPredict    = 4
+	jxlICCCommandXYZ        = 10
+	jxlICCCommandTypeFirst  = 16
+	jxlICCTagUnknown        = 1
+	jxlICCTagTRC            = 2
+	jxlICCTagXYZ            = 3
+	jxlICCTagStringFirst    = 4
+	jxlICCFlagTagOffset     = 64
+	jxlICCFlagTagSize       = 128
+	jxlICCHeaderSizeLimit   = 128
+	jxlICCMaxProfileSize    = 1 << 28
+	jxlICCTagListEntryBytes = 12
+)
+
+var (
+	jxlICCTagStrings  = []string{"cprt", "wtpt", "bkpt", "rXYZ", "gXYZ", "bXYZ", "kXYZ", "rTRC", "gTRC", "bTRC", "kTRC", "chad", "desc", "chrm", "dmnd", "dmdd", "lumi"}
+	jxlICCTypeStrings = []string{"XYZ ", "desc", "text", "mluc", "para", "curv", "sf32", "gbd "}
+)
+
+// jxlUnpredictICC reconstructs the ICC profile from the decoded ICC stream
+//
+// the stream consists of the output size, a command stream and a data stream - the header is predicted from
+// typical values, the tag table is described by commands and the tag data by insert/shuffle/predict commands
+func jxlUnpredictICC(enc []byte) ([]byte, error) {
+	invalid := errors.New("invalid JPEG XL ICC stream")
+	pos := 0
+	varInt := func(p *int, end int) (uint64, bool) {
+		var v uint64
+		for shift := 0; *p < end && shift < 63; shift += 7 {
+			b := enc[*p]
+			*p++
+			v |= uint64(b&0x7f) << shift
+			if b&0x80 == 0 {
+				return v, true
+			}
+		}
+		return v, false
+	}
+	osize, ok := varInt(&pos, len(enc))
+	if !ok || osize > jxlICCMaxProfileSize {
+		return nil, invalid
+	}
+	csize, ok := varInt(&pos, len(enc))
+	if !ok || csize > uint64(len(enc)-pos) {
+		return nil, invalid
+	}
+	cpos, cend := pos, pos+int(csize)
+	pos = cend
+	result := make([]byte, 0, osize)
+	appendUint32 := func(v uint64) {
+		result = binary.BigEndian.AppendUint32(result, uint32(v))
+	}
+	// header...
+	header := jxlICCInitialHeader(osize)
+	for i := 0; i <= jxlICCHeaderSizeLimit; i++ {
+		if uint64(len(result)) == osize {
+			if cpos != cend || pos != len(enc) {
+				return nil, invalid
+			}
+			return result, nil
+		}
+		if i == jxlICCHeaderSizeLimit {
+			break
+		}
+		jxlPredictICCHeader(result, &header, i)
+		if pos >= len(enc) {
+			return nil, invalid
+		}
+		result = append(result, enc[pos]+header[i])
+		pos++
+	}
+	if cpos >= cend {
+		return nil, invalid
+	}
+	// tag list...
+	numTags, ok := varInt(&cpos, cend)
+	if !ok {
+		return nil, invalid
+	}
+	if numTags != 0 {
+		numTags--
+		if numTags > 0xffffffff {
+			return nil, invalid
+		}
+		appendUint32(numTags)
+		prevStart, prevSize := jxlICCHeaderSizeLimit+numTags*jxlICCTagListEntryBytes, uint64(0)
+		for cpos < cend {
+			if uint64(len(result)) > osize {
+				return nil, invalid
+			}
+			command := enc[cpos]
+			cpos++
+			code := int(command & 63)
+			var tag string
+			switch {
+			case code == 0:
+			case code == jxlICCTagUnknown:
+				if pos+4 > len(enc) {
+					return nil, invalid
+				}
+				tag = string(enc[pos : pos+4])
+				pos += 4
+			case code == jxlICCTagTRC:
+				tag = "rTRC"
+			case code == jxlICCTagXYZ:
+				tag = "rXYZ"
+			case code-jxlICCTagStringFirst < len(jxlICCTagStrings):
+				tag = jxlICCTagStrings[code-jxlICCTagStringFirst]
+			default:
+				return nil, invalid
+			}
+			if code == 0 {
+				break
+			}
+			result = append(result, tag...)
+			start, size := prevStart+prevSize, prevSize
+			switch tag {
+			case "rXYZ", "gXYZ", "bXYZ", "kXYZ", "wtpt", "bkpt", "lumi":
+				size = 20
+			}
+			if command&jxlICCFlagTagOffset != 0 {
+				if start, ok = varInt(&cpos, cend); !ok {
+					return nil, invalid
+				}
+			}
+			if command&jxlICCFlagTagSize != 0 {
+				if size, ok = varInt(&cpos, cend); !ok {
+					return nil, invalid
+				}
+			}
+			if start > 0xffffffff || size > 0xffffffff || start+size*2 > 0xffffffff {
+				return nil, invalid
+			}
+			appendUint32(start)
+			appendUint32(size)
+			prevStart, prevSize = start, size
+			switch code {
+			case jxlICCTagTRC:
+				result = append(result, "gTRC"...)
+				appendUint32(start)
+				appendUint32(size)
+				result = append(result, "bTRC"...)
+				appendUint32(start)
+				appendUint32(size)
+			case jxlICCTagXYZ:
+				result = append(result, "gXYZ"...)
+				appendUint32(start + size)
+				appendUint32(size)
+				result = append(result, "bXYZ"...)
+				appendUint32(start + size*2)
+				appendUint32(size)
+			}
+		}
+	}
+	// main content...
+	for cpos < cend {
+		if uint64(len(result)) > osize {
+			return nil, invalid
+		}
+		command := enc[cpos]
+		cpos++
+		switch {
+		case command == jxlICCCommandInsert, command == jxlICCCommandShuffle2, command == jxlICCCommandShuffle4:
+			num, ok := varInt(&cpos, cend)
+			if !ok || num > uint64(len(enc)-pos) {
+				return nil, invalid
+			}
+			data := bytes.Clone(enc[pos : pos+int(num)])
+			switch command {
+			case jxlICCCommandShuffle2:
+				jxlShuffle(data, 2)
+			case jxlICCCommandShuffle4:
+				jxlShuffle(data, 4)
+			}
+			result = append(result, data...)
+			pos += int(num)
+		case command == jxlICCCommandPredict:
+			if cpos >= cend {
+				return nil, invalid
+			}
+			flags := enc[cpos]
+			cpos++
+			width := int(flags&3) + 1
+			order := int(flags&12) >> 2
+			if width == 3 || order == 3 {
+				return nil, invalid
+			}
+			stride := uint64(width)
+			if flags&16 != 0 {
+				if stride, ok = varInt(&cpos, cend); !ok || stride < uint64(width) {
+					return nil, invalid
+				}
+			}
+			if len(result) == 0 || uint64(len(result)-1)>>2 < stride {
+				return nil, invalid
+			}
+			num, ok := varInt(&cpos, cend)
+			if !ok || num > uint64(len(enc)-pos) {
+				return nil, invalid
+			}
+			data := bytes.Clone(enc[pos : pos+int(num)])
+			if width > 1 {
+				jxlShuffle(data, width)
+			}
+			start := len(result)
+			for i, b := range data {
+				result = append(result, b+jxlPredictICCValue(result, start, i, int(stride), width, order))
+			}
+			pos += int(num)
+		case command == jxlICCCommandXYZ:
+			if pos+12 > len(enc) {
+				return nil, invalid
+			}
+			result = append(result, "XYZ \x00\x00\x00\x00"...)
+			result = append(result, enc[pos:pos+12]...)
+			pos += 12
+		case command >= jxlICCCommandTypeFirst && int(command-jxlICCCommandTypeFirst) < len(jxlICCTypeStrings):
+			result = append(result, jxlICCTypeStrings[command-jxlICCCommandTypeFirst]...)
+			result = append(result, 0, 0, 0, 0)
+		default:
+			return nil, invalid
+		}
+	}
+	if pos != len(enc) || uint64(len(result)) != osize {
+		return nil, invalid
+	}
+	return result, nil
+}
+
+// jxlICCInitialHeader is the initial prediction of the ICC header
+func jxlICCInitialHeader(osize uint64) (header [jxlICCHeaderSizeLimit]byte) {
+	binary.BigEndian.PutUint32(header[0:], uint32(osize))
+	header[8] = 4
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+	copy(header[68:], []byte{0, 0, 246, 214, 0, 1, 0, 0, 0, 0, 211, 45})
+	return header
+}
+
+// jxlPredictICCHeader updates the header prediction (for the i-th byte) from the bytes decoded so far
+func jxlPredictICCHeader(icc []byte, header *[jxlICCHeaderSizeLimit]byte, i int) {
+	switch {
+	case i == 8 && len(icc) >= 8:
+		// creator is predicted to be the same as the CMM
+		copy(header[80:84], icc[4:8])
+	case i == 41 && len(icc) >= 41:
+		switch icc[40] {
+		case 'A':
+			copy(header[41:44], "PPL")
+		case 'M':
+			copy(header[41:44], "SFT")
+		}
+	case i == 42 && len(icc) >= 42:
+		switch {
+		case icc[40] == 'S' && icc[41] == 'G':
+			copy(header[42:44], "I ")
+		case icc[40] == 'S' && icc[41] == 'U':
+			copy(header[42:44], "NW")
+		}
+	}
+}
+
+// jxlShuffle transposes the data (viewed as width columns)
+func jxlShuffle(data []byte, width int) {
+	height := (len(data) + width - 1) / width
+	result := make([]byte, len(data))
+	for i, j, s := 0, 0, 0; i < len(data); i++ {
+		result[i] = data[j]
+		if j += height; j >= len(data) {
+			s++
+			j = s
+		}
+	}
+	copy(data, result)
+}
+
+// jxlPredictICCValue predicts the i-th byte (from start) from the previous (stride apart) values
+func jxlPredictICCValue(data []byte, start int, i int, stride int, width int, order int) byte {
+	predict := func(p1, p2, p3 uint32) uint32 {
+		switch order {
+		case 1:
+			return 2*p1 - p2
+		case 2:
+			return 3*p1 - 3*p2 + p3
+		}
+		return p1
+	}
+	switch width {
+	case 1:
+		pos := start + i
+		return byte(predict(uint32(data[pos-stride]), uint32(data[pos-stride*2]), uint32(data[pos-stride*3])))
+	case 2:
+		p := start + i&^1
+		v := func(n int) uint32 {
+			return uint32(binary.BigEndian.Uint16(data[p-stride*n:]))
+		}
+		pred := uint16(predict(v(1), v(2), v(3)))
+		if i&1 != 0 {
+			return byte(pred)
+		}
+		return byte(pred >> 8)
+	}
+	p := start + i&^3
+	v := func(n int) uint32 {
+		return binary.BigEndian.Uint32(data[p-stride*n:])
+	}
+	return byte(predict(v(1), v(2), v(3)) >> ((3 - i&3) * 8))
+}
@@ -0,0 +1,722 @@
+package iccarus
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+	"slices"
+)
+
+// jxlBitReader reads bits (least significant first) from a JPEG XL codestream
+//
+// read errors are sticky - once an error has occurred, all reads return zero bits and the error is
+// available from err()
+type jxlBitReader struct {
+	r     io.ByteReader
+	buf   uint64
+	n     uint
+	eof   bool
+	error error
+}
+
+func newJXLBitReader(r io.ByteReader) *jxlBitReader {
+	return &jxlBitReader{r: r}
+}
+
+func (b *jxlBitReader) err() error {
+	return b.error
+}
+
+func (b *jxlBitReader) fill(n uint) {
+	for b.n < n && !b.eof && b.error == nil {
+		c, err := b.r.ReadByte()
+		if err == io.EOF {
+			b.eof = true
+		} else if err != nil {
+			b.error = err
+		} else {
+			b.buf |= uint64(c) << b.n
+			b.n += 8
+		}
+	}
+}
+
+// peek returns the next n (max 32) bits without consuming them (bits beyond the end of the stream are zero)
+func (b *jxlBitReader) peek(n uint) uint64 {
+	b.fill(n)
+	return b.buf & (1<<n - 1)
+}
+
+func (b *jxlBitReader) consume(n uint) {
+	if n > b.n {
+		if b.error == nil {
+			b.error = io.ErrUnexpectedEOF
+		}
+		b.buf, b.n = 0, 0
+		return
+	}
+	b.buf >>= n
+	b.n -= n
+}
+
+// bits reads n (max 32) bits
+func (b *jxlBitReader) bits(n uint) uint64 {
+	v := b.peek(n)
+	b.consume(n)
+	return v
+}
+
+func (b *jxlBitReader) bool() bool {
+	return b.bits(1) == 1
+}
+
+// jxlDist is a U32 distribution - a constant (bits = 0) or bits with an offset
+type jxlDist struct {
+	bits   uint
+	offset uint32
+}
+
+// u32 reads a U32 with the four distributions (selected by 2 bits)
+func (b *jxlBitReader) u32(d0, d1, d2, d3 jxlDist) uint32 {
+	d := [4]jxlDist{d0, d1, d2, d3}[b.bits(2)]
+	return uint32(b.bits(d.bits)) + d.offset
+}
+
+func (b *jxlBitReader) u64() uint64 {
+	switch b.bits(2) {
+	case 0:
+		return 0
+	case 1:
+		return 1 + b.bits(4)
+	case 2:
+		return 17 + b.bits(8)
+	}
+	value := b.bits(12)
+	for shift := uint(12); b.bool(); shift += 8 {
+		if shift == 60 {
+			return value | b.bits(4)<<60
+		}
+		value |= b.bits(8) << shift
+	}
+	return value
+}
+
+func (b *jxlBitReader) enum() uint32 {
+	return b.u32(jxlDist{0, 0}, jxlDist{0, 1}, jxlDist{4, 2}, jxlDist{6, 18})
+}
+
+func (b *jxlBitReader) varLenUint8() uint32 {
+	if b.bool() {
+		if n := uint(b.bits(3)); n != 0 {
+			return uint32(b.bits(n)) + 1<<n
+		}
+		return 1
+	}
+	return 0
+}
+
+func (b *jxlBitReader) varLenUint16() uint32 {
+	if b.bool() {
+		if n := uint(b.bits(4)); n != 0 {
+			return uint32(b.bits(n)) + 1<<n
+		}
+		return 1
+	}
+	return 0
+}
+
+const (
+	jxlANSLogTabSize = 12
+	jxlANSTabSize    = 1 << jxlANSLogTabSize
+	jxlANSSignature  = 0x13 << 16
+	jxlPrefixMaxBits = 15
+	jxlLZ77Window    = 1 << 20
+	jxlMaxClusters   = 256
+)
+
+// jxlHybridUintConfig is a hybrid integer configuration - how tokens are split into token & raw bits
+type jxlHybridUintConfig struct {
+	splitExponent uint
+	msbInToken    uint
+	lsbInToken    uint
+}
+
+func readJXLHybridUintConfig(br *jxlBitReader, logAlphaSize uint) (jxlHybridUintConfig, error) {
+	var c jxlHybridUintConfig
+	c.splitExponent = uint(br.bits(ceilLog2(logAlphaSize + 1)))
+	if c.splitExponent != logAlphaSize {
+		c.msbInToken = uint(br.bits(ceilLog2(c.splitExponent + 1)))
+		if c.msbInToken > c.splitExponent {
+			return c, errors.New("invalid hybrid uint config")
+		}
+		c.lsbInToken = uint(br.bits(ceilLog2(c.splitExponent - c.msbInToken + 1)))
+	}
+	if c.msbInToken+c.lsbInToken > c.splitExponent {
+		return c, errors.New("invalid hybrid uint config")
+	}
+	return c, nil
+}
+
+// read reads the value of a token (reading any raw bits)
+func (c jxlHybridUintConfig) read(br *jxlBitReader, token uint32) uint32 {
+	split := uint32(1) << c.splitExponent
+	if token < split {
+		return token
+	}
+	n := (c.splitExponent - (c.msbInToken + c.lsbInToken) + uint((token-split)>>(c.msbInToken+c.lsbInToken))) & 31
+	low := token & (1<<c.lsbInToken - 1)
+	token >>= c.lsbInToken
+	token = token&(1<<c.msbInToken-1) | 1<<c.msbInToken
+	return ((token<<n|uint32(br.bits(n)))<<c.lsbInToken | low)
+}
+
+func ceilLog2(v uint) uint {
+	if v <= 1 {
+		return 0
+	}
+	return uint(bits.Len(v - 1))
+}
+
+// jxlEntropyCode is a JPEG XL entropy code (prefix or ANS) for a number of (clustered) contexts
+type jxlEntropyCode struct {
+	lz77          bool
+	lz77MinSymbol uint32
+	lz77MinLength uint32
+	lz77Config    jxlHybridUintConfig
+	contextMap    []uint8
+	usePrefixCode bool
+	logAlphaSize  uint
+	configs       []jxlHybridUintConfig
+	prefixCodes   []*jxlPrefixCode
+	aliasTables   [][]jxlAliasEntry
+}
+
+// readJXLEntropyCode reads the entropy code (histograms) for the number of contexts
+func readJXLEntropyCode(br *jxlBitReader, numContexts int, disallowLZ77 bool) (*jxlEntropyCode, error) {
+	code := &jxlEntropyCode{}
+	if code.lz77 = br.bool(); code.lz77 {
+		if disallowLZ77 {
+			return nil, errors.New("invalid entropy code (LZ77 not allowed)")
+		}
+		code.lz77MinSymbol = br.u32(jxlDist{0, 224}, jxlDist{0, 512}, jxlDist{0, 4096}, jxlDist{15, 8})
+		code.lz77MinLength = br.u32(jxlDist{0, 3}, jxlDist{0, 4}, jxlDist{2, 5}, jxlDist{8, 9})
+		var err error
+		if code.lz77Config, err = readJXLHybridUintConfig(br, 8); err != nil {
+			return nil, err
+		}
+		numContexts++
+	}
+	code.contextMap = make([]uint8, numContexts)
+	numClusters := 1
+	if numContexts > 1 {
+		var err error
+		if numClusters, err = readJXLContextMap(br, code.contextMap); err != nil {
+			return nil, err
+		}
+	}
+	if code.usePrefixCode = br.bool(); code.usePrefixCode {
+		code.logAlphaSize = jxlPrefixMaxBits
+	} else {
+		code.logAlphaSize = uint(br.bits(2)) + 5
+	}
+	code.configs = make([]jxlHybridUintConfig, numClusters)
+	for i := range code.configs {
+		var err error
+		if code.configs[i], err = readJXLHybridUintConfig(br, code.logAlphaSize); err != nil {
+			return nil, err
+		}
+	}
+	maxAlphabetSize := 1 << code.logAlphaSize
+	if code.usePrefixCode {
+		sizes := make([]int, numClusters)
+		for i := range sizes {
+			if sizes[i] = int(br.varLenUint16()) + 1; sizes[i] > maxAlphabetSize {
+				return nil, errors.New("invalid prefix code alphabet size")
+			}
+		}
+		code.prefixCodes = make([]*jxlPrefixCode, numClusters)
+		for i, size := range sizes {
+			var err error
+			if code.prefixCodes[i], err = readJXLPrefixCode(br, size); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		code.aliasTables = make([][]jxlAliasEntry, numClusters)
+		for i := range code.aliasTables {
+			counts, err := readJXLHistogram(br)
+			if err != nil {
+				return nil, err
+			} else if len(counts) > maxAlphabetSize {
+				return nil, errors.New("invalid ANS histogram (alphabet too large)")
+			}
+			code.aliasTables[i] = jxlAliasTable(counts, code.logAlphaSize)
+		}
+	}
+	return code, br.err()
+}
+
+// readJXLContextMap reads the context map (mapping contexts to clusters) - returning the number of clusters
+func readJXLContextMap(br *jxlBitReader, contextMap []uint8) (int, error) {
+	if br.bool() {
+		// simple...
+		n := uint(br.bits(2))
+		for i := range contextMap {
+			contextMap[i] = uint8(br.bits(n))
+		}
+	} else {
+		useMTF := br.bool()
+		code, err := readJXLEntropyCode(br, 1, len(contextMap) <= 2)
+		if err != nil {
+			return 0, err
+		}
+		reader := newJXLSymbolReader(code, br)
+		for i := range contextMap {
+			v := reader.readUint(0)
+			if v >= jxlMaxClusters {
+				return 0, errors.New("invalid context map (too many clusters)")
+			}
+			contextMap[i] = uint8(v)
+		}
+		if !reader.finalStateOK() {
+			return 0, errors.New("invalid context map (ANS final state)")
+		}
+		if useMTF {
+			var mtf [256]uint8
+			for i := range mtf {
+				mtf[i] = uint8(i)
+			}
+			for i, index := range contextMap {
+				value := mtf[index]
+				contextMap[i] = value
+				copy(mtf[1:index+1], mtf[:index])
+				mtf[0] = value
+			}
+		}
+	}
+	if err := br.err(); err != nil {
+		return 0, err
+	}
+	numClusters := int(slices.Max(contextMap)) + 1
+	used := make([]bool, numClusters)
+	for _, c := range contextMap {
+		used[c] = true
+	}
+	if slices.Contains(used, false) {
+		return 0, errors.New("invalid context map (unused cluster)")
+	}
+	return numClusters, nil
+}
+
+// jxlPrefixCode is a canonical (Brotli style) prefix code
+type jxlPrefixCode struct {
+	counts  [jxlPrefixMaxBits + 1]int
+	symbols []uint32
+}
+
+// newJXLPrefixCode creates a canonical prefix code from symbol code lengths (a code with a single
+// used symbol reads zero bits)
+func newJXLPrefixCode(lengths []uint8) *jxlPrefixCode {
+	result := &jxlPrefixCode{}
+	for l := 1; l <= jxlPrefixMaxBits; l++ {
+		for s, sl := range lengths {
+			if int(sl) == l {
+				result.counts[l]++
+				result.symbols = append(result.symbols, uint32(s))
+			}
+		}
+	}
+	if len(result.symbols) == 1 {
+		result.counts = [jxlPrefixMaxBits + 1]int{}
+	}
+	return result
+}
+
+func (c *jxlPrefixCode) read(br *jxlBitReader) uint32 {
+	if len(c.symbols) == 1 {
+		return c.symbols[0]
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l <= jxlPrefixMaxBits; l++ {
+		code |= int(br.bits(1))
+		count := c.counts[l]
+		if code-count < first {
+			return c.symbols[index+(code-first)]
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0
+}
+
+var jxlCodeLengthCodeOrder = [18]int{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// readJXLPrefixCode reads a (Brotli style) prefix code for the alphabet size
+func readJXLPrefixCode(br *jxlBitReader, alphabetSize int) (*jxlPrefixCode, error) {
+	if alphabetSize == 1 {
+		return &jxlPrefixCode{symbols: []uint32{0}}, nil
+	}
+	lengths := make([]uint8, alphabetSize)
+	skip := int(br.bits(2))
+	if skip == 1 {
+		// simple code...
+		maxBits := uint(bits.Len(uint(alphabetSize - 1)))
+		n := int(br.bits(2)) + 1
+		symbols := make([]int, n)
+		for i := range symbols {
+			if symbols[i] = int(br.bits(maxBits)); symbols[i] >= alphabetSize {
+				return nil, errors.New("invalid prefix code symbol")
+			}
+			for _, s := range symbols[:i] {
+				if s == symbols[i] {
+					return nil, errors.New("invalid prefix code (duplicate symbol)")
+				}
+			}
+		}
+		switch n {
+		case 1:
+			return &jxlPrefixCode{symbols: []uint32{uint32(symbols[0])}}, br.err()
+		case 2:
+			lengths[symbols[0]], lengths[symbols[1]] = 1, 1
+		case 3:
+			lengths[symbols[0]], lengths[symbols[1]], lengths[symbols[2]] = 1, 2, 2
+		case 4:
+			if br.bool() {
+				lengths[symbols[0]], lengths[symbols[1]], lengths[symbols[2]], lengths[symbols[3]] = 1, 2, 3, 3
+			} else {
+				lengths[symbols[0]], lengths[symbols[1]], lengths[symbols[2]], lengths[symbols[3]] = 2, 2, 2, 2
+			}
+		}
+		return newJXLPrefixCode(lengths), br.err()
+	}
+	// complex code - code lengths of the code length code...
+	var codeLengthLengths [18]uint8
+	space, numCodes := 32, 0
+	for _, index := range jxlCodeLengthCodeOrder[skip:] {
+		// static prefix code: 0=00, 1=0111, 2=011, 3=10, 4=01, 5=1111 (read right to left)
+		v := [16]uint8{0, 4, 3, 2, 0, 4, 3, 1, 0, 4, 3, 2, 0, 4, 3, 5}[br.peek(4)]
+		br.consume(uint([16]uint8{2, 2, 2, 3, 2, 2, 2, 4, 2, 2, 2, 3, 2, 2, 2, 4}[br.peek(4)]))
+		codeLengthLengths[index] = v
+		if v != 0 {
+			space -= 32 >> v
+			numCodes++
+			if space <= 0 {
+				break
+			}
+		}
+	}
+	if numCodes != 1 && space != 0 {
+		return nil, errors.New("invalid prefix code (code length code)")
+	}
+	codeLengthCode := newJXLPrefixCode(codeLengthLengths[:])
+	symbol, prevLength, repeat, repeatLength := 0, uint8(8), 0, uint8(0)
+	space = 1 << 15
+	for symbol < alphabetSize && space > 0 && br.err() == nil {
+		length := codeLengthCode.read(br)
+		if length < 16 {
+			repeat = 0
+			lengths[symbol] = uint8(length)
+			symbol++
+			if length != 0 {
+				prevLength = uint8(length)
+				space -= 32768 >> length
+			}
+			continue
+		}
+		extraBits, newLength := uint(3), uint8(0)
+		if length == 16 {
+			extraBits, newLength = 2, prevLength
+		}
+		if repeatLength != newLength {
+			repeat, repeatLength = 0, newLength
+		}
+		oldRepeat := repeat
+		if repeat > 0 {
+			repeat = (repeat - 2) << extraBits
+		}
+		repeat += int(br.bits(extraBits)) + 3
+		delta := repeat - oldRepeat
+		if symbol+delta > alphabetSize {
+			return nil, errors.New("invalid prefix code (too many code lengths)")
+		}
+		for i := 0; i < delta; i++ {
+			lengths[symbol+i] = repeatLength
+		}
+		symbol += delta
+		if repeatLength != 0 {
+			space -= delta << (15 - repeatLength)
+		}
+	}
+	if err := br.err(); err != nil {
+		return nil, err
+	} else if space != 0 {
+		return nil, errors.New("invalid prefix code (incomplete)")
+	}
+	return newJXLPrefixCode(lengths), nil
+}
+
+// jxlLogCountLengths & jxlLogCountCodes are the prefix code of ANS histogram log counts (13 is RLE)
+var (
+	jxlLogCountLengths = [14]uint{5, 4, 4, 4, 4, 4, 3, 3, 3, 3, 3, 6, 7, 7}
+	jxlLogCountCodes   = [14]uint64{17, 11, 15, 3, 9, 7, 4, 2, 5, 6, 0, 33, 1, 65}
+)
+
+func readJXLLogCount(br *jxlBitReader) int {
+	peek := br.peek(7)
+	for v, length := range jxlLogCountLengths {
+		if peek&(1<<length-1) == jxlLogCountCodes[v] {
+			br.consume(length)
+			return v
+		}
+	}
+	return 0 // unreachable (the code is complete)
+}
+
+// readJXLHistogram reads an ANS histogram (symbol frequencies summing to jxlANSTabSize)
+func readJXLHistogram(br *jxlBitReader) ([]int, error) {
+	if br.bool() {
+		// simple (one or two symbols)...
+		n := int(br.bits(1)) + 1
+		var symbols [2]int
+		for i := 0; i < n; i++ {
+			symbols[i] = int(br.varLenUint8())
+		}
+		counts := make([]int, max(symbols[0], symbols[1])+1)
+		if n == 1 {
+			counts[symbols[0]] = jxlANSTabSize
+		} else {
+			if symbols[0] == symbols[1] {
+				return nil, errors.New("invalid ANS histogram (duplicate symbol)")
+			}
+			counts[symbols[0]] = int(br.bits(jxlANSLogTabSize))
+			counts[symbols[1]] = jxlANSTabSize - counts[symbols[0]]
+		}
+		return counts, br.err()
+	}
+	if br.bool() {
+		// flat...
+		size := int(br.varLenUint8()) + 1
+		counts := make([]int, size)
+		for i := range counts {
+			counts[i] = jxlANSTabSize / size
+		}
+		for i := 0; i < jxlANSTabSize%size; i++ {
+			counts[i]++
+		}
+		return counts, br.err()
+	}
+	log := uint(0)
+	for ; log < 3 && br.bool(); log++ {
+	}
+	shift := int(br.bits(log)|1<<log) - 1
+	if shift > jxlANSLogTabSize+1 {
+		return nil, errors.New("invalid ANS histogram (shift)")
+	}
+	length := int(br.varLenUint8()) + 3
+	counts := make([]int, length)
+	logCounts := make([]int, length)
+	same := make([]int, length)
+	omitLog, omitPos := -1, -1
+	for i := 0; i < length; i++ {
+		logCounts[i] = readJXLLogCount(br)
+		if logCounts[i] == jxlANSLogTabSize+1 {
+			rle := int(br.varLenUint8())
+			same[i] = rle + 5
+			i += rle + 3
+			continue
+		}
+		if logCounts[i] > omitLog {
+			omitLog, omitPos = logCounts[i], i
+		}
+	}
+	if omitPos < 0 {
+		return nil, errors.New("invalid ANS histogram")
+	}
+	total, prev, numSame := 0, 0, 0
+	for i := 0; i < length; i++ {
+		if same[i] != 0 {
+			numSame = same[i] - 1
+			if i > 0 {
+				prev = counts[i-1]
+			} else {
+				prev = 0
+			}
+		}
+		if numSame > 0 {
+			counts[i] = prev
+			numSame--
+		} else {
+			code := logCounts[i]
+			switch {
+			case i == omitPos || code == 0:
+				continue
+			case code == 1:
+				counts[i] = 1
+			default:
+				bitCount := max(min(code-1, shift-((jxlANSLogTabSize-(code-1))>>1)), 0)
+				counts[i] = 1<<(code-1) + int(br.bits(uint(bitCount)))<<(code-1-bitCount)
+			}
+		}
+		total += counts[i]
+	}
+	if counts[omitPos] = jxlANSTabSize - total; counts[omitPos] <= 0 {
+		return nil, errors.New("invalid ANS histogram (count)")
+	}
+	return counts, br.err()
+}
+
+// jxlAliasEntry is an entry of an ANS alias table
+type jxlAliasEntry struct {
+	cutoff     uint32
+	rightValue uint32
+	offset1    uint32
+	freq0      uint32
+	freq1      uint32
+}
+
+// jxlAliasTable creates the alias table for ANS symbol frequencies
+func jxlAliasTable(counts []int, logAlphaSize uint) []jxlAliasEntry {
+	for len(counts) > 0 && counts[len(counts)-1] == 0 {
+		counts = counts[:len(counts)-1]
+	}
+	if len(counts) == 0 {
+		counts = []int{jxlANSTabSize}
+	}
+	tableSize := 1 << logAlphaSize
+	entrySize := uint32(jxlANSTabSize >> logAlphaSize)
+	table := make([]jxlAliasEntry, tableSize)
+	for s, c := range counts {
+		if c == jxlANSTabSize {
+			// single symbol (state does not change)...
+			for i := range table {
+				table[i] = jxlAliasEntry{rightValue: uint32(s), offset1: entrySize * uint32(i), freq1: jxlANSTabSize}
+			}
+			return table
+		}
+	}
+	cutoffs := make([]uint32, tableSize)
+	var underfull, overfull []int
+	for i := range cutoffs {
+		if i < len(counts) {
+			cutoffs[i] = uint32(counts[i])
+		}
+		if cutoffs[i] > entrySize {
+			overfull = append(overfull, i)
+		} else if cutoffs[i] < entrySize {
+			underfull = append(underfull, i)
+		}
+	}
+	for len(overfull) > 0 && len(underfull) > 0 {
+		o := overfull[len(overfull)-1]
+		overfull = overfull[:len(overfull)-1]
+		u := underfull[len(underfull)-1]
+		underfull = underfull[:len(underfull)-1]
+		cutoffs[o] -= entrySize - cutoffs[u]
+		table[u].rightValue = uint32(o)
+		table[u].offset1 = cutoffs[o]
+		if cutoffs[o] < entrySize {
+			underfull = append(underfull, o)
+		} else if cutoffs[o] > entrySize {
+			overfull = append(overfull, o)
+		}
+	}
+	freq := func(s uint32) uint32 {
+		if int(s) < len(counts) {
+			return uint32(counts[s])
+		}
+		return 0
+	}
+	for i := range table {
+		if cutoffs[i] == entrySize {
+			table[i].rightValue = uint32(i)
+			table[i].offset1 = 0
+			table[i].cutoff = 0
+		} else {
+			table[i].offset1 -= cutoffs[i]
+			table[i].cutoff = cutoffs[i]
+		}
+		table[i].freq0 = freq(uint32(i))
+		table[i].freq1 = freq(table[i].rightValue)
+	}
+	return table
+}
+
+// jxlSymbolReader reads (hybrid uint) values using an entropy code
+type jxlSymbolReader struct {
+	code       *jxlEntropyCode
+	br         *jxlBitReader
+	state      uint32
+	window     []uint32
+	numToCopy  uint32
+	copyPos    uint32
+	numDecoded uint32
+}
+
+func newJXLSymbolReader(code *jxlEntropyCode, br *jxlBitReader) *jxlSymbolReader {
+	result := &jxlSymbolReader{code: code, br: br, state: jxlANSSignature}
+	if !code.usePrefixCode {
+		result.state = uint32(br.bits(32))
+	}
+	if code.lz77 {
+		result.window = make([]uint32, jxlLZ77Window)
+	}
+	return result
+}
+
+func (r *jxlSymbolReader) readSymbol(cluster uint8) uint32 {
+	if r.code.usePrefixCode {
+		return r.code.prefixCodes[cluster].read(r.br)
+	}
+	logEntrySize := jxlANSLogTabSize - r.code.logAlphaSize
+	res := r.state & (jxlANSTabSize - 1)
+	entry := r.code.aliasTables[cluster][res>>logEntrySize]
+	pos := res & (1<<logEntrySize - 1)
+	symbol, offset, freq := res>>logEntrySize, pos, entry.freq0
+	if pos >= entry.cutoff {
+		symbol, offset, freq = entry.rightValue, entry.offset1+pos, entry.freq1
+	}
+	r.state = freq*(r.state>>jxlANSLogTabSize) + offset
+	if r.state < 1<<16 {
+		r.state = r.state<<16 | uint32(r.br.bits(16))
+	}
+	return symbol
+}
+
+// readUint reads a value for the context
+func (r *jxlSymbolReader) readUint(ctx int) uint32 {
+	if r.numToCopy > 0 {
+		return r.copy()
+	}
+	cluster := r.code.contextMap[ctx]
+	token := r.readSymbol(cluster)
+	if r.code.lz77 && token >= r.code.lz77MinSymbol {
+		r.numToCopy = r.code.lz77Config.read(r.br, token-r.code.lz77MinSymbol) + r.code.lz77MinLength
+		distanceCluster := r.code.contextMap[len(r.code.contextMap)-1]
+		distance := r.code.configs[distanceCluster].read(r.br, r.readSymbol(distanceCluster)) + 1
+		distance = min(distance, r.numDecoded, jxlLZ77Window)
+		r.copyPos = r.numDecoded - distance
+		return r.copy()
+	}
+	value := r.code.configs[cluster].read(r.br, token)
+	if r.window != nil {
+		r.window[r.numDecoded%jxlLZ77Window] = value
+		r.numDecoded++
+	}
+	return value
+}
+
+func (r *jxlSymbolReader) copy() uint32 {
+	// (a copy distance of zero - at the start - copies zeros)
+	value := r.window[r.copyPos%jxlLZ77Window]
+	r.copyPos++
+	r.numToCopy--
+	r.window[r.numDecoded%jxlLZ77Window] = value
+	r.numDecoded++
+	return value
+}
+
+// finalStateOK checks the ANS final state (always true for prefix codes)
+func (r *jxlSymbolReader) finalStateOK() bool {
+	return r.state == jxlANSSignature
+}
@@ -0,0 +1,453 @@
+package iccarus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func testJXLBitReader(fn func(w *testJXLBitWriter)) *jxlBitReader {
+	w := &testJXLBitWriter{}
+	fn(w)
+	return newJXLBitReader(bytes.NewReader(w.data))
+}
+
+func TestJXLBitReader(t *testing.T) {
+	t.Run("Bits", func(t *testing.T) {
+		br := newJXLBitReader(bytes.NewReader([]byte{0b10110100, 0xff, 0x01}))
+		assert.Equal(t, uint64(0b100), br.bits(3))
+		assert.False(t, br.bool())
+		assert.Equal(t, uint64(0x1ffb), br.peek(16))
+		assert.Equal(t, uint64(0x1ffb), br.bits(20))
+		require.NoError(t, br.err())
+		br.bits(1)
+		assert.ErrorIs(t, br.err(), io.ErrUnexpectedEOF)
+		assert.Equal(t, uint64(0), br.bits(8))
+	})
+	t.Run("Read Error", func(t *testing.T) {
+		br := newJXLBitReader(testByteReader{iotest.ErrReader(io.ErrClosedPipe)})
+		br.bits(1)
+		assert.ErrorIs(t, br.err(), io.ErrClosedPipe)
+	})
+	t.Run("U32", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 2)
+			w.write(2, 2)
+			w.write(5, 4)
+		})
+		assert.Equal(t, uint32(7), br.u32(jxlDist{0, 7}, jxlDist{0, 8}, jxlDist{4, 2}, jxlDist{6, 18}))
+		assert.Equal(t, uint32(7), br.enum())
+	})
+	t.Run("U64", func(t *testing.T) {
+		for _, v := range []uint64{0, 1, 16, 17, 272, 273, 4096, 1 << 40, 1<<64 - 1} {
+			br := testJXLBitReader(func(w *testJXLBitWriter) {
+				switch {
+				case v == 0:
+					w.write(0, 2)
+				case v <= 16:
+					w.write(1, 2)
+					w.write(v-1, 4)
+				case v <= 272:
+					w.write(2, 2)
+					w.write(v-17, 8)
+				default:
+					w.write(3, 2)
+					w.write(v&0xfff, 12)
+					shift := 12
+					for ; v>>shift != 0 && shift < 60; shift += 8 {
+						w.write(1, 1)
+						w.write(v>>shift&0xff, 8)
+					}
+					if shift == 60 {
+						w.write(1, 1)
+						w.write(v>>60, 4)
+					} else {
+						w.write(0, 1)
+					}
+				}
+			})
+			assert.Equal(t, v, br.u64())
+			assert.NoError(t, br.err())
+		}
+	})
+	t.Run("VarLenUint", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 1)
+			w.write(1, 1)
+			w.write(0, 3)
+			w.write(1, 1)
+			w.write(7, 3)
+			w.write(127, 7)
+			w.write(1, 1)
+			w.write(15, 4)
+			w.write(0x7fff, 15)
+		})
+		assert.Equal(t, uint32(0), br.varLenUint8())
+		assert.Equal(t, uint32(1), br.varLenUint8())
+		assert.Equal(t, uint32(255), br.varLenUint8())
+		assert.Equal(t, uint32(0xffff), br.varLenUint16())
+	})
+}
+
+// testByteReader adapts a reader to an io.ByteReader (without buffering)
+type testByteReader struct {
+	r io.Reader
+}
+
+func (b testByteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
+
+func TestJXLHybridUintConfig(t *testing.T) {
+	br := testJXLBitReader(func(w *testJXLBitWriter) {
+		w.write(4, 4) // split exponent
+		w.write(1, 3) // msb in token
+		w.write(1, 2) // lsb in token
+		w.write(0b101, 3)
+	})
+	c, err := readJXLHybridUintConfig(br, 8)
+	require.NoError(t, err)
+	assert.Equal(t, jxlHybridUintConfig{4, 1, 1}, c)
+	assert.Equal(t, uint32(15), c.read(br, 15))
+	// token 16 + 2*(n=3-2=1)... token 21 = split + (1 << 2) | msb 0 | lsb 1 -> n = 2 + 1 = 3 bits
+	assert.Equal(t, uint32(0b10_101_1), c.read(br, 21))
+	t.Run("Invalid", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(2, 4)
+			w.write(3, 2)
+		})
+		_, err := readJXLHybridUintConfig(br, 8)
+		assert.Error(t, err)
+	})
+}
+
+func TestJXLHistogram(t *testing.T) {
+	t.Run("Simple One Symbol", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(1, 1)
+			w.write(0, 1)
+			w.write(1, 1)
+			w.write(1, 3)
+			w.write(1, 1) // symbol 3
+		})
+		counts, err := readJXLHistogram(br)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 0, 0, 4096}, counts)
+	})
+	t.Run("Simple Two Symbols", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(1, 1)
+			w.write(1, 1)
+			w.write(1, 1)
+			w.write(0, 3) // symbol 1
+			w.write(0, 1) // symbol 0
+			w.write(1000, 12)
+		})
+		counts, err := readJXLHistogram(br)
+		require.NoError(t, err)
+		assert.Equal(t, []int{3096, 1000}, counts)
+	})
+	t.Run("Flat", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 1)
+			w.write(1, 1)
+			w.write(1, 1)
+			w.write(1, 3)
+			w.write(0, 1) // 2 + 1 symbols
+		})
+		counts, err := readJXLHistogram(br)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1366, 1365, 1365}, counts)
+	})
+	t.Run("Log Counts", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 2) // not simple, not flat
+			w.write(1, 1) // shift...
+			w.write(0, 1) // ...log 1
+			w.write(1, 1) // ...shift = (1 | 2) - 1 = 2
+			w.write(1, 1) // length 1 + 3 = 4
+			w.write(0, 3)
+			w.write(jxlLogCountCodes[10], jxlLogCountLengths[10])
+			w.write(jxlLogCountCodes[1], jxlLogCountLengths[1])
+			w.write(jxlLogCountCodes[0], jxlLogCountLengths[0])
+			w.write(jxlLogCountCodes[12], jxlLogCountLengths[12])
+			w.write(1, 1) // extra bit of log count 10 (bit count = min(9, 2 - 1) = 1)
+		})
+		counts, err := readJXLHistogram(br)
+		require.NoError(t, err)
+		assert.Equal(t, []int{512 + 256, 1, 0, 4096 - 768 - 1}, counts)
+	})
+	t.Run("RLE", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 2)
+			w.write(0, 1) // shift 0
+			w.write(1, 1) // length 5 + 3 = 8
+			w.write(2, 3)
+			w.write(1, 2)
+			w.write(jxlLogCountCodes[7], jxlLogCountLengths[7])
+			w.write(jxlLogCountCodes[13], jxlLogCountLengths[13])
+			w.write(0, 1) // repeat 4 times
+			w.write(jxlLogCountCodes[0], jxlLogCountLengths[0])
+			w.write(jxlLogCountCodes[0], jxlLogCountLengths[0])
+			w.write(jxlLogCountCodes[12], jxlLogCountLengths[12])
+		})
+		counts, err := readJXLHistogram(br)
+		require.NoError(t, err)
+		assert.Equal(t, []int{64, 64, 64, 64, 64, 0, 0, 4096 - 5*64}, counts)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, fn := range map[string]func(w *testJXLBitWriter){
+			"Duplicate": func(w *testJXLBitWriter) {
+				w.write(0b111, 3)
+				w.write(0, 3)
+				w.write(0b01, 2)
+				w.write(0, 3)
+			},
+			"Shift": func(w *testJXLBitWriter) {
+				w.write(0, 2)
+				w.write(0b111, 3)
+				w.write(7, 3)
+			},
+			"Count": func(w *testJXLBitWriter) {
+				w.write(0, 2)
+				w.write(0, 1)
+				w.write(0, 1) // length 3
+				for i := 0; i < 3; i++ {
+					w.write(jxlLogCountCodes[12], jxlLogCountLengths[12])
+				}
+			},
+			"All RLE": func(w *testJXLBitWriter) {
+				w.write(0, 2)
+				w.write(0, 1)
+				w.write(0, 1)
+				w.write(jxlLogCountCodes[13], jxlLogCountLengths[13])
+				w.write(0, 1)
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := readJXLHistogram(testJXLBitReader(fn))
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestJXLAliasTable(t *testing.T) {
+	for name, counts := range map[string][]int{
+		"Flat":     {1024, 1024, 1024, 1024},
+		"Skewed":   {4000, 1, 0, 95},
+		"Many":     {100, 200, 300, 400, 500, 600, 700, 800, 496},
+		"Single":   {0, 0, 4096},
+		"Trailing": {2048, 2048, 0, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, logAlphaSize := range []uint{5, 8} {
+				table := jxlAliasTable(counts, logAlphaSize)
+				logEntrySize := jxlANSLogTabSize - logAlphaSize
+				seen := map[[2]uint32]bool{}
+				for res := uint32(0); res < jxlANSTabSize; res++ {
+					entry := table[res>>logEntrySize]
+					pos := res & (1<<logEntrySize - 1)
+					symbol, offset, freq := res>>logEntrySize, pos, entry.freq0
+					if pos >= entry.cutoff {
+						symbol, offset, freq = entry.rightValue, entry.offset1+pos, entry.freq1
+					}
+					require.Less(t, int(symbol), len(counts))
+					assert.Equal(t, uint32(counts[symbol]), freq)
+					assert.Less(t, offset, freq)
+					seen[[2]uint32{symbol, offset}] = true
+				}
+				// every (symbol, offset) is decoded from exactly one state...
+				assert.Len(t, seen, jxlANSTabSize)
+			}
+		})
+	}
+}
+
+func TestJXLPrefixCode(t *testing.T) {
+	t.Run("Simple", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			symbols []uint64
+			tree    bool
+			codes   map[uint32][]uint64
+		}{
+			"One":        {symbols: []uint64{9}, codes: map[uint32][]uint64{9: {}}},
+			"Two":        {symbols: []uint64{9, 2}, codes: map[uint32][]uint64{2: {0}, 9: {1}}},
+			"Three":      {symbols: []uint64{9, 7, 2}, codes: map[uint32][]uint64{9: {0}, 2: {1, 0}, 7: {1, 1}}},
+			"Four":       {symbols: []uint64{9, 7, 2, 1}, codes: map[uint32][]uint64{1: {0, 0}, 2: {0, 1}, 7: {1, 0}, 9: {1, 1}}},
+			"Four Tree":  {symbols: []uint64{9, 7, 2, 1}, tree: true, codes: map[uint32][]uint64{9: {0}, 7: {1, 0}, 1: {1, 1, 0}, 2: {1, 1, 1}}},
+			"Four Tree ": {symbols: []uint64{0, 1, 3, 2}, tree: true, codes: map[uint32][]uint64{0: {0}, 1: {1, 0}, 2: {1, 1, 0}, 3: {1, 1, 1}}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for symbol, code := range tc.codes {
+					br := testJXLBitReader(func(w *testJXLBitWriter) {
+						w.write(1, 2)
+						w.write(uint64(len(tc.symbols)-1), 2)
+						for _, s := range tc.symbols {
+							w.write(s, 4)
+						}
+						if len(tc.symbols) == 4 {
+							w.write(map[bool]uint64{false: 0, true: 1}[tc.tree], 1)
+						}
+						for _, b := range code {
+							w.write(b, 1)
+						}
+					})
+					c, err := readJXLPrefixCode(br, 10)
+					require.NoError(t, err)
+					assert.Equal(t, symbol, c.read(br))
+					assert.NoError(t, br.err())
+				}
+			})
+		}
+	})
+	t.Run("Complex With Repeats", func(t *testing.T) {
+		// code length code: 2 (1 bit), 16 - repeat previous & 17 - repeat zero (2 bits each)
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 2) // complex, no skip
+			// order: 1, 2, 3, 4, 0, 5, 17, 6, 16 (static code: 0 = 00, 1 = 0111, 2 = 011)
+			for _, v := range []int{0, 1, 0, 0, 0, 0, 2, 0, 2} {
+				switch v {
+				case 0:
+					w.write(0, 2)
+				case 1:
+					w.write(0b0111, 4)
+				case 2:
+					w.write(0b011, 3)
+				}
+			}
+			w.write(0, 1)    // symbol 0 length 2
+			w.write(0b11, 2) // 3 zeros...
+			w.write(0, 3)
+			w.write(0b01, 2) // 3 x length 2...
+			w.write(0, 2)
+		})
+		c, err := readJXLPrefixCode(br, 8)
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{0, 4, 5, 6}, c.symbols)
+		assert.Equal(t, 4, c.counts[2])
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, fn := range map[string]func(w *testJXLBitWriter){
+			"Symbol Out Of Range": func(w *testJXLBitWriter) {
+				w.write(1, 2)
+				w.write(0, 2)
+				w.write(15, 4)
+			},
+			"Duplicate Symbol": func(w *testJXLBitWriter) {
+				w.write(1, 2)
+				w.write(1, 2)
+				w.write(3, 4)
+				w.write(3, 4)
+			},
+			"Incomplete Code Length Code": func(w *testJXLBitWriter) {
+				w.write(0, 2)
+				w.write(0b011, 3)
+				w.write(0b011, 3)
+				w.write(0, 32)
+			},
+			"Incomplete Code": func(w *testJXLBitWriter) {
+				w.write(0, 2)
+				for i := 0; i < 18; i++ {
+					if jxlCodeLengthCodeOrder[i] == 4 {
+						w.write(0b0111, 4)
+					} else {
+						w.write(0, 2)
+					}
+				}
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := readJXLPrefixCode(testJXLBitReader(fn), 10)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestJXLEntropyCode(t *testing.T) {
+	t.Run("LZ77", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(1, 1)  // lz77...
+			w.write(0, 2)  // ...min symbol 224
+			w.write(0, 2)  // ...min length 3
+			w.write(8, 4)  // ...length config
+			w.write(1, 1)  // simple context map...
+			w.write(0, 2)  // ...one cluster
+			w.write(1, 1)  // prefix code
+			w.write(8, 4)  // config...
+			w.write(0, 4)  // ...
+			w.write(0, 4)  // ...
+			w.write(1, 1)  // alphabet size...
+			w.write(7, 4)  // ...
+			w.write(96, 7) // ...225
+			w.write(1, 2)  // simple code...
+			w.write(2, 2)  // ...3 symbols: 5 = 0, 0 = 10, 224 = 11
+			w.write(5, 8)
+			w.write(224, 8)
+			w.write(0, 8)
+			// 5, 5, copy 3 at distance 1, 0
+			w.write(0, 1)
+			w.write(0, 1)
+			w.write(0b11, 2)
+			w.write(0b01, 2)
+			w.write(0b01, 2)
+		})
+		code, err := readJXLEntropyCode(br, 1, false)
+		require.NoError(t, err)
+		reader := newJXLSymbolReader(code, br)
+		var values []uint32
+		for i := 0; i < 6; i++ {
+			values = append(values, reader.readUint(0))
+		}
+		require.NoError(t, br.err())
+		assert.Equal(t, []uint32{5, 5, 5, 5, 5, 0}, values)
+		assert.True(t, reader.finalStateOK())
+	})
+	t.Run("LZ77 Disallowed", func(t *testing.T) {
+		_, err := readJXLEntropyCode(testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(1, 1)
+		}), 1, true)
+		assert.Error(t, err)
+	})
+	t.Run("Context Map MTF", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(0, 1) // not simple
+			w.write(1, 1) // MTF
+			w.write(0, 1) // no lz77
+			w.write(1, 1) // prefix code
+			w.write(8, 4)
+			w.write(0, 4)
+			w.write(0, 4)
+			w.write(1, 1) // alphabet size 2
+			w.write(0, 4)
+			w.write(1, 2) // simple code...
+			w.write(1, 2) // ...symbols 0 & 1
+			w.write(0, 1)
+			w.write(1, 1)
+			w.write(1, 1)
+			w.write(0, 1)
+			w.write(1, 1)
+		})
+		contextMap := make([]uint8, 3)
+		n, err := readJXLContextMap(br, contextMap)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []uint8{1, 1, 0}, contextMap)
+	})
+	t.Run("Context Map Unused Cluster", func(t *testing.T) {
+		br := testJXLBitReader(func(w *testJXLBitWriter) {
+			w.write(1, 1)
+			w.write(1, 2)
+			w.write(1, 1)
+			w.write(1, 1)
+		})
+		_, err := readJXLContextMap(br, make([]uint8, 2))
+		assert.Error(t, err)
+	})
+}
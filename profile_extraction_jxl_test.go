@@ -0,0 +1,401 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testJXLBitWriter struct {
+	data []byte
+	n    uint
+}
+
+func (w *testJXLBitWriter) write(v uint64, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (w.n % 8)
+		w.n++
+	}
+}
+
+func (w *testJXLBitWriter) u64(v uint64) {
+	w.write(3, 2)
+	w.write(v&0xfff, 12)
+	for v >>= 12; v != 0; v >>= 8 {
+		w.write(1, 1)
+		w.write(v&0xff, 8)
+	}
+	w.write(0, 1)
+}
+
+func testJXLVarInt(v int) (result []byte) {
+	for ; v >= 0x80; v >>= 7 {
+		result = append(result, byte(v&0x7f|0x80))
+	}
+	return append(result, byte(v))
+}
+
+// testJXLEncodeICC encodes an ICC profile as a JPEG XL ICC stream (header residuals followed by a single insert command)
+func testJXLEncodeICC(icc []byte) []byte {
+	header := jxlICCInitialHeader(uint64(len(icc)))
+	var data []byte
+	for i := 0; i < jxlICCHeaderSizeLimit; i++ {
+		jxlPredictICCHeader(icc[:i], &header, i)
+		data = append(data, icc[i]-header[i])
+	}
+	data = append(data, icc[jxlICCHeaderSizeLimit:]...)
+	commands := append([]byte{0, jxlICCCommandInsert}, testJXLVarInt(len(icc)-jxlICCHeaderSizeLimit)...)
+	result := append(testJXLVarInt(len(icc)), testJXLVarInt(len(commands))...)
+	return append(append(result, commands...), data...)
+}
+
+// testJXLWriteICCStream writes the encoded ICC stream - using either a flat 8-bit prefix code or a flat ANS distribution
+func testJXLWriteICCStream(w *testJXLBitWriter, enc []byte, ans bool) {
+	w.u64(uint64(len(enc)))
+	w.write(0, 1) // no lz77
+	w.write(1, 1) // simple context map...
+	w.write(0, 2) // ...all contexts in one cluster
+	if !ans {
+		w.write(1, 1)   // prefix code
+		w.write(8, 4)   // split exponent
+		w.write(0, 4)   // msb in token
+		w.write(0, 4)   // lsb in token
+		w.write(1, 1)   // alphabet size...
+		w.write(7, 4)   // ...
+		w.write(127, 7) // ...256
+		w.write(0, 2)   // complex code
+		for i := 0; i < 18; i++ {
+			if jxlCodeLengthCodeOrder[i] == 8 {
+				w.write(2, 2) // code length 8 has code length 3 (the only code length used)
+			} else {
+				w.write(0, 2)
+			}
+		}
+		for _, b := range enc {
+			for i := 7; i >= 0; i-- {
+				w.write(uint64(b>>i&1), 1)
+			}
+		}
+		return
+	}
+	w.write(0, 1)   // ANS
+	w.write(3, 2)   // log alpha size 8
+	w.write(8, 4)   // split exponent
+	w.write(0, 1)   // not simple histogram
+	w.write(1, 1)   // flat histogram...
+	w.write(1, 1)   // ...
+	w.write(7, 3)   // ...
+	w.write(127, 7) // ...256 symbols
+	// (flat 256 symbols is an identity alias table - so state = 16 * (state >> 12) + offset)
+	state := uint32(jxlANSSignature)
+	var words []uint32
+	for i := len(enc) - 1; i >= 0; i-- {
+		if state >= 1<<24 {
+			words = append(words, state&0xffff)
+			state >>= 16
+		}
+		state = (state/16)<<12 | uint32(enc[i])<<4 | state%16
+	}
+	w.write(uint64(state), 32)
+	for i := len(words) - 1; i >= 0; i-- {
+		w.write(uint64(words[i]), 16)
+	}
+}
+
+// testJXLCodestream builds a JPEG XL codestream header (followed by the ICC stream if icc is not nil)
+func testJXLCodestream(icc []byte, ans bool) []byte {
+	w := &testJXLBitWriter{}
+	w.write(0x0aff, 16)
+	w.write(1, 1) // small size...
+	w.write(0, 5) // ...height 8
+	w.write(1, 3) // ...ratio 1:1
+	w.write(0, 1) // metadata not all default
+	w.write(1, 1) // extra fields...
+	w.write(0, 3) // ...orientation
+	w.write(1, 1) // ...have intrinsic size
+	w.write(0, 1) // ...not small
+	w.write(1, 2) // ...
+	w.write(99, 13)
+	w.write(0, 3)
+	w.write(1, 2)
+	w.write(199, 13)
+	w.write(0, 1) // ...no preview
+	w.write(0, 1) // ...no animation
+	w.write(0, 1) // bit depth integer...
+	w.write(0, 2) // ...8 bits
+	w.write(1, 1) // modular 16-bit buffers
+	w.write(1, 2) // one extra channel...
+	w.write(0, 1) // ...not all default
+	w.write(0, 2) // ...alpha
+	w.write(0, 1) // ...integer
+	w.write(1, 2) // ...10 bits
+	w.write(0, 2) // ...dim shift 0
+	w.write(1, 2) // ...name length...
+	w.write(3, 4) // ...3
+	w.write('a', 8)
+	w.write('b', 8)
+	w.write('c', 8)
+	w.write(1, 1) // ...alpha associated
+	w.write(1, 1) // xyb encoded
+	w.write(0, 1) // color encoding not all default
+	if icc == nil {
+		w.write(0, 1) // no ICC...
+		w.write(0, 16)
+		return w.data
+	}
+	w.write(1, 1)  // want ICC
+	w.write(0, 2)  // RGB
+	w.write(0, 1)  // tone mapping not all default...
+	w.write(0, 49) // ...
+	w.write(1, 2)  // extensions...
+	w.write(1, 4)  // ...bit 1
+	w.write(1, 2)  // ...of...
+	w.write(2, 4)  // ...3 bits
+	w.write(5, 3)
+	w.write(0, 1) // transform data not all default...
+	w.write(1, 1) // ...default opsin matrix
+	w.write(1, 3) // ...upsampling 2 weights
+	w.write(0, 15*16)
+	testJXLWriteICCStream(w, testJXLEncodeICC(icc), ans)
+	w.write(0, 32) // (frames would follow)
+	return w.data
+}
+
+func testJXLContainer(codestream []byte, parts int) []byte {
+	result := bytes.Join([][]byte{
+		[]byte(jxlContainerSignature),
+		testISOBox("ftyp", []byte("jxl \x00\x00\x00\x00jxl ")),
+		testISOBox("jxll", []byte{5}),
+	}, nil)
+	if parts == 0 {
+		return append(result, testISOBox("jxlc", codestream)...)
+	}
+	size := (len(codestream) + parts - 1) / parts
+	for i := 0; i < parts; i++ {
+		index := uint32(i)
+		if i == parts-1 {
+			index |= 0x80000000
+		}
+		part := codestream[min(i*size, len(codestream)):min((i+1)*size, len(codestream))]
+		result = append(result, testISOBox("jxlp", binary.BigEndian.AppendUint32(nil, index), part)...)
+		if i == 0 {
+			result = append(result, testISOBox("Exif", []byte{0, 0, 0, 0, 'M', 'M', 0, 42})...)
+		}
+	}
+	return result
+}
+
+func TestExtractFromJXL(t *testing.T) {
+	iccData := testProfileBytes(t, testEmbedProfile)
+	expect := testProfile(t, testEmbedProfile)
+	t.Run("Codestream", func(t *testing.T) {
+		p, err := ExtractFromJXL(bytes.NewReader(testJXLCodestream(iccData, false)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+		assert.Equal(t, expect.TagHeaderTable, p.TagHeaderTable)
+		// format detection...
+		p, err = ExtractFromImage(bytes.NewReader(testJXLCodestream(iccData, false)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("Codestream ANS", func(t *testing.T) {
+		p, err := ExtractFromJXL(bytes.NewReader(testJXLCodestream(iccData, true)), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+		assert.Empty(t, p.TagBlocks)
+	})
+	t.Run("Container", func(t *testing.T) {
+		data := testJXLContainer(testJXLCodestream(iccData, false), 0)
+		p, err := ExtractFromJXL(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+		p, err = ExtractFromImage(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("Container Partial Codestreams", func(t *testing.T) {
+		data := testJXLContainer(testJXLCodestream(iccData, true), 3)
+		p, err := ExtractFromJXL(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		_, err := ExtractFromJXL(bytes.NewReader(testJXLCodestream(nil, false)), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		_, err = ExtractFromJXL(bytes.NewReader(testJXLContainer(testJXLCodestream(nil, false), 2)), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+		// all default metadata...
+		_, err = ExtractFromImage(bytes.NewReader([]byte{0xff, 0x0a, 0x41, 0x02}), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		codestream := testJXLCodestream(iccData, false)
+		for name, data := range map[string][]byte{
+			"Empty":           {},
+			"Not JXL":         []byte("\x00\x00\x00\x0cJXL \r\n\x87\x00"),
+			"Truncated":       codestream[:len(codestream)/2],
+			"Truncated Box":   testJXLContainer(codestream, 0)[:100],
+			"No Codestream":   testJXLContainer(nil, 0)[:40],
+			"Invalid Profile": testJXLCodestream(iccData[:130], true),
+			"Bad Box Header":  append([]byte(jxlContainerSignature), 0, 0, 0),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromJXL(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
+
+func TestReadJXLExtraChannelInfo(t *testing.T) {
+	read := func(typ uint64) error {
+		w := &testJXLBitWriter{}
+		w.write(0, 1) // not all default
+		w.write(2, 2) // type...
+		w.write(typ-2, 4)
+		w.write(0, 1) // integer...
+		w.write(0, 2) // ...8 bits
+		w.write(0, 2) // dim shift 0
+		w.write(0, 2) // no name
+		return readJXLExtraChannelInfo(newJXLBitReader(bytes.NewReader(w.data)))
+	}
+	for _, typ := range []uint64{3, 4, 6, 15, 16} {
+		assert.NoError(t, read(typ), typ)
+	}
+	for typ := uint64(7); typ <= 14; typ++ {
+		assert.Error(t, read(typ), typ)
+	}
+	assert.Error(t, read(17))
+}
+
+func TestJXLUnpredictICC(t *testing.T) {
+	t.Run("Tag List", func(t *testing.T) {
+		commands := []byte{
+			4,        // 3 tags
+			0x80 | 2, // rTRC/gTRC/bTRC with size...
+			14,       // ...14
+			0,        // end of tag list
+			16 + 5,   // curv type
+			1, 6,     // insert 6 bytes
+		}
+		enc := append(testJXLVarInt(182), testJXLVarInt(len(commands))...)
+		enc = append(enc, commands...)
+		enc = append(enc, make([]byte, 128)...)
+		enc = append(enc, 0, 0, 0, 1, 1, 0)
+		icc, err := jxlUnpredictICC(enc)
+		require.NoError(t, err)
+		require.Len(t, icc, 182)
+		assert.Equal(t, []byte{0, 0, 0, 182}, icc[0:4])
+		assert.Equal(t, "mntr", string(icc[12:16]))
+		assert.Equal(t, "acsp", string(icc[36:40]))
+		assert.Equal(t, []byte{0, 0, 0, 3}, icc[128:132])
+		assert.Equal(t, "rTRC\x00\x00\x00\xa4\x00\x00\x00\x0e", string(icc[132:144]))
+		assert.Equal(t, "gTRC\x00\x00\x00\xa4\x00\x00\x00\x0e", string(icc[144:156]))
+		assert.Equal(t, "bTRC\x00\x00\x00\xa4\x00\x00\x00\x0e", string(icc[156:168]))
+		assert.Equal(t, "curv\x00\x00\x00\x00\x00\x00\x00\x01\x01\x00", string(icc[168:]))
+	})
+	t.Run("Tag List XYZ", func(t *testing.T) {
+		commands := []byte{
+			3,               // 2 tags
+			1,               // unknown tag (from data)
+			0x40 | 0x80 | 3, // rXYZ/gXYZ/bXYZ with offset & size...
+			200, 1,          // ...200
+			20, // ...20
+		}
+		enc := append(testJXLVarInt(128+4+4*12), testJXLVarInt(len(commands))...)
+		enc = append(enc, commands...)
+		enc = append(enc, make([]byte, 128)...)
+		enc = append(enc, "abcd"...)
+		icc, err := jxlUnpredictICC(enc)
+		require.NoError(t, err)
+		assert.Equal(t, "abcd\x00\x00\x00\x98\x00\x00\x00\x00", string(icc[132:144]))
+		assert.Equal(t, "rXYZ\x00\x00\x00\xc8\x00\x00\x00\x14", string(icc[144:156]))
+		assert.Equal(t, "gXYZ\x00\x00\x00\xdc\x00\x00\x00\x14", string(icc[156:168]))
+		assert.Equal(t, "bXYZ\x00\x00\x00\xf0\x00\x00\x00\x14", string(icc[168:180]))
+	})
+	t.Run("Commands", func(t *testing.T) {
+		commands := []byte{
+			0,    // no tag list
+			10,   // XYZ
+			2, 4, // shuffle2 4 bytes
+			4, 0, 3, // predict (width 1, order 0) 3 bytes
+			4, 1 | 4, 4, // predict (width 2, order 1) 4 bytes
+		}
+		enc := append(testJXLVarInt(128+20+4+3+4), testJXLVarInt(len(commands))...)
+		enc = append(enc, commands...)
+		enc = append(enc, make([]byte, 128)...)
+		enc = append(enc, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12)
+		enc = append(enc, 1, 2, 3, 4)
+		enc = append(enc, 1, 1, 1)
+		enc = append(enc, 0, 0, 0, 0)
+		icc, err := jxlUnpredictICC(enc)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("XYZ \x00\x00\x00\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c"), icc[128:148])
+		assert.Equal(t, []byte{1, 3, 2, 4}, icc[148:152])
+		assert.Equal(t, []byte{5, 6, 7}, icc[152:155])
+		// uint16s predicted linearly from 0x0405, 0x0607...
+		assert.Equal(t, []byte{0x08, 0x09, 0x0a, 0x0b}, icc[155:159])
+	})
+	t.Run("Header Only", func(t *testing.T) {
+		enc := append(testJXLVarInt(16), 0)
+		enc = append(enc, make([]byte, 16)...)
+		icc, err := jxlUnpredictICC(enc)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 16, 0, 0, 0, 0, 4, 0, 0, 0, 'm', 'n', 't', 'r'}, icc)
+	})
+	t.Run("Header Prediction", func(t *testing.T) {
+		header := jxlICCInitialHeader(200)
+		icc := []byte("\x00\x00\x00\xc8lcms")
+		jxlPredictICCHeader(icc, &header, 8)
+		assert.Equal(t, "lcms", string(header[80:84]))
+		icc = append(make([]byte, 40), 'A')
+		jxlPredictICCHeader(icc, &header, 41)
+		assert.Equal(t, "PPL", string(header[41:44]))
+		icc = append(make([]byte, 40), 'S', 'G')
+		jxlPredictICCHeader(icc, &header, 42)
+		assert.Equal(t, "I ", string(header[42:44]))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		header := func(osize int, commands ...byte) []byte {
+			enc := append(testJXLVarInt(osize), testJXLVarInt(len(commands))...)
+			return append(append(enc, commands...), make([]byte, 128)...)
+		}
+		for name, enc := range map[string][]byte{
+			"Empty":           {},
+			"Commands Size":   {200, 1, 10},
+			"Short Header":    append(testJXLVarInt(130), 0, 0, 0),
+			"No Commands":     header(130),
+			"Unknown Tag":     header(140, 3, 63),
+			"Unknown Command": header(140, 0, 9),
+			"Insert Overrun":  header(140, 0, 1, 12),
+			"Size Mismatch":   append(header(140, 0, 1, 2), 0, 0),
+			"Unused Data":     append(header(128), 0),
+			"Bad Predict":     append(header(140, 0, 4, 2, 4), 0, 0, 0, 0),
+			"Bad Stride":      append(header(140, 0, 4, 16, 0, 4), 0, 0, 0, 0),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := jxlUnpredictICC(enc)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestJXLICCContext(t *testing.T) {
+	assert.Equal(t, 0, jxlICCContext(128, 'a', 'b'))
+	assert.Equal(t, 1, jxlICCContext(129, 'a', 'b'))
+	assert.Equal(t, 1+1+8, jxlICCContext(129, '0', ','))
+	assert.Equal(t, 1+2+2*8, jxlICCContext(129, 0, 0))
+	assert.Equal(t, 1+3+2*8, jxlICCContext(129, 1, 15))
+	assert.Equal(t, 1+4+3*8, jxlICCContext(129, 15, 241))
+	assert.Equal(t, 1+5+4*8, jxlICCContext(129, 241, 128))
+	assert.Equal(t, 1+6+3*8, jxlICCContext(129, 255, 255))
+	assert.Equal(t, 1+7+4*8, jxlICCContext(129, 128, 16))
+	assert.Equal(t, 40, jxlICCContext(1000, 200, 200))
+}
@@ -0,0 +1,945 @@
+package iccarus
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PDFProfileUsage is how an ICC profile (stream) is used in a PDF document
+//
+// usages are flags - the same profile stream may be used, for example, both by a color space and an output intent
+type PDFProfileUsage uint8
+
+const (
+	// PDFUsageICCBased is a profile used by an /ICCBased color space
+	PDFUsageICCBased PDFProfileUsage = 1 << iota
+	// PDFUsageOutputIntent is a profile used as the /DestOutputProfile of an output intent
+	PDFUsageOutputIntent
+)
+
+func (u PDFProfileUsage) String() string {
+	var names []string
+	if u&PDFUsageICCBased != 0 {
+		names = append(names, "ICCBased")
+	}
+	if u&PDFUsageOutputIntent != 0 {
+		names = append(names, "OutputIntent")
+	}
+	if unknown := u &^ (PDFUsageICCBased | PDFUsageOutputIntent); unknown != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("Unknown (%d)", uint8(unknown)))
+	}
+	return strings.Join(names, "|")
+}
+
+// PDFProfile is an ICC profile found in a PDF document
+type PDFProfile struct {
+	Profile *Profile
+	// ObjectNumber is the object number of the ICC profile stream
+	ObjectNumber int
+	// Generation is the generation number of the ICC profile stream (the highest referenced, if referenced with differing generations)
+	Generation int
+	Usage      PDFProfileUsage
+}
+
+// isPDF reports whether data starts like a PDF document - the %PDF- header may be preceded by junk (readers
+// accept it anywhere in the first 1024 bytes)
+func isPDF(data []byte) bool {
+	return bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-"))
+}
+
+// ExtractFromPDF extracts all ICC profiles from a PDF document
+//
+// the objects are located using the cross-reference tables/streams (including compressed object streams) - if
+// the cross-reference information is missing or broken, the objects are located by scanning the document
+//
+// profiles are those referenced by /ICCBased color spaces and output intent /DestOutputProfile entries -
+// returned in object number order
+//
+// returns ErrNoICCProfile if the document has no ICC profiles
+func ExtractFromPDF(r io.Reader, options *ParseOptions) ([]*PDFProfile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if !isPDF(data) {
+		return nil, errors.New("not a valid PDF file")
+	}
+	doc := newPDFDocument(data)
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, errors.New("encrypted PDF documents are not supported")
+	}
+	refs := make(map[int]*pdfProfileRef)
+	for _, num := range doc.objectNumbers() {
+		if obj, err := doc.object(num); err == nil {
+			collectPDFProfileRefs(obj, refs, 0)
+		}
+	}
+	nums := slices.Sorted(maps.Keys(refs))
+	result := make([]*PDFProfile, 0, len(nums))
+	for _, num := range nums {
+		obj, err := doc.object(num)
+		if err != nil {
+			return nil, fmt.Errorf("PDF object %d: %w", num, err)
+		}
+		stream, ok := obj.(*pdfStream)
+		if !ok {
+			return nil, fmt.Errorf("PDF object %d: ICC profile is not a stream", num)
+		}
+		iccData, err := doc.streamData(stream)
+		if err != nil {
+			return nil, fmt.Errorf("PDF object %d: %w", num, err)
+		}
+		p, err := ParseProfile(bytes.NewReader(iccData), options)
+		if err != nil {
+			return nil, fmt.Errorf("PDF object %d: %w", num, err)
+		}
+		result = append(result, &PDFProfile{Profile: p, ObjectNumber: num, Generation: refs[num].gen, Usage: refs[num].usage})
+	}
+	if len(result) == 0 {
+		return nil, ErrNoICCProfile
+	}
+	return result, nil
+}
+
+// pdfProfileRef is a referenced ICC profile stream - objects are keyed by object number only (generation numbers
+// are not used to locate objects), so the highest referenced generation is kept
+type pdfProfileRef struct {
+	gen   int
+	usage PDFProfileUsage
+}
+
+func addPDFProfileRef(refs map[int]*pdfProfileRef, ref pdfRef, usage PDFProfileUsage) {
+	if existing, ok := refs[ref.num]; ok {
+		existing.gen = max(existing.gen, ref.gen)
+		existing.usage |= usage
+	} else {
+		refs[ref.num] = &pdfProfileRef{gen: ref.gen, usage: usage}
+	}
+}
+
+// collectPDFProfileRefs collects the (ICC profile stream) references of /ICCBased color spaces and /DestOutputProfile entries
+func collectPDFProfileRefs(obj any, usages map[int]*pdfProfileRef, depth int) {
+	if depth > pdfMaxDepth {
+		return
+	}
+	switch v := obj.(type) {
+	case pdfArray:
+		if len(v) >= 2 && v[0] == pdfName("ICCBased") {
+			if ref, ok := v[1].(pdfRef); ok {
+				addPDFProfileRef(usages, ref, PDFUsageICCBased)
+			}
+		}
+		for _, item := range v {
+			collectPDFProfileRefs(item, usages, depth+1)
+		}
+	case pdfDict:
+		if ref, ok := v["DestOutputProfile"].(pdfRef); ok {
+			addPDFProfileRef(usages, ref, PDFUsageOutputIntent)
+		}
+		for _, item := range v {
+			collectPDFProfileRefs(item, usages, depth+1)
+		}
+	case *pdfStream:
+		collectPDFProfileRefs(v.dict, usages, depth)
+	}
+}
+
+type (
+	pdfName  string
+	pdfArray []any
+	pdfDict  map[pdfName]any
+	pdfRef   struct {
+		num int
+		gen int
+	}
+	pdfStream struct {
+		dict pdfDict
+		data []byte // (encoded)
+	}
+)
+
+// pdfXrefEntry is a cross-reference entry - an object at an offset (inUse) or in an object stream (compressed)
+type pdfXrefEntry struct {
+	compressed bool
+	offset     int // offset or object stream number
+	index      int // index within object stream
+}
+
+type pdfObjectStream struct {
+	data    []byte
+	first   int
+	numbers []int
+	offsets []int
+}
+
+type pdfDocument struct {
+	data          []byte
+	xref          map[int]pdfXrefEntry
+	trailer       pdfDict
+	objects       map[int]any
+	objectStreams map[int]*pdfObjectStream
+	loading       map[int]bool
+}
+
+func newPDFDocument(data []byte) *pdfDocument {
+	doc := &pdfDocument{
+		data:          data,
+		xref:          make(map[int]pdfXrefEntry),
+		objects:       make(map[int]any),
+		objectStreams: make(map[int]*pdfObjectStream),
+		loading:       make(map[int]bool),
+	}
+	if err := doc.readXref(); err != nil || !doc.xrefValid() {
+		doc.xref = make(map[int]pdfXrefEntry)
+		doc.objects = make(map[int]any)
+		doc.objectStreams = make(map[int]*pdfObjectStream)
+		doc.scanObjects()
+	}
+	return doc
+}
+
+// xrefValid checks that every (uncompressed) cross-reference entry points at its object
+func (d *pdfDocument) xrefValid() bool {
+	for num, entry := range d.xref {
+		if entry.compressed || entry.offset < 0 {
+			continue
+		} else if entry.offset >= len(d.data) {
+			return false
+		}
+		m := pdfObjectPattern.FindSubmatchIndex(d.data[entry.offset:min(entry.offset+64, len(d.data))])
+		if m == nil || m[0] != 0 || string(d.data[entry.offset+m[2]:entry.offset+m[3]]) != strconv.Itoa(num) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *pdfDocument) objectNumbers() []int {
+	result := make([]int, 0, len(d.xref))
+	for num := range d.xref {
+		result = append(result, num)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// readXref reads the cross-reference sections (tables and/or streams) - starting at startxref and following /Prev
+func (d *pdfDocument) readXref() error {
+	pos := bytes.LastIndex(d.data, []byte("startxref"))
+	if pos < 0 {
+		return errors.New("no startxref")
+	}
+	p := &pdfParser{data: d.data, pos: pos + len("startxref")}
+	offset, err := p.integer()
+	if err != nil {
+		return err
+	}
+	visited := make(map[int]bool)
+	for offset >= 0 && !visited[offset] {
+		visited[offset] = true
+		trailer, err := d.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		if d.trailer == nil {
+			d.trailer = trailer
+		}
+		if stm, ok := trailer["XRefStm"].(int); ok && !visited[stm] {
+			// hybrid-reference file...
+			visited[stm] = true
+			if _, err = d.readXrefSection(stm); err != nil {
+				return err
+			}
+		}
+		offset = -1
+		if prev, ok := trailer["Prev"].(int); ok {
+			offset = prev
+		}
+	}
+	if len(d.xref) == 0 {
+		return errors.New("empty xref")
+	}
+	return nil
+}
+
+// readXrefSection reads a cross-reference table or stream (entries already read - from later updates - take precedence)
+func (d *pdfDocument) readXrefSection(offset int) (pdfDict, error) {
+	if offset >= len(d.data) {
+		return nil, errors.New("invalid xref offset")
+	}
+	p := &pdfParser{data: d.data, pos: offset}
+	if p.keyword() != "xref" {
+		// cross-reference stream...
+		p.pos = offset
+		_, obj, err := d.parseIndirectObject(p)
+		if err != nil {
+			return nil, err
+		}
+		stream, ok := obj.(*pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("XRef") {
+			return nil, errors.New("invalid xref stream")
+		}
+		return stream.dict, d.readXrefStream(stream)
+	}
+	for {
+		p.skip()
+		save := p.pos
+		if p.keyword() == "trailer" {
+			obj, err := p.object()
+			if err != nil {
+				return nil, err
+			}
+			trailer, ok := obj.(pdfDict)
+			if !ok {
+				return nil, errors.New("invalid trailer")
+			}
+			return trailer, nil
+		}
+		p.pos = save
+		start, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		count, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			entryOffset, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = p.integer(); err != nil {
+				return nil, err
+			}
+			inUse := p.keyword()
+			if inUse != "n" && inUse != "f" {
+				return nil, errors.New("invalid xref entry")
+			}
+			if _, ok := d.xref[start+i]; !ok && inUse == "n" {
+				d.xref[start+i] = pdfXrefEntry{offset: entryOffset}
+			} else if !ok {
+				// (free entries hide older entries)
+				d.xref[start+i] = pdfXrefEntry{offset: -1}
+			}
+		}
+	}
+}
+
+func (d *pdfDocument) readXrefStream(stream *pdfStream) error {
+	data, err := d.streamData(stream)
+	if err != nil {
+		return err
+	}
+	invalid := errors.New("invalid xref stream")
+	widths, ok := stream.dict["W"].(pdfArray)
+	if !ok || len(widths) != 3 {
+		return invalid
+	}
+	var w [3]int
+	for i := range w {
+		if w[i], ok = widths[i].(int); !ok || w[i] < 0 || w[i] > 8 {
+			return invalid
+		}
+	}
+	index := pdfArray{0, stream.dict["Size"]}
+	if arr, ok := stream.dict["Index"].(pdfArray); ok {
+		index = arr
+	}
+	field := func(b []byte, def int) int {
+		if len(b) == 0 {
+			return def
+		}
+		v := 0
+		for _, c := range b {
+			v = v<<8 | int(c)
+		}
+		return v
+	}
+	size := w[0] + w[1] + w[2]
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, ok1 := index[i].(int)
+		count, ok2 := index[i+1].(int)
+		if !ok1 || !ok2 {
+			return invalid
+		}
+		for j := 0; j < count; j++ {
+			if pos+size > len(data) {
+				return invalid
+			}
+			entry := data[pos : pos+size]
+			pos += size
+			typ := field(entry[:w[0]], 1)
+			f2 := field(entry[w[0]:w[0]+w[1]], 0)
+			f3 := field(entry[w[0]+w[1]:], 0)
+			if _, exists := d.xref[start+j]; exists {
+				continue
+			}
+			switch typ {
+			case 0:
+				d.xref[start+j] = pdfXrefEntry{offset: -1}
+			case 1:
+				d.xref[start+j] = pdfXrefEntry{offset: f2}
+			case 2:
+				d.xref[start+j] = pdfXrefEntry{compressed: true, offset: f2, index: f3}
+			}
+		}
+	}
+	return nil
+}
+
+var pdfObjectPattern = regexp.MustCompile(`(\d+)[\x00\t\n\f\r ]+(\d+)[\x00\t\n\f\r ]+obj\b`)
+
+// scanObjects locates objects by scanning the document (used when the cross-reference information is unusable)
+func (d *pdfDocument) scanObjects() {
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(d.data, -1) {
+		if m[0] > 0 && !isPDFSpace(d.data[m[0]-1]) && !isPDFDelimiter(d.data[m[0]-1]) {
+			continue
+		}
+		if num, err := strconv.Atoi(string(d.data[m[2]:m[3]])); err == nil {
+			// (later objects - incremental updates - take precedence)
+			d.xref[num] = pdfXrefEntry{offset: m[0]}
+		}
+	}
+	if pos := bytes.LastIndex(d.data, []byte("trailer")); pos >= 0 {
+		p := &pdfParser{data: d.data, pos: pos + len("trailer")}
+		if obj, err := p.object(); err == nil {
+			d.trailer, _ = obj.(pdfDict)
+		}
+	}
+	// objects in object streams...
+	for _, num := range d.objectNumbers() {
+		if d.xref[num].compressed {
+			continue
+		}
+		obj, err := d.object(num)
+		if stream, ok := obj.(*pdfStream); err == nil && ok {
+			switch stream.dict["Type"] {
+			case pdfName("ObjStm"):
+				if os, err := d.objectStream(num); err == nil {
+					for i, n := range os.numbers {
+						if _, exists := d.xref[n]; !exists {
+							d.xref[n] = pdfXrefEntry{compressed: true, offset: num, index: i}
+						}
+					}
+				}
+			case pdfName("XRef"):
+				if d.trailer == nil {
+					d.trailer = stream.dict
+				}
+			}
+		}
+	}
+}
+
+// object returns the (parsed) object with the object number
+func (d *pdfDocument) object(num int) (any, error) {
+	if obj, ok := d.objects[num]; ok {
+		return obj, nil
+	}
+	entry, ok := d.xref[num]
+	if !ok || entry.offset < 0 {
+		return nil, fmt.Errorf("object %d not found", num)
+	}
+	if d.loading[num] {
+		return nil, fmt.Errorf("object %d is self-referencing", num)
+	}
+	d.loading[num] = true
+	defer delete(d.loading, num)
+	var obj any
+	if entry.compressed {
+		os, err := d.objectStream(entry.offset)
+		if err != nil {
+			return nil, err
+		}
+		if entry.index >= len(os.numbers) || os.numbers[entry.index] != num {
+			return nil, fmt.Errorf("object %d not found in object stream", num)
+		}
+		p := &pdfParser{data: os.data, pos: os.first + os.offsets[entry.index]}
+		if obj, err = p.object(); err != nil {
+			return nil, err
+		}
+	} else {
+		if entry.offset >= len(d.data) {
+			return nil, fmt.Errorf("object %d offset out of range", num)
+		}
+		found, parsed, err := d.parseIndirectObject(&pdfParser{data: d.data, pos: entry.offset})
+		if err != nil {
+			return nil, err
+		} else if found != num {
+			return nil, fmt.Errorf("object %d not found at offset", num)
+		}
+		obj = parsed
+	}
+	d.objects[num] = obj
+	return obj, nil
+}
+
+func (d *pdfDocument) objectStream(num int) (*pdfObjectStream, error) {
+	if os, ok := d.objectStreams[num]; ok {
+		return os, nil
+	}
+	obj, err := d.object(num)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("object %d is not an object stream", num)
+	}
+	data, err := d.streamData(stream)
+	if err != nil {
+		return nil, err
+	}
+	n, ok1 := stream.dict["N"].(int)
+	first, ok2 := stream.dict["First"].(int)
+	if !ok1 || !ok2 || n < 0 || first < 0 || first > len(data) {
+		return nil, fmt.Errorf("invalid object stream %d", num)
+	}
+	os := &pdfObjectStream{data: data, first: first}
+	p := &pdfParser{data: data[:first]}
+	for i := 0; i < n; i++ {
+		objNum, err := p.integer()
+		if err != nil {
+			return nil, fmt.Errorf("invalid object stream %d", num)
+		}
+		offset, err := p.integer()
+		if err != nil || first+offset > len(data) {
+			return nil, fmt.Errorf("invalid object stream %d", num)
+		}
+		os.numbers = append(os.numbers, objNum)
+		os.offsets = append(os.offsets, offset)
+	}
+	d.objectStreams[num] = os
+	return os, nil
+}
+
+// resolve resolves an indirect reference (nil if the object cannot be found)
+func (d *pdfDocument) resolve(obj any) any {
+	if ref, ok := obj.(pdfRef); ok {
+		obj, _ = d.object(ref.num)
+	}
+	return obj
+}
+
+// parseIndirectObject parses "num gen obj ... endobj" - returning the object number and the object
+func (d *pdfDocument) parseIndirectObject(p *pdfParser) (int, any, error) {
+	num, err := p.integer()
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err = p.integer(); err != nil {
+		return 0, nil, err
+	}
+	if p.keyword() != "obj" {
+		return 0, nil, errors.New("invalid indirect object")
+	}
+	obj, err := p.object()
+	if err != nil {
+		return 0, nil, err
+	}
+	dict, ok := obj.(pdfDict)
+	if !ok {
+		return num, obj, nil
+	}
+	save := p.pos
+	if p.keyword() != "stream" {
+		p.pos = save
+		return num, obj, nil
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+	start := p.pos
+	if length, ok := d.resolve(dict["Length"]).(int); ok && length >= 0 && start+length <= len(p.data) {
+		end := &pdfParser{data: p.data, pos: start + length}
+		if end.keyword() == "endstream" {
+			return num, &pdfStream{dict: dict, data: p.data[start : start+length]}, nil
+		}
+	}
+	// missing or wrong /Length...
+	end := bytes.Index(p.data[start:], []byte("endstream"))
+	if end < 0 {
+		return 0, nil, errors.New("invalid stream (no endstream)")
+	}
+	data := p.data[start : start+end]
+	if bytes.HasSuffix(data, []byte("\r\n")) {
+		data = data[:len(data)-2]
+	} else if bytes.HasSuffix(data, []byte("\n")) || bytes.HasSuffix(data, []byte("\r")) {
+		data = data[:len(data)-1]
+	}
+	return num, &pdfStream{dict: dict, data: data}, nil
+}
+
+// streamData returns the decoded data of a stream
+func (d *pdfDocument) streamData(stream *pdfStream) ([]byte, error) {
+	var filters, params pdfArray
+	switch f := d.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = pdfArray{f}
+	case pdfArray:
+		filters = f
+	}
+	switch dp := d.resolve(stream.dict["DecodeParms"]).(type) {
+	case pdfDict:
+		params = pdfArray{dp}
+	case pdfArray:
+		params = dp
+	}
+	data := stream.data
+	for i, f := range filters {
+		switch d.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid FlateDecode stream: %w", err)
+			}
+			if data, err = io.ReadAll(zr); err != nil {
+				return nil, fmt.Errorf("invalid FlateDecode stream: %w", err)
+			}
+			var dp pdfDict
+			if i < len(params) {
+				dp, _ = d.resolve(params[i]).(pdfDict)
+			}
+			if data, err = pdfUnpredict(data, dp); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported PDF stream filter %v", f)
+		}
+	}
+	return data, nil
+}
+
+// pdfUnpredict reverses the (PNG) predictor of FlateDecode stream data
+func pdfUnpredict(data []byte, params pdfDict) ([]byte, error) {
+	param := func(name pdfName, def int) int {
+		if v, ok := params[name].(int); ok {
+			return v
+		}
+		return def
+	}
+	predictor := param("Predictor", 1)
+	if predictor == 1 {
+		return data, nil
+	} else if predictor < 10 {
+		return nil, fmt.Errorf("unsupported PDF predictor %d", predictor)
+	}
+	// (the parameters are untrusted - check them against the data before multiplying or allocating anything)
+	colors, bitsPerComponent, columns := param("Colors", 1), param("BitsPerComponent", 8), param("Columns", 1)
+	maxBits := 8 * len(data)
+	if colors <= 0 || bitsPerComponent <= 0 || columns <= 0 ||
+		bitsPerComponent > maxBits/colors || columns > maxBits/(colors*bitsPerComponent) {
+		return nil, errors.New("invalid PDF predictor parameters")
+	}
+	bitsPerPixel := colors * bitsPerComponent
+	rowLen := (bitsPerPixel*columns + 7) / 8
+	if rowLen+1 > len(data) {
+		return nil, errors.New("invalid PDF predictor data (incomplete row)")
+	}
+	bpp := max((bitsPerPixel+7)/8, 1)
+	result := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for pos := 0; pos < len(data); pos += rowLen + 1 {
+		if pos+rowLen+1 > len(data) {
+			return nil, errors.New("invalid PDF predictor data (incomplete row)")
+		}
+		row := bytes.Clone(data[pos+1 : pos+rowLen+1])
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			switch data[pos] {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += prev[i]
+			case 3:
+				row[i] += byte((int(left) + int(prev[i])) / 2)
+			case 4:
+				row[i] += pdfPaeth(left, prev[i], upLeft)
+			default:
+				return nil, fmt.Errorf("invalid PDF predictor row filter %d", data[pos])
+			}
+		}
+		result = append(result, row...)
+		prev = row
+	}
+	return result, nil
+}
+
+func pdfPaeth(a, b, c byte) byte {
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// pdfMaxDepth is the maximum nesting depth of PDF arrays & dictionaries
+const pdfMaxDepth = 512
+
+// pdfParser parses PDF objects (from a position in the data)
+type pdfParser struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// nest enters a nested array or dictionary - the returned func leaves it
+func (p *pdfParser) nest() (func(), error) {
+	if p.depth >= pdfMaxDepth {
+		return nil, fmt.Errorf("PDF objects nested too deeply (more than %d)", pdfMaxDepth)
+	}
+	p.depth++
+	return func() { p.depth-- }, nil
+}
+
+// skip skips whitespace & comments
+func (p *pdfParser) skip() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\r' && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case isPDFSpace(c):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// keyword reads a run of regular characters (e.g. obj, true, R)
+func (p *pdfParser) keyword() string {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *pdfParser) integer() (int, error) {
+	kw := p.keyword()
+	v, err := strconv.Atoi(kw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PDF integer %q", kw)
+	}
+	return v, nil
+}
+
+// object parses a direct object (or an indirect reference)
+func (p *pdfParser) object() (any, error) {
+	p.skip()
+	if p.pos >= len(p.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	switch c := p.data[p.pos]; {
+	case c == '/':
+		return p.name(), nil
+	case c == '(':
+		return p.literalString()
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		return p.dict()
+	case c == '<':
+		return p.hexString()
+	case c == '[':
+		leave, err := p.nest()
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		p.pos++
+		result := pdfArray{}
+		for {
+			p.skip()
+			if p.pos < len(p.data) && p.data[p.pos] == ']' {
+				p.pos++
+				return result, nil
+			}
+			item, err := p.object()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+	case isPDFDelimiter(c):
+		return nil, fmt.Errorf("unexpected PDF delimiter %q", c)
+	}
+	kw := p.keyword()
+	switch kw {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if v, err := strconv.Atoi(kw); err == nil {
+		// possibly an indirect reference (num gen R)...
+		save := p.pos
+		if gen, err := strconv.Atoi(p.keyword()); err == nil && p.keyword() == "R" {
+			return pdfRef{num: v, gen: gen}, nil
+		}
+		p.pos = save
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(kw, 64); err == nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unexpected PDF token %q", kw)
+}
+
+func (p *pdfParser) name() pdfName {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if v, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				sb.WriteByte(byte(v))
+				p.pos += 3
+				continue
+			}
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return pdfName(sb.String())
+}
+
+func (p *pdfParser) dict() (any, error) {
+	leave, err := p.nest()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	p.pos += 2
+	result := pdfDict{}
+	for {
+		p.skip()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return result, nil
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, errors.New("invalid PDF dictionary key")
+		}
+		key := p.name()
+		value, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+}
+
+func (p *pdfParser) literalString() ([]byte, error) {
+	p.pos++
+	var result []byte
+	for depth := 1; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				p.pos++
+				return result, nil
+			}
+		case '\\':
+			if p.pos++; p.pos >= len(p.data) {
+				break
+			}
+			switch c = p.data[p.pos]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// line continuation
+				if c == '\r' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '\n' {
+					p.pos++
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := 0
+				for i := 0; i < 3 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+					v = v*8 + int(p.data[p.pos]-'0')
+					p.pos++
+				}
+				p.pos--
+				c = byte(v)
+			}
+		}
+		result = append(result, c)
+	}
+	return nil, errors.New("unterminated PDF string")
+}
+
+func (p *pdfParser) hexString() ([]byte, error) {
+	p.pos++
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return nil, errors.New("unterminated PDF hex string")
+	}
+	hex := bytes.Map(func(r rune) rune {
+		if r < 0x80 && isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, p.data[p.pos:p.pos+end])
+	p.pos += end + 1
+	if len(hex)%2 != 0 {
+		hex = append(hex, '0')
+	}
+	result := make([]byte, len(hex)/2)
+	for i := range result {
+		v, err := strconv.ParseUint(string(hex[i*2:i*2+2]), 16, 8)
+		if err != nil {
+			return nil, errors.New("invalid PDF hex string")
+		}
+		result[i] = byte(v)
+	}
+	return result, nil
+}
@@ -0,0 +1,321 @@
+package iccarus
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const testPDFOutputProfile = "default/ISOcoated_v2_300_eci.icc"
+
+func testFlate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// testPDFStream builds a stream object - FlateDecode compressed if flate
+func testPDFStream(dict string, data []byte, flate bool) string {
+	if flate {
+		data = testFlate(data)
+		dict += " /Filter /FlateDecode"
+	}
+	return fmt.Sprintf("<< /Length %d %s >>\nstream\n%s\nendstream", len(data), dict, data)
+}
+
+// testPDF builds a PDF document from objects (numbered from 1) with a cross-reference table
+func testPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		_, _ = fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	_, _ = fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		_, _ = fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+	_, _ = fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// testPDFUpdate appends an incremental update (replacing objects by number) to a document built by testPDF
+func testPDFUpdate(pdf []byte, objects map[int]string) []byte {
+	prev := bytes.LastIndex(pdf, []byte("startxref\n")) + len("startxref\n")
+	var prevOffset int
+	_, _ = fmt.Sscanf(string(pdf[prev:]), "%d", &prevOffset)
+	buf := bytes.NewBuffer(bytes.Clone(pdf))
+	offsets := make(map[int]int)
+	for num, obj := range objects {
+		offsets[num] = buf.Len()
+		_, _ = fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", num, obj)
+	}
+	xref := buf.Len()
+	buf.WriteString("xref\n")
+	for num, offset := range offsets {
+		_, _ = fmt.Fprintf(buf, "%d 1\n%010d 00000 n\r\n", num, offset)
+	}
+	_, _ = fmt.Fprintf(buf, "trailer\n<< /Size 100 /Root 1 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", prevOffset, xref)
+	return buf.Bytes()
+}
+
+// testPDFCompressed builds a PDF document from objects (numbered from 1) with a cross-reference stream (using the PNG
+// Up predictor) - non-stream objects are stored in an object stream
+func testPDFCompressed(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	objStmNum, xrefNum := len(objects)+1, len(objects)+2
+	type entry struct {
+		typ    byte
+		f2, f3 int
+	}
+	entries := make([]entry, xrefNum+1)
+	var header, body strings.Builder
+	index := 0
+	for i, obj := range objects {
+		if strings.Contains(obj, "stream") {
+			entries[i+1] = entry{1, buf.Len(), 0}
+			_, _ = fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+		} else {
+			entries[i+1] = entry{2, objStmNum, index}
+			index++
+			_, _ = fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+			body.WriteString(obj + "\n")
+		}
+	}
+	entries[objStmNum] = entry{1, buf.Len(), 0}
+	_, _ = fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", objStmNum,
+		testPDFStream(fmt.Sprintf("/Type /ObjStm /N %d /First %d", index, header.Len()), []byte(header.String()+body.String()), true))
+	entries[xrefNum] = entry{1, buf.Len(), 0}
+	var data []byte
+	prev := make([]byte, 7)
+	for _, e := range entries {
+		row := []byte{e.typ}
+		row = binary.BigEndian.AppendUint32(row, uint32(e.f2))
+		row = binary.BigEndian.AppendUint16(row, uint16(e.f3))
+		data = append(data, 2) // Up
+		for i := range row {
+			data = append(data, row[i]-prev[i])
+		}
+		prev = row
+	}
+	xref := buf.Len()
+	_, _ = fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", xrefNum,
+		testPDFStream(fmt.Sprintf("/Type /XRef /Size %d /Root 1 0 R /W [1 4 2] /DecodeParms << /Predictor 12 /Columns 7 >>", len(entries)), data, true))
+	_, _ = fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+func testPDFObjects(t *testing.T, profileRef string, outputRef string) []string {
+	return []string{
+		"<< /Type /Catalog /Pages 2 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFX /OutputConditionIdentifier (FOGRA39 \\(coated\\)) /DestOutputProfile " + outputRef + " >>] >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /ColorSpace << /CS0 [/ICCBased " + profileRef + "] /CS1 /DeviceRGB >> >> >>",
+		testPDFStream("/N 3 /Alternate /DeviceRGB", testProfileBytes(t, testEmbedProfile), true),
+		testPDFStream("/N 4", testProfileBytes(t, testPDFOutputProfile), false),
+	}
+}
+
+func TestExtractFromPDF(t *testing.T) {
+	expectRGB := testProfile(t, testEmbedProfile)
+	expectCMYK := testProfile(t, testPDFOutputProfile)
+	assertProfiles := func(t *testing.T, profiles []*PDFProfile) {
+		require.Len(t, profiles, 2)
+		assert.Equal(t, 4, profiles[0].ObjectNumber)
+		assert.Equal(t, PDFUsageICCBased, profiles[0].Usage)
+		assert.Equal(t, expectRGB.Header, profiles[0].Profile.Header)
+		assert.Equal(t, 5, profiles[1].ObjectNumber)
+		assert.Equal(t, PDFUsageOutputIntent, profiles[1].Usage)
+		assert.Equal(t, expectCMYK.Header, profiles[1].Profile.Header)
+	}
+	t.Run("Xref Table", func(t *testing.T) {
+		profiles, err := ExtractFromPDF(bytes.NewReader(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...)), nil)
+		require.NoError(t, err)
+		assertProfiles(t, profiles)
+	})
+	t.Run("Xref Stream & Object Stream", func(t *testing.T) {
+		profiles, err := ExtractFromPDF(bytes.NewReader(testPDFCompressed(testPDFObjects(t, "4 0 R", "5 0 R")...)), nil)
+		require.NoError(t, err)
+		assertProfiles(t, profiles)
+	})
+	t.Run("Broken Xref", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"Table":      bytes.Replace(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...), []byte("startxref\n"), []byte("startxref\n1"), 1),
+			"Stream":     bytes.Replace(testPDFCompressed(testPDFObjects(t, "4 0 R", "5 0 R")...), []byte("startxref\n"), []byte("startxref\n1"), 1),
+			"No Xref":    bytes.Replace(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...), []byte("startxref"), []byte("xxxxxxxxx"), 1),
+			"Bad Offset": bytes.Replace(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...), []byte("0 65535 f\r\n0"), []byte("0 65535 f\r\n1"), 1),
+		} {
+			t.Run(name, func(t *testing.T) {
+				profiles, err := ExtractFromPDF(bytes.NewReader(data), nil)
+				require.NoError(t, err)
+				require.NotEmpty(t, profiles)
+				assert.Equal(t, 5, profiles[len(profiles)-1].ObjectNumber)
+			})
+		}
+	})
+	t.Run("Shared Profile", func(t *testing.T) {
+		profiles, err := ExtractFromPDF(bytes.NewReader(testPDF(testPDFObjects(t, "4 0 R", "4 0 R")...)), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		require.Len(t, profiles, 1)
+		assert.Equal(t, PDFUsageICCBased|PDFUsageOutputIntent, profiles[0].Usage)
+		assert.Equal(t, "ICCBased|OutputIntent", profiles[0].Usage.String())
+		assert.Empty(t, profiles[0].Profile.TagBlocks)
+	})
+	t.Run("Different Generations", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			profiles, err := ExtractFromPDF(bytes.NewReader(testPDF(testPDFObjects(t, "4 0 R", "4 1 R")...)), &ParseOptions{Mode: ParseHeaderOnly})
+			require.NoError(t, err)
+			require.Len(t, profiles, 1)
+			assert.Equal(t, 4, profiles[0].ObjectNumber)
+			assert.Equal(t, 1, profiles[0].Generation)
+			assert.Equal(t, PDFUsageICCBased|PDFUsageOutputIntent, profiles[0].Usage)
+		}
+	})
+	t.Run("Incremental Update", func(t *testing.T) {
+		pdf := testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...)
+		pdf = testPDFUpdate(pdf, map[int]string{
+			1: "<< /Type /Catalog /Pages 2 0 R >>",
+			3: "<< /Type /Page /Parent 2 0 R /Resources << /ColorSpace << /CS0 [/ICCBased 6 0 R] >> >> >>",
+			6: testPDFStream("/N 4 /Length 7 0 R", testProfileBytes(t, testPDFOutputProfile), true),
+			7: "<< >>",
+		})
+		// (object 6 has an indirect /Length to a non-integer object - so is found by endstream)
+		profiles, err := ExtractFromPDF(bytes.NewReader(pdf), nil)
+		require.NoError(t, err)
+		require.Len(t, profiles, 1)
+		assert.Equal(t, 6, profiles[0].ObjectNumber)
+		assert.Equal(t, PDFUsageICCBased, profiles[0].Usage)
+		assert.Equal(t, expectCMYK.Header, profiles[0].Profile.Header)
+	})
+	t.Run("Indirect Length", func(t *testing.T) {
+		objects := testPDFObjects(t, "6 0 R", "5 0 R")
+		data := testFlate(testProfileBytes(t, testEmbedProfile))
+		objects = append(objects, fmt.Sprintf("<< /Length 7 0 R /Filter [/FlateDecode] /DecodeParms [null] >>\nstream\r\n%s\r\nendstream", data), fmt.Sprint(len(data)))
+		profiles, err := ExtractFromPDF(bytes.NewReader(testPDF(objects...)), nil)
+		require.NoError(t, err)
+		require.Len(t, profiles, 2)
+		assert.Equal(t, 6, profiles[1].ObjectNumber)
+		assert.Equal(t, expectRGB.Header, profiles[1].Profile.Header)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		_, err := ExtractFromPDF(bytes.NewReader(testPDF("<< /Type /Catalog >>", "[/Indexed /DeviceRGB 1 <000000FFFFFF>]")), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+	})
+	t.Run("Deeply Nested", func(t *testing.T) {
+		// (must not overflow the stack - the unparseable object is ignored)
+		_, err := ExtractFromPDF(bytes.NewReader(testPDF("<< /Type /Catalog >>", strings.Repeat("[", 20_000_000))), nil)
+		assert.ErrorIs(t, err, ErrNoICCProfile)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"Empty":              {},
+			"Not PDF":            []byte("%!PS-Adobe-3.0\n"),
+			"Encrypted":          bytes.Replace(testPDF(testPDFObjects(t, "4 0 R", "5 0 R")...), []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 9 0 R"), 1),
+			"Missing Profile":    testPDF(testPDFObjects(t, "9 0 R", "5 0 R")...),
+			"Profile Not Stream": testPDF(testPDFObjects(t, "2 0 R", "5 0 R")...),
+			"Unsupported Filter": testPDF("<< /CS [/ICCBased 2 0 R] >>", "<< /Length 4 /Filter /ASCIIHexDecode >>\nstream\n00FF\nendstream"),
+			"Invalid Flate":      testPDF("<< /CS [/ICCBased 2 0 R] >>", "<< /Length 4 /Filter /FlateDecode >>\nstream\n00FF\nendstream"),
+			"Invalid Profile":    testPDF("<< /CS [/ICCBased 2 0 R] >>", testPDFStream("", []byte("not a profile"), false)),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromPDF(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
+
+func TestPDFParser(t *testing.T) {
+	p := &pdfParser{data: []byte(`<< /Name#20X (a\(b\)\n\101\
+c(d)) /Hex <48 6 9> /Arr [1 -2.5 true false null 3 0 R /N] % comment
+/Ref 12 5 R >>`)}
+	obj, err := p.object()
+	require.NoError(t, err)
+	assert.Equal(t, pdfDict{
+		"Name X": []byte("a(b)\nAc(d)"),
+		"Hex":    []byte("Hi"),
+		"Arr":    pdfArray{1, -2.5, true, false, nil, pdfRef{num: 3}, pdfName("N")},
+		"Ref":    pdfRef{num: 12, gen: 5},
+	}, obj)
+	for _, s := range []string{"", "(abc", "<4G>", "<abc", "<< 1 2 >>", ")", "[1 2", "bogus"} {
+		_, err = (&pdfParser{data: []byte(s)}).object()
+		assert.Error(t, err, s)
+	}
+	t.Run("Nesting Depth", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			return []byte(strings.Repeat("[<< /A ", depth/2) + "1" + strings.Repeat(">>]", depth/2))
+		}
+		_, err := (&pdfParser{data: nested(pdfMaxDepth)}).object()
+		assert.NoError(t, err)
+		_, err = (&pdfParser{data: nested(pdfMaxDepth + 2)}).object()
+		assert.ErrorContains(t, err, "PDF objects nested too deeply")
+	})
+	t.Run("Collect Refs Depth", func(t *testing.T) {
+		nested := func(depth int) any {
+			var obj any = pdfArray{pdfName("ICCBased"), pdfRef{num: 4}}
+			for i := 0; i < depth; i++ {
+				obj = pdfArray{obj}
+			}
+			return obj
+		}
+		refs := make(map[int]*pdfProfileRef)
+		collectPDFProfileRefs(nested(pdfMaxDepth), refs, 0)
+		assert.Len(t, refs, 1)
+		refs = make(map[int]*pdfProfileRef)
+		collectPDFProfileRefs(nested(pdfMaxDepth+1), refs, 0)
+		assert.Empty(t, refs)
+	})
+}
+
+func TestPDFUnpredict(t *testing.T) {
+	params := pdfDict{"Predictor": 15, "Colors": 2, "Columns": 2}
+	// rows of 4 bytes (2 pixels x 2 colors)...
+	data := []byte{
+		0, 1, 2, 3, 4, // None
+		1, 1, 1, 1, 1, // Sub: 1, 1, 2, 2
+		2, 1, 1, 1, 1, // Up: 2, 2, 3, 3
+		3, 2, 2, 2, 2, // Average: 2+(0+2)/2=3, 3, 2+(3+3)/2=5, 5
+		4, 1, 1, 1, 1, // Paeth: 1+paeth(0,3,0)=4, 4, 1+paeth(4,5,3)=6, 6
+	}
+	result, err := pdfUnpredict(data, params)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 5, 5, 4, 4, 6, 6}, result)
+	result, err = pdfUnpredict(data, nil)
+	require.NoError(t, err)
+	assert.Equal(t, data, result)
+	_, err = pdfUnpredict(data, pdfDict{"Predictor": 2})
+	assert.Error(t, err)
+	_, err = pdfUnpredict(data[:7], params)
+	assert.Error(t, err)
+	_, err = pdfUnpredict([]byte{5, 0, 0, 0, 0}, params)
+	assert.Error(t, err)
+	for name, params := range map[string]pdfDict{
+		"Huge Columns":     {"Predictor": 12, "Columns": 100000000000000},
+		"Overflow":         {"Predictor": 12, "Colors": 1 << 40, "BitsPerComponent": 1 << 40, "Columns": 1 << 40},
+		"Row Exceeds Data": {"Predictor": 12, "Columns": 20},
+		"Negative Colors":  {"Predictor": 12, "Colors": -1},
+		"Zero Columns":     {"Predictor": 12, "Columns": 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := pdfUnpredict(data, params)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPDFProfileUsage_String(t *testing.T) {
+	assert.Equal(t, "ICCBased", PDFUsageICCBased.String())
+	assert.Equal(t, "OutputIntent", PDFUsageOutputIntent.String())
+	assert.Equal(t, "Unknown (0)", PDFProfileUsage(0).String())
+	assert.Equal(t, "ICCBased|Unknown (4)", PDFProfileUsage(5).String())
+}
@@ -0,0 +1,100 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+const (
+	psdSignature        = "8BPS"
+	psdResourceICC      = 1039
+	psdVersionPSD       = 1
+	psdVersionPSB       = 2
+	psdFileHeaderLength = 26
+)
+
+// psdResourceSignatures are the signatures of image resource blocks ("8BIM" is the norm, the others are found in
+// files written by older/other applications)
+var psdResourceSignatures = []string{"8BIM", "MeSa", "AgHg", "PHUT", "DCSR"}
+
+// ExtractFromPSD extracts ICC profile from a Photoshop .psd (or large document format .psb) file
+//
+// the profile is taken from image resource 1039 (ICC Profile) in the image resources section
+func ExtractFromPSD(r io.Reader, options *ParseOptions) (*Profile, error) {
+	header := make([]byte, psdFileHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read PSD header: %w", err)
+	}
+	if string(header[:4]) != psdSignature {
+		return nil, errors.New("not a valid PSD file (missing 8BPS signature)")
+	}
+	if version := binary.BigEndian.Uint16(header[4:6]); version != psdVersionPSD && version != psdVersionPSB {
+		return nil, fmt.Errorf("unsupported PSD version %d", version)
+	}
+	// skip color mode data section...
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read PSD color mode data length: %w", err)
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+		return nil, fmt.Errorf("failed to skip PSD color mode data: %w", err)
+	}
+	// image resources section (the length is 4 bytes in both PSD & PSB)...
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read PSD image resources length: %w", err)
+	}
+	resources := &io.LimitedReader{R: r, N: int64(length)}
+	for {
+		var blockHeader [7]byte
+		if _, err := io.ReadFull(resources, blockHeader[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read PSD image resource header: %w", err)
+		}
+		if !slices.Contains(psdResourceSignatures, string(blockHeader[:4])) {
+			return nil, fmt.Errorf("invalid PSD image resource signature %q", blockHeader[:4])
+		}
+		id := binary.BigEndian.Uint16(blockHeader[4:6])
+		// pascal string name (padded, including the length byte, to even length)...
+		nameLen := int64(blockHeader[6])
+		if nameLen%2 == 0 {
+			nameLen++
+		}
+		if _, err := io.CopyN(io.Discard, resources, nameLen); err != nil {
+			return nil, fmt.Errorf("failed to read PSD image resource name: %w", err)
+		}
+		var size uint32
+		if err := binary.Read(resources, binary.BigEndian, &size); err != nil {
+			return nil, fmt.Errorf("failed to read PSD image resource size: %w", err)
+		} else if int64(size) > resources.N {
+			return nil, fmt.Errorf("invalid PSD image resource %d size %d", id, size)
+		}
+		if id == psdResourceICC {
+			// (read through a limit reader rather than allocating the untrusted resource size up front)
+			iccData, err := io.ReadAll(io.LimitReader(resources, int64(size)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read PSD ICC profile resource: %w", err)
+			} else if int64(len(iccData)) < int64(size) {
+				return nil, fmt.Errorf("failed to read PSD ICC profile resource: %w", io.ErrUnexpectedEOF)
+			}
+			return ParseProfile(bytes.NewReader(iccData), options)
+		}
+		if _, err := io.CopyN(io.Discard, resources, int64(size)); err != nil {
+			return nil, fmt.Errorf("failed to skip PSD image resource %d: %w", id, err)
+		}
+		if size%2 == 1 {
+			// (tolerate missing padding on the last resource)
+			if _, err := io.CopyN(io.Discard, resources, 1); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to skip PSD image resource %d: %w", id, err)
+			}
+		}
+	}
+	return nil, ErrNoICCProfile
+}
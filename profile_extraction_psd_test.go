@@ -0,0 +1,149 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+type testPSDResource struct {
+	sig  string
+	id   uint16
+	name string
+	data []byte
+}
+
+// testPSD builds a minimal PSD (version 1) or PSB (version 2) file with the image resources
+func testPSD(version uint16, resources ...testPSDResource) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(psdSignature)
+	_ = binary.Write(&buf, binary.BigEndian, version)
+	buf.Write(make([]byte, 6))                          // reserved
+	_ = binary.Write(&buf, binary.BigEndian, uint16(3)) // channels
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1)) // height
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1)) // width
+	_ = binary.Write(&buf, binary.BigEndian, uint16(8)) // depth
+	_ = binary.Write(&buf, binary.BigEndian, uint16(3)) // mode (RGB)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(2)) // color mode data...
+	buf.Write([]byte{0, 0})
+	var res bytes.Buffer
+	for _, r := range resources {
+		res.WriteString(r.sig)
+		_ = binary.Write(&res, binary.BigEndian, r.id)
+		res.WriteByte(byte(len(r.name)))
+		res.WriteString(r.name)
+		if len(r.name)%2 == 0 {
+			res.WriteByte(0)
+		}
+		_ = binary.Write(&res, binary.BigEndian, uint32(len(r.data)))
+		res.Write(r.data)
+		if len(r.data)%2 == 1 {
+			res.WriteByte(0)
+		}
+	}
+	_ = binary.Write(&buf, binary.BigEndian, uint32(res.Len()))
+	buf.Write(res.Bytes())
+	// layer & mask info (empty) and image data...
+	if version == psdVersionPSB {
+		buf.Write(make([]byte, 8))
+	} else {
+		buf.Write(make([]byte, 4))
+	}
+	buf.Write(make([]byte, 5))
+	return buf.Bytes()
+}
+
+func TestExtractFromPSD(t *testing.T) {
+	iccData := testProfileBytes(t, testEmbedProfile)
+	expect := testProfile(t, testEmbedProfile)
+	others := []testPSDResource{
+		{"8BIM", 1005, "", []byte{0, 0x48, 0, 0, 0, 1, 0, 1, 0, 0x48, 0, 0, 0, 1, 0, 1}},
+		{"8BIM", 1028, "IPTC", []byte{1, 2, 3}},
+		{"MeSa", 4000, "odd", []byte{1}},
+	}
+	t.Run("PSD", func(t *testing.T) {
+		p, err := ExtractFromPSD(bytes.NewReader(testPSD(psdVersionPSD, append(others, testPSDResource{"8BIM", psdResourceICC, "", iccData})...)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+		assert.Equal(t, expect.TagHeaderTable, p.TagHeaderTable)
+	})
+	t.Run("PSB", func(t *testing.T) {
+		p, err := ExtractFromPSD(bytes.NewReader(testPSD(psdVersionPSB, testPSDResource{"8BIM", psdResourceICC, "ICC Profile", iccData})), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("Options", func(t *testing.T) {
+		p, err := ExtractFromPSD(bytes.NewReader(testPSD(psdVersionPSD, testPSDResource{"8BIM", psdResourceICC, "", iccData})), &ParseOptions{Mode: ParseHeaderOnly})
+		require.NoError(t, err)
+		assert.Empty(t, p.TagBlocks)
+	})
+	t.Run("ExtractFromImage", func(t *testing.T) {
+		p, err := ExtractFromImage(bytes.NewReader(testPSD(psdVersionPSB, testPSDResource{"8BIM", psdResourceICC, "", iccData})), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expect.Header, p.Header)
+	})
+	t.Run("No ICC Profile", func(t *testing.T) {
+		// last resource with odd length data but no padding...
+		missingPad := testPSD(psdVersionPSD, testPSDResource{"8BIM", 1028, "", []byte{1}})
+		missingPad = append(missingPad[:psdFileHeaderLength+23], missingPad[psdFileHeaderLength+24:]...)
+		binary.BigEndian.PutUint32(missingPad[psdFileHeaderLength+6:], 13)
+		for name, data := range map[string][]byte{
+			"No Resources": testPSD(psdVersionPSD),
+			"Other":        testPSD(psdVersionPSD, others...),
+			"Missing Pad":  missingPad,
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromPSD(bytes.NewReader(data), nil)
+				assert.ErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+	t.Run("Resource Size Exceeds Section", func(t *testing.T) {
+		data := testPSD(psdVersionPSD, testPSDResource{"8BIM", psdResourceICC, "", []byte{1, 2}})
+		binary.BigEndian.PutUint32(data[psdFileHeaderLength+18:], 0xffffffff)
+		_, err := ExtractFromPSD(bytes.NewReader(data), nil)
+		assert.ErrorContains(t, err, "invalid PSD image resource 1039 size 4294967295")
+	})
+	t.Run("Resource Size Exceeds Data", func(t *testing.T) {
+		// (the declared resources section length is trusted no more than the resource size)
+		data := testPSD(psdVersionPSD, testPSDResource{"8BIM", psdResourceICC, "", []byte{1, 2}})
+		binary.BigEndian.PutUint32(data[psdFileHeaderLength+6:], 0xfffffff0)
+		binary.BigEndian.PutUint32(data[psdFileHeaderLength+18:], 0xffffffe0)
+		_, err := ExtractFromPSD(bytes.NewReader(data), nil)
+		assert.ErrorContains(t, err, "failed to read PSD ICC profile resource")
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		valid := testPSD(psdVersionPSD, testPSDResource{"8BIM", psdResourceICC, "", iccData})
+		bad := func(pos int, b ...byte) []byte {
+			data := bytes.Clone(valid)
+			copy(data[pos:], b)
+			return data
+		}
+		for name, data := range map[string][]byte{
+			"Empty":            {},
+			"Short Header":     valid[:10],
+			"Not PSD":          bad(0, '8', 'B', 'P', 'X'),
+			"Bad Version":      bad(4, 0, 3),
+			"No Color Mode":    valid[:psdFileHeaderLength],
+			"Short Color Mode": bad(psdFileHeaderLength, 0xff, 0xff, 0xff, 0xff),
+			"No Resources":     valid[:psdFileHeaderLength+6],
+			"Bad Resource Sig": bad(psdFileHeaderLength+10, 'X'),
+			"Short Resource":   valid[:psdFileHeaderLength+14],
+			"Short Name":       bad(psdFileHeaderLength+16, 0xff),
+			"Short ICC":        bad(psdFileHeaderLength+18, 0xff, 0xff),
+			"Short Size":       bad(psdFileHeaderLength+6, 0, 0, 0, 10),
+			"Invalid Profile":  testPSD(psdVersionPSD, testPSDResource{"8BIM", psdResourceICC, "", iccData[:100]}),
+			"Short Other":      testPSD(psdVersionPSD, testPSDResource{"8BIM", 1028, "", []byte{1, 2, 3}})[:psdFileHeaderLength+20],
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ExtractFromPSD(bytes.NewReader(data), nil)
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoICCProfile)
+			})
+		}
+	})
+}
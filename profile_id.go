@@ -0,0 +1,55 @@
+package iccarus
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ComputeProfileID computes the profile ID (MD5 checksum) of the supplied ICC profile bytes
+//
+// as defined by the ICC spec, the checksum is computed over the entire profile (as determined by the header
+// profile size) with the profile flags, rendering intent and profile ID header fields set to zero
+func ComputeProfileID(data []byte) ([16]byte, error) {
+	if len(data) < 128 {
+		return [16]byte{}, errors.New("profile data too short")
+	}
+	if size := int(binary.BigEndian.Uint32(data[0:4])); size >= 128 && size < len(data) {
+		data = data[:size]
+	}
+	h := md5.New()
+	h.Write(data[:44])
+	h.Write(make([]byte, 4)) // flags
+	h.Write(data[48:64])
+	h.Write(make([]byte, 4)) // rendering intent
+	h.Write(data[68:84])
+	h.Write(make([]byte, 16)) // profile ID
+	h.Write(data[100:])
+	var result [16]byte
+	copy(result[:], h.Sum(nil))
+	return result, nil
+}
+
+// verifyProfileID verifies the profile ID in the header of the supplied ICC profile bytes
+//
+// a zero profile ID (i.e. not computed) is not verified
+func verifyProfileID(data []byte, id [16]byte) error {
+	if id == [16]byte{} {
+		return nil
+	}
+	computed, err := ComputeProfileID(data)
+	if err == nil && computed != id {
+		err = fmt.Errorf("profile ID mismatch (header %x, computed %x)", id, computed)
+	}
+	return err
+}
+
+// setProfileID computes and sets the profile ID in the supplied ICC profile bytes
+func setProfileID(data []byte) error {
+	id, err := ComputeProfileID(data)
+	if err == nil {
+		copy(data[84:100], id[:])
+	}
+	return err
+}
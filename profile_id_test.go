@@ -0,0 +1,76 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/iotest"
+)
+
+func TestComputeProfileID(t *testing.T) {
+	testCases := map[string]string{
+		"default/ISOcoated_v2_300_eci.icc":       "35db7968bf0904e9317ca9780d871bc3",
+		"default/display-p3-v4-with-v2-desc.icc": "ca1a9582257f104d389913d5d1ea1582",
+	}
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data := testProfileBytes(t, name)
+			id, err := ComputeProfileID(data)
+			require.NoError(t, err)
+			assert.Equal(t, expected, hex.EncodeToString(id[:]))
+			// flags, rendering intent and profile ID are excluded...
+			data[47] = 0xFF
+			data[67] = 0x03
+			data[90] = 0xAA
+			id2, err := ComputeProfileID(data)
+			require.NoError(t, err)
+			assert.Equal(t, id, id2)
+			// trailing data (beyond the header profile size) is ignored...
+			id3, err := ComputeProfileID(append(data, 1, 2, 3, 4))
+			require.NoError(t, err)
+			assert.Equal(t, id, id3)
+			// any other change is detected...
+			data[200]++
+			id4, err := ComputeProfileID(data)
+			require.NoError(t, err)
+			assert.NotEqual(t, id, id4)
+		})
+	}
+	t.Run("Too Short", func(t *testing.T) {
+		_, err := ComputeProfileID(make([]byte, 127))
+		assert.ErrorContains(t, err, "profile data too short")
+	})
+}
+
+func TestParseProfile_VerifyProfileID(t *testing.T) {
+	name := "default/display-p3-v4-with-v2-desc.icc"
+	t.Run("Valid", func(t *testing.T) {
+		for _, mode := range []ParseMode{ParseFull, ParseHeaderAndTagHeaderTable, ParseHeaderOnly} {
+			p, err := ParseProfile(bytes.NewReader(testProfileBytes(t, name)), &ParseOptions{Mode: mode, VerifyProfileID: true})
+			require.NoError(t, err)
+			assert.Equal(t, uint32(548), p.Header.ProfileSize)
+		}
+	})
+	t.Run("Tampered", func(t *testing.T) {
+		data := testProfileBytes(t, name)
+		data[len(data)-1]++
+		_, err := ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true})
+		assert.ErrorContains(t, err, "profile ID mismatch (header ca1a9582257f104d389913d5d1ea1582, computed ")
+		_, err = ParseProfile(bytes.NewReader(data), nil)
+		assert.NoError(t, err)
+	})
+	t.Run("Zero Profile ID", func(t *testing.T) {
+		data := testProfileBytes(t, name)
+		copy(data[84:100], make([]byte, 16))
+		data[len(data)-1]++
+		_, err := ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true})
+		assert.NoError(t, err)
+	})
+	t.Run("Read Error", func(t *testing.T) {
+		_, err := ParseProfile(iotest.ErrReader(errors.New("read failure")), &ParseOptions{VerifyProfileID: true})
+		assert.ErrorContains(t, err, "read failure")
+	})
+}
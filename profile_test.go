@@ -62,3 +62,79 @@ func TestParseProfile(t *testing.T) {
 		})
 	}
 }
+
+func testProfile(t *testing.T, name string) *Profile {
+	f, err := profiles.Open(name)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	p, err := ParseProfile(f, nil)
+	require.NoError(t, err)
+	return p
+}
+
+func TestProfile_SetTag(t *testing.T) {
+	t.Run("Add", func(t *testing.T) {
+		p := &Profile{}
+		p.SetTag(TagHeaderCopyright, TagText, "foo")
+		tag, ok := p.TagByHeader(TagHeaderCopyright)
+		require.True(t, ok)
+		assert.Equal(t, TagText, tag.Name)
+		assert.Nil(t, tag.Raw)
+		val, err := p.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", val)
+		tags, ok := p.TagsByName(TagText)
+		require.True(t, ok)
+		assert.Len(t, tags, 1)
+	})
+	t.Run("Replace Shared", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		blocks := len(p.TagBlocks)
+		shared, ok := p.TagByHeader(TagHeaderGreenTRC)
+		require.True(t, ok)
+		require.Len(t, shared.Headers, 3)
+		p.SetTag(TagHeaderGreenTRC, TagCurve, &CurveTag{Type: CurveTypeIdentity})
+		assert.Len(t, p.TagBlocks, blocks)
+		assert.Len(t, shared.Headers, 2)
+		green, ok := p.TagByHeader(TagHeaderGreenTRC)
+		require.True(t, ok)
+		assert.NotSame(t, shared, green)
+		red, ok := p.TagByHeader(TagHeaderRedTRC)
+		require.True(t, ok)
+		assert.Same(t, shared, red)
+	})
+	t.Run("Replace Shared Listed Once", func(t *testing.T) {
+		shared := &Tag{Headers: []TagHeader{{Name: TagHeaderRedTRC}, {Name: TagHeaderGreenTRC}}, Name: TagCurve, value: &CurveTag{Type: CurveTypeGamma, Gamma: 2.2}}
+		p := &Profile{TagBlocks: []*Tag{shared}}
+		p.mapTags()
+		p.SetTag(TagHeaderGreenTRC, TagCurve, &CurveTag{Type: CurveTypeIdentity})
+		require.Len(t, p.TagBlocks, 2)
+		assert.Same(t, shared, p.TagBlocks[0])
+		red, err := p.TagValue(TagHeaderRedTRC)
+		require.NoError(t, err)
+		assert.Equal(t, &CurveTag{Type: CurveTypeGamma, Gamma: 2.2}, red)
+		green, err := p.TagValue(TagHeaderGreenTRC)
+		require.NoError(t, err)
+		assert.Equal(t, &CurveTag{Type: CurveTypeIdentity}, green)
+	})
+	t.Run("Replace Unshared", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		blocks := len(p.TagBlocks)
+		existing, ok := p.TagByHeader(TagHeaderCopyright)
+		require.True(t, ok)
+		p.SetTag(TagHeaderCopyright, TagText, "foo")
+		assert.Len(t, p.TagBlocks, blocks)
+		assert.NotContains(t, p.TagBlocks, existing)
+	})
+	t.Run("Clears Cached Transforms", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		before, err := p.ToCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		p.SetTag(TagHeaderRedMatrixColumn, TagXYZ, []XYZNumber{{X: 0.5, Y: 0.25, Z: 0.01}})
+		after, err := p.ToCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+}
@@ -0,0 +1,181 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// WriteOptions represents the options passed to Profile.MarshalWithOptions and Profile.WriteToWithOptions
+type WriteOptions struct {
+	// TagEncoders allows you to provide custom tag encoders (or override default tag encoders)
+	//
+	// tag encoders are keyed by TagName (tag type signature) and are only used for tags that have
+	// no raw data (e.g. tags set using Profile.SetTag)
+	TagEncoders map[string]func(value any) ([]byte, error)
+	// ComputeProfileID determines whether the profile ID (MD5 checksum) is computed and written into the header
+	//
+	// if not set, the header profile ID is written as is - unless any tags have been encoded (tags without raw data,
+	// e.g. set using Profile.SetTag), in which case a zero (not computed) profile ID is written
+	ComputeProfileID bool
+}
+
+var _ io.WriterTo = (*Profile)(nil)
+
+// WriteTo writes the profile (as ICC profile bytes) to the supplied writer
+//
+// see Profile.Marshal for details
+func (p *Profile) WriteTo(w io.Writer) (int64, error) {
+	return p.WriteToWithOptions(w, nil)
+}
+
+// WriteToWithOptions writes the profile (as ICC profile bytes) to the supplied writer with the supplied WriteOptions
+//
+// see Profile.MarshalWithOptions for details
+func (p *Profile) WriteToWithOptions(w io.Writer, options *WriteOptions) (int64, error) {
+	data, err := p.MarshalWithOptions(options)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Marshal encodes the profile as ICC profile bytes
+//
+// the tag header table is rebuilt from the tag blocks (Profile.TagBlocks) - tag blocks shared by multiple
+// tag headers are only written once (with all the sharing tag headers pointing to the same offset) and each
+// tag block is padded to a 4-byte boundary
+//
+// the header profile size is recomputed (all other header fields are written as is) - except that the profile ID is
+// written as zero (not computed) if any tags were encoded
+//
+// tags without raw data (e.g. tags set using Profile.SetTag) are encoded using the default tag encoders
+func (p *Profile) Marshal() ([]byte, error) {
+	return p.MarshalWithOptions(nil)
+}
+
+// MarshalWithOptions encodes the profile as ICC profile bytes with the supplied WriteOptions
+//
+// see Profile.Marshal for details
+func (p *Profile) MarshalWithOptions(options *WriteOptions) ([]byte, error) {
+	if options == nil {
+		options = &WriteOptions{}
+	}
+	blocks := p.uniqueTagBlocks()
+	raws := make([][]byte, len(blocks))
+	type entry struct {
+		name  TagHeaderName
+		block int
+	}
+	entries := make([]entry, 0, len(blocks))
+	seen := make(map[TagHeaderName]bool, len(blocks))
+	encoded := false
+	for i, block := range blocks {
+		encoded = encoded || block.Raw == nil
+		raw, err := block.encode(options)
+		if err != nil {
+			return nil, err
+		}
+		raws[i] = raw
+		for _, hdr := range block.Headers {
+			if seen[hdr.Name] {
+				return nil, fmt.Errorf("duplicate tag header %q", hdr.Name)
+			}
+			seen[hdr.Name] = true
+			entries = append(entries, entry{name: hdr.Name, block: i})
+		}
+	}
+	// preserve the original tag header table order (new tag headers go last)...
+	order := make(map[TagHeaderName]int, len(p.TagHeaderTable.Entries))
+	for i, hdr := range p.TagHeaderTable.Entries {
+		if _, ok := order[hdr.Name]; !ok {
+			order[hdr.Name] = i
+		}
+	}
+	position := func(name TagHeaderName) int {
+		if i, ok := order[name]; ok {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return position(a.name) - position(b.name)
+	})
+	// layout the tag blocks...
+	offsets := make([]uint32, len(blocks))
+	offset := 128 + 4 + (12 * len(entries))
+	for i, raw := range raws {
+		offset = align4(offset)
+		offsets[i] = uint32(offset)
+		offset += len(raw)
+	}
+	size := align4(offset)
+	var buf bytes.Buffer
+	buf.Grow(size)
+	buf.Write(p.Header.encode(uint32(size)))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(entries)))
+	for _, e := range entries {
+		buf.Write(signatureBytes(e.name))
+		_ = binary.Write(&buf, binary.BigEndian, offsets[e.block])
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(raws[e.block])))
+	}
+	for i, raw := range raws {
+		buf.Write(make([]byte, int(offsets[i])-buf.Len()))
+		buf.Write(raw)
+	}
+	buf.Write(make([]byte, size-buf.Len()))
+	data := buf.Bytes()
+	if options.ComputeProfileID {
+		if err := setProfileID(data); err != nil {
+			return nil, err
+		}
+	} else if encoded {
+		// (the header profile ID is stale)
+		clear(data[84:100])
+	}
+	return data, nil
+}
+
+// encode returns the raw data of the tag - encoding the tag value if there is no raw data
+func (t *Tag) encode(options *WriteOptions) ([]byte, error) {
+	if t.Raw != nil {
+		return t.Raw, nil
+	}
+	if t.value == nil {
+		return nil, fmt.Errorf("tag %q has no raw data", t.Name)
+	}
+	encoder, ok := options.TagEncoders[t.Name]
+	if !ok {
+		if encoder, ok = defaultEncoders[t.Name]; !ok {
+			return nil, fmt.Errorf("no encoder for tag %q", t.Name)
+		}
+	}
+	raw, err := encoder(t.value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tag %q: %w", t.Name, err)
+	}
+	return raw, nil
+}
+
+// uniqueTagBlocks returns the tag blocks (in order) - with shared tag blocks only appearing once
+func (p *Profile) uniqueTagBlocks() []*Tag {
+	result := make([]*Tag, 0, len(p.TagBlocks))
+	seen := make(map[*Tag]bool, len(p.TagBlocks))
+	for _, block := range p.TagBlocks {
+		if block != nil && !seen[block] && len(block.Headers) > 0 {
+			seen[block] = true
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+func align4(offset int) int {
+	if pad := offset % 4; pad != 0 {
+		return offset + 4 - pad
+	}
+	return offset
+}
@@ -0,0 +1,231 @@
+package iccarus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/go-andiamo/iccarus/_test_data/profiles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testProfileBytes(t *testing.T, name string) []byte {
+	f, err := profiles.Open(name)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return data
+}
+
+func TestProfile_Marshal(t *testing.T) {
+	t.Run("Round Trip (identical)", func(t *testing.T) {
+		name := "default/display-p3-v4-with-v2-desc.icc"
+		original := testProfileBytes(t, name)
+		data, err := testProfile(t, name).Marshal()
+		require.NoError(t, err)
+		assert.Equal(t, original, data)
+	})
+	t.Run("Round Trip (padded)", func(t *testing.T) {
+		name := "default/ISOcoated_v2_300_eci.icc"
+		original := testProfileBytes(t, name)
+		p := testProfile(t, name)
+		data, err := p.Marshal()
+		require.NoError(t, err)
+		// original last tag is not padded to 4-byte boundary...
+		require.Len(t, data, align4(len(original)))
+		assert.Equal(t, uint32(len(data)), binary.BigEndian.Uint32(data[0:4]))
+		tableEnd := 128 + 4 + 12*len(p.TagHeaderTable.Entries)
+		assert.Equal(t, original[4:tableEnd], data[4:tableEnd])
+		p2, err := ParseProfile(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(len(data)), p2.Header.ProfileSize)
+		assert.Equal(t, p.TagHeaderTable, p2.TagHeaderTable)
+		require.Len(t, p2.TagBlocks, len(p.TagBlocks))
+		for i, block := range p.TagBlocks {
+			assert.Equal(t, block.Raw, p2.TagBlocks[i].Raw)
+		}
+	})
+	t.Run("Shared Tags", func(t *testing.T) {
+		shared := &Tag{
+			Headers: []TagHeader{{Name: TagHeaderRedTRC}, {Name: TagHeaderGreenTRC}},
+			Name:    TagCurve,
+			Raw:     []byte("curv\x00\x00\x00\x00\x00\x00\x00\x00"),
+		}
+		text := &Tag{
+			Headers: []TagHeader{{Name: TagHeaderCopyright}},
+			Name:    TagText,
+			Raw:     []byte("text\x00\x00\x00\x00foo\x00"[:11]),
+		}
+		p := &Profile{
+			Header:    Header{PCS: "XYZ", ColorSpace: "RGB", DeviceClass: "mntr"},
+			TagBlocks: []*Tag{text, shared, shared},
+		}
+		data, err := p.Marshal()
+		require.NoError(t, err)
+		p2, err := ParseProfile(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		require.Len(t, p2.TagHeaderTable.Entries, 3)
+		// text is padded to 4-byte boundary...
+		assert.Equal(t, TagHeader{Name: TagHeaderCopyright, Offset: 168, Size: 11}, p2.TagHeaderTable.Entries[0])
+		assert.Equal(t, TagHeader{Name: TagHeaderRedTRC, Offset: 180, Size: 12}, p2.TagHeaderTable.Entries[1])
+		assert.Equal(t, TagHeader{Name: TagHeaderGreenTRC, Offset: 180, Size: 12}, p2.TagHeaderTable.Entries[2])
+		assert.Equal(t, uint32(192), p2.Header.ProfileSize)
+		assert.Equal(t, "XYZ", p2.Header.PCS)
+		assert.Equal(t, "RGB", p2.Header.ColorSpace)
+		assert.Equal(t, [3]float64{0.964202880859375, 1, 0.8249053955078125}, p2.Header.Illuminant)
+		red, ok := p2.TagByHeader(TagHeaderRedTRC)
+		require.True(t, ok)
+		green, ok := p2.TagByHeader(TagHeaderGreenTRC)
+		require.True(t, ok)
+		assert.Same(t, red, green)
+	})
+	t.Run("No Raw Data", func(t *testing.T) {
+		p := &Profile{
+			TagBlocks: []*Tag{{Name: TagXYZ, Headers: []TagHeader{{Name: TagHeaderMediaWhitePointTag}}}},
+		}
+		_, err := p.Marshal()
+		assert.ErrorContains(t, err, `tag "XYZ" has no raw data`)
+	})
+	t.Run("Encoded Tags", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		p.SetTag(TagHeaderCopyright, TagText, "Copyright foo")
+		p.SetTag(TagHeaderRedTRC, TagCurve, &CurveTag{Type: CurveTypeGamma, Gamma: 2.2})
+		p.SetTag(TagHeaderViewingConditions, TagView, &ViewingConditionsTag{IlluminantType: 1})
+		data, err := p.Marshal()
+		require.NoError(t, err)
+		p2, err := ParseProfile(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		assert.Len(t, p2.TagHeaderTable.Entries, len(p.TagHeaderTable.Entries)+1)
+		// new tag headers go last...
+		assert.Equal(t, TagHeaderViewingConditions, p2.TagHeaderTable.Entries[len(p2.TagHeaderTable.Entries)-1].Name)
+		cprt, err := p2.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, "Copyright foo", cprt)
+		red, err := p2.TagValue(TagHeaderRedTRC)
+		require.NoError(t, err)
+		assert.Equal(t, &CurveTag{Type: CurveTypeGamma, Gamma: 2.19921875}, red)
+		// green & blue TRCs (previously shared with red) are unchanged...
+		green, ok := p2.TagByHeader(TagHeaderGreenTRC)
+		require.True(t, ok)
+		assert.Equal(t, TagParametricCurve, green.Name)
+		blue, ok := p2.TagByHeader(TagHeaderBlueTRC)
+		require.True(t, ok)
+		assert.Same(t, green, blue)
+	})
+	t.Run("Custom Encoders", func(t *testing.T) {
+		p := &Profile{}
+		p.SetTag("cust", "cust", 42)
+		p.SetTag(TagHeaderCopyright, TagText, "foo")
+		options := &WriteOptions{TagEncoders: map[string]func(value any) ([]byte, error){
+			"cust": func(value any) ([]byte, error) {
+				return append([]byte("cust\x00\x00\x00\x00"), byte(value.(int))), nil
+			},
+			TagText: func(value any) ([]byte, error) {
+				return textEncoder(strings.ToUpper(value.(string)))
+			},
+		}}
+		data, err := p.MarshalWithOptions(options)
+		require.NoError(t, err)
+		p2, err := ParseProfile(bytes.NewReader(data), nil)
+		require.NoError(t, err)
+		cust, ok := p2.TagByHeader("cust")
+		require.True(t, ok)
+		assert.Equal(t, []byte("cust\x00\x00\x00\x00*"), cust.Raw)
+		cprt, err := p2.TagValue(TagHeaderCopyright)
+		require.NoError(t, err)
+		assert.Equal(t, "FOO", cprt)
+	})
+	t.Run("No Encoder", func(t *testing.T) {
+		p := &Profile{}
+		p.SetTag("cust", "cust", 42)
+		_, err := p.Marshal()
+		assert.ErrorContains(t, err, `no encoder for tag "cust"`)
+	})
+	t.Run("Encode Error", func(t *testing.T) {
+		p := &Profile{}
+		p.SetTag(TagHeaderCopyright, TagText, 42)
+		_, err := p.Marshal()
+		assert.ErrorContains(t, err, `failed to encode tag "text": text encoder expects string (got int)`)
+	})
+	t.Run("Compute Profile ID", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		p.SetTag(TagHeaderCopyright, TagText, "Copyright foo")
+		data, err := p.MarshalWithOptions(&WriteOptions{ComputeProfileID: true})
+		require.NoError(t, err)
+		assert.NotEqual(t, make([]byte, 16), data[84:100])
+		_, err = ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true})
+		assert.NoError(t, err)
+	})
+	t.Run("Stale Profile ID", func(t *testing.T) {
+		t.Run("SetTag", func(t *testing.T) {
+			p, err := StandardProfile(SRGB)
+			require.NoError(t, err)
+			require.NotEqual(t, [16]byte{}, p.Header.ProfileID)
+			p.SetTag(TagHeaderRedTRC, TagParametricCurve, &ParametricCurveTag{Parameters: []float64{1.8}})
+			assert.Equal(t, [16]byte{}, p.Header.ProfileID)
+			data, err := p.Marshal()
+			require.NoError(t, err)
+			p2, err := ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true})
+			require.NoError(t, err)
+			assert.Equal(t, [16]byte{}, p2.Header.ProfileID)
+		})
+		t.Run("Tag Without Raw Data", func(t *testing.T) {
+			p, err := StandardProfile(SRGB)
+			require.NoError(t, err)
+			id := p.Header.ProfileID
+			data, err := p.Marshal()
+			require.NoError(t, err)
+			// unchanged profile keeps its profile ID...
+			assert.Equal(t, id[:], data[84:100])
+			p.TagBlocks[0] = &Tag{Headers: p.TagBlocks[0].Headers, Name: TagText, value: "Copyright foo"}
+			data, err = p.Marshal()
+			require.NoError(t, err)
+			assert.Equal(t, make([]byte, 16), data[84:100])
+			_, err = ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true})
+			assert.NoError(t, err)
+		})
+	})
+	t.Run("Duplicate Header", func(t *testing.T) {
+		p := &Profile{
+			TagBlocks: []*Tag{
+				{Name: TagXYZ, Raw: []byte{}, Headers: []TagHeader{{Name: TagHeaderMediaWhitePointTag}}},
+				{Name: TagXYZ, Raw: []byte{}, Headers: []TagHeader{{Name: TagHeaderMediaWhitePointTag}}},
+			},
+		}
+		_, err := p.Marshal()
+		assert.ErrorContains(t, err, `duplicate tag header "wtpt"`)
+	})
+}
+
+func TestProfile_WriteTo(t *testing.T) {
+	name := "default/display-p3-v4-with-v2-desc.icc"
+	var buf bytes.Buffer
+	n, err := testProfile(t, name).WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(548), n)
+	assert.Equal(t, testProfileBytes(t, name), buf.Bytes())
+	_, err = (&Profile{TagBlocks: []*Tag{{Headers: []TagHeader{{Name: TagHeaderCopyright}}}}}).WriteTo(&buf)
+	assert.Error(t, err)
+}
+
+func TestProfile_WriteToWithOptions(t *testing.T) {
+	p := &Profile{}
+	p.SetTag(TagHeaderCopyright, "cust", "foo")
+	options := &WriteOptions{TagEncoders: map[string]func(value any) ([]byte, error){
+		"cust": func(value any) ([]byte, error) {
+			return append([]byte("cust\x00\x00\x00\x00"), value.(string)...), nil
+		},
+	}}
+	var buf bytes.Buffer
+	n, err := p.WriteToWithOptions(&buf, options)
+	require.NoError(t, err)
+	assert.Equal(t, int64(128+4+12+12), n)
+	assert.Equal(t, []byte("cust\x00\x00\x00\x00foo\x00"), buf.Bytes()[144:])
+	_, err = p.WriteToWithOptions(&buf, nil)
+	assert.ErrorContains(t, err, `no encoder for tag "cust"`)
+}
@@ -0,0 +1,42 @@
+package iccarus
+
+import "fmt"
+
+// RenderingIntent is the ICC rendering intent used to select the A2Bx/B2Ax tags for conversions
+type RenderingIntent uint32
+
+const (
+	IntentPerceptual RenderingIntent = iota
+	IntentRelativeColorimetric
+	IntentSaturation
+	IntentAbsoluteColorimetric
+)
+
+func (ri RenderingIntent) String() string {
+	switch ri {
+	case IntentPerceptual:
+		return "Perceptual"
+	case IntentRelativeColorimetric:
+		return "Relative Colorimetric"
+	case IntentSaturation:
+		return "Saturation"
+	case IntentAbsoluteColorimetric:
+		return "Absolute Colorimetric"
+	}
+	return fmt.Sprintf("Unknown (%d)", uint32(ri))
+}
+
+// lutIndex returns the A2Bx/B2Ax tag index for the intent
+//
+// absolute colorimetric uses the relative colorimetric (A2B1/B2A1) tags - with media white point scaling
+func (ri RenderingIntent) lutIndex() (int, error) {
+	switch ri {
+	case IntentPerceptual:
+		return 0, nil
+	case IntentRelativeColorimetric, IntentAbsoluteColorimetric:
+		return 1, nil
+	case IntentSaturation:
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unknown rendering intent %d", uint32(ri))
+}
@@ -0,0 +1,38 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRenderingIntent_String(t *testing.T) {
+	assert.Equal(t, "Perceptual", IntentPerceptual.String())
+	assert.Equal(t, "Relative Colorimetric", IntentRelativeColorimetric.String())
+	assert.Equal(t, "Saturation", IntentSaturation.String())
+	assert.Equal(t, "Absolute Colorimetric", IntentAbsoluteColorimetric.String())
+	assert.Equal(t, "Unknown (9)", RenderingIntent(9).String())
+}
+
+func TestRenderingIntent_lutIndex(t *testing.T) {
+	testCases := []struct {
+		intent RenderingIntent
+		expect int
+	}{
+		{IntentPerceptual, 0},
+		{IntentRelativeColorimetric, 1},
+		{IntentSaturation, 2},
+		{IntentAbsoluteColorimetric, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.intent.String(), func(t *testing.T) {
+			idx, err := tc.intent.lutIndex()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, idx)
+		})
+	}
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := RenderingIntent(4).lutIndex()
+		assert.ErrorContains(t, err, "unknown rendering intent 4")
+	})
+}
@@ -0,0 +1,159 @@
+package iccarus
+
+import (
+	"fmt"
+	"time"
+)
+
+// StandardProfileType is a well-known (standard) color profile
+type StandardProfileType uint8
+
+const (
+	// SRGB is sRGB (IEC 61966-2-1)
+	SRGB StandardProfileType = iota
+	// DisplayP3 is Display P3 (DCI-P3 primaries, D65 white point & sRGB transfer function)
+	DisplayP3
+	// AdobeRGB is Adobe RGB (1998) compatible
+	AdobeRGB
+	// Rec2020 is ITU-R BT.2020
+	Rec2020
+	// ProPhoto is ProPhoto RGB (ROMM RGB)
+	ProPhoto
+	// GrayGamma22 is gray with a gamma of 2.2 (D50 white point)
+	GrayGamma22
+)
+
+func (t StandardProfileType) String() string {
+	if def, ok := standardProfiles[t]; ok {
+		return def.description
+	}
+	return fmt.Sprintf("Unknown (%d)", uint8(t))
+}
+
+type standardProfile struct {
+	description string
+	red         XyY
+	green       XyY
+	blue        XyY
+	white       XyY
+	gamma       float64
+	trc         *ParametricCurveTag
+	gray        bool
+}
+
+var (
+	whiteD65 = XyY{X: 0.3127, Y: 0.3290}
+	whiteD50 = XyY{X: 0.3457, Y: 0.3585}
+)
+
+var standardProfiles = map[StandardProfileType]standardProfile{
+	SRGB: {
+		description: "sRGB",
+		red:         XyY{X: 0.64, Y: 0.33},
+		green:       XyY{X: 0.30, Y: 0.60},
+		blue:        XyY{X: 0.15, Y: 0.06},
+		white:       whiteD65,
+		trc:         sRGBCurve(),
+	},
+	DisplayP3: {
+		description: "Display P3",
+		red:         XyY{X: 0.680, Y: 0.320},
+		green:       XyY{X: 0.265, Y: 0.690},
+		blue:        XyY{X: 0.150, Y: 0.060},
+		white:       whiteD65,
+		trc:         sRGBCurve(),
+	},
+	AdobeRGB: {
+		description: "Adobe RGB (1998)",
+		red:         XyY{X: 0.64, Y: 0.33},
+		green:       XyY{X: 0.21, Y: 0.71},
+		blue:        XyY{X: 0.15, Y: 0.06},
+		white:       whiteD65,
+		gamma:       563.0 / 256.0,
+	},
+	Rec2020: {
+		description: "Rec. ITU-R BT.2020",
+		red:         XyY{X: 0.708, Y: 0.292},
+		green:       XyY{X: 0.170, Y: 0.797},
+		blue:        XyY{X: 0.131, Y: 0.046},
+		white:       whiteD65,
+		// inverse of the BT.2020 OETF...
+		trc: &ParametricCurveTag{
+			FunctionType: SplitFunction,
+			Parameters:   []float64{1 / 0.45, 1 / 1.09929682680944, 0.09929682680944 / 1.09929682680944, 1 / 4.5, 0.08124285829863},
+		},
+	},
+	ProPhoto: {
+		description: "ProPhoto RGB",
+		red:         XyY{X: 0.7347, Y: 0.2653},
+		green:       XyY{X: 0.1596, Y: 0.8404},
+		blue:        XyY{X: 0.0366, Y: 0.0001},
+		white:       whiteD50,
+		gamma:       1.8,
+	},
+	GrayGamma22: {
+		description: "Gray Gamma 2.2",
+		white:       whiteD50,
+		gamma:       2.2,
+		gray:        true,
+	},
+}
+
+func sRGBCurve() *ParametricCurveTag {
+	return &ParametricCurveTag{
+		FunctionType: SplitFunction,
+		Parameters:   []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045},
+	}
+}
+
+// standardProfileCreated is the (fixed) creation date of standard profiles - so that serialized standard profiles
+// are always identical
+var standardProfileCreated = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// StandardProfile returns a new v4 profile for a well-known (standard) color space
+//
+// the profile is generated from the published primaries, white point & transfer function and has its
+// profile ID set (so it can be serialized as is - see Profile.Marshal)
+//
+// a new profile is returned on each call - so it is safe to modify the returned profile
+func StandardProfile(t StandardProfileType) (*Profile, error) {
+	def, ok := standardProfiles[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown standard profile %d", uint8(t))
+	}
+	const copyright = "No copyright, use freely"
+	var result *Profile
+	var err error
+	if def.gray {
+		result, err = (&GrayProfileBuilder{
+			White:       def.white,
+			Gamma:       def.gamma,
+			TRC:         def.trc,
+			Description: def.description,
+			Copyright:   copyright,
+			Created:     standardProfileCreated,
+		}).Build()
+	} else {
+		result, err = (&RGBProfileBuilder{
+			Red:         def.red,
+			Green:       def.green,
+			Blue:        def.blue,
+			White:       def.white,
+			Gamma:       def.gamma,
+			TRC:         def.trc,
+			Description: def.description,
+			Copyright:   copyright,
+			Created:     standardProfileCreated,
+		}).Build()
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := result.MarshalWithOptions(&WriteOptions{ComputeProfileID: true})
+	if err != nil {
+		return nil, err
+	}
+	result.Header.ProfileSize = uint32(len(data))
+	copy(result.Header.ProfileID[:], data[84:100])
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package iccarus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStandardProfile(t *testing.T) {
+	testCases := []struct {
+		profile    StandardProfileType
+		name       string
+		colorSpace string
+	}{
+		{SRGB, "sRGB", "RGB"},
+		{DisplayP3, "Display P3", "RGB"},
+		{AdobeRGB, "Adobe RGB (1998)", "RGB"},
+		{Rec2020, "Rec. ITU-R BT.2020", "RGB"},
+		{ProPhoto, "ProPhoto RGB", "RGB"},
+		{GrayGamma22, "Gray Gamma 2.2", "GRAY"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.name, tc.profile.String())
+			p, err := StandardProfile(tc.profile)
+			require.NoError(t, err)
+			assert.Equal(t, tc.colorSpace, p.Header.ColorSpace)
+			assert.Equal(t, Version{Major: 4, Minor: 3}, p.Header.Version)
+			desc, err := p.TagValue(TagHeaderDescription)
+			require.NoError(t, err)
+			assert.Equal(t, tc.name, desc.(*MultiLocalizedTag).Strings[0].Value)
+			channels := []float64{1, 1, 1}
+			if tc.colorSpace == "GRAY" {
+				channels = []float64{1}
+			}
+			xyz, err := p.ToCIEXYZ(channels...)
+			require.NoError(t, err)
+			// device white is the PCS illuminant...
+			assert.InDeltaSlice(t, d50[:], xyz, 0.001)
+			// serializes identically (with a valid profile ID)...
+			data, err := p.Marshal()
+			require.NoError(t, err)
+			assert.Equal(t, p.Header.ProfileSize, uint32(len(data)))
+			p2, err := StandardProfile(tc.profile)
+			require.NoError(t, err)
+			data2, err := p2.Marshal()
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(data, data2))
+			_, err = ParseProfile(bytes.NewReader(data), &ParseOptions{VerifyProfileID: true, ErrorOnTagDecode: true})
+			require.NoError(t, err)
+		})
+	}
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := StandardProfile(StandardProfileType(99))
+		assert.ErrorContains(t, err, "unknown standard profile 99")
+		assert.Equal(t, "Unknown (99)", StandardProfileType(99).String())
+	})
+	t.Run("Matches Display P3 Profile", func(t *testing.T) {
+		p, err := StandardProfile(DisplayP3)
+		require.NoError(t, err)
+		p3 := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		for _, hdr := range []TagHeaderName{TagHeaderRedMatrixColumn, TagHeaderGreenMatrixColumn, TagHeaderBlueMatrixColumn} {
+			expected, err := p3.TagValue(hdr)
+			require.NoError(t, err)
+			actual, err := p.TagValue(hdr)
+			require.NoError(t, err)
+			e, a := expected.([]XYZNumber)[0], actual.([]XYZNumber)[0]
+			assert.InDelta(t, e.X, a.X, 0.001, hdr)
+			assert.InDelta(t, e.Y, a.Y, 0.001, hdr)
+			assert.InDelta(t, e.Z, a.Z, 0.001, hdr)
+		}
+		for _, v := range []float64{0, 0.04, 0.2, 0.5, 0.9, 1} {
+			expected, err := p3.ToCIEXYZ(v, v, v)
+			require.NoError(t, err)
+			actual, err := p.ToCIEXYZ(v, v, v)
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, expected, actual, 0.001)
+		}
+	})
+	t.Run("sRGB to Display P3", func(t *testing.T) {
+		srgb, err := StandardProfile(SRGB)
+		require.NoError(t, err)
+		p3, err := StandardProfile(DisplayP3)
+		require.NoError(t, err)
+		tr, err := NewTransform(srgb, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		out, err := tr.Transform(1, 0, 0)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, []float64{0.9175, 0.2003, 0.1386}, out, 0.002)
+	})
+}
+
+func testStandardProfile(t *testing.T, sp StandardProfileType) *Profile {
+	p, err := StandardProfile(sp)
+	require.NoError(t, err)
+	return p
+}
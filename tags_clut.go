@@ -11,6 +11,7 @@ type CLUTTag struct {
 	GridPoints     []uint8 // e.g., [17,17,17] for 3D CLUT
 	InputChannels  uint8
 	OutputChannels uint8
+	Precision      uint8     // bytes per value in the encoded table (1 or 2)
 	Values         []float64 // flattened [in1, in2, ..., out1, out2, ...]
 	expectedValues int
 }
@@ -47,6 +48,7 @@ func clutDecoder(raw []byte) (any, error) {
 		GridPoints:     gridPoints,
 		InputChannels:  uint8(inputCh),
 		OutputChannels: uint8(outputCh),
+		Precision:      2,
 		Values:         values,
 		expectedValues: expectedValues(gridPoints, outputCh),
 	}, nil
@@ -154,3 +156,24 @@ func clamp01(v float64) float64 {
 	}
 	return v
 }
+
+func clutEncoder(value any) ([]byte, error) {
+	c, ok := value.(*CLUTTag)
+	if !ok {
+		return nil, fmt.Errorf("clut encoder expects *CLUTTag (got %T)", value)
+	}
+	if len(c.GridPoints) != int(c.InputChannels) {
+		return nil, fmt.Errorf("grid points mismatch: expected %d, got %d", c.InputChannels, len(c.GridPoints))
+	}
+	if expected := expectedValues(c.GridPoints, int(c.OutputChannels)); len(c.Values) != expected {
+		return nil, fmt.Errorf("CLUT unexpected values length: expected %d, got %d", expected, len(c.Values))
+	}
+	buf := newTagBuffer(TagColorLookupTable)
+	buf.WriteByte(c.InputChannels)
+	buf.WriteByte(c.OutputChannels)
+	buf.Write(c.GridPoints)
+	for _, v := range c.Values {
+		_ = binary.Write(buf, binary.BigEndian, encodeNormalizedUint16(v))
+	}
+	return buf.Bytes(), nil
+}
@@ -157,3 +157,30 @@ func TestClamp01(t *testing.T) {
 	require.Equal(t, float64(0), clamp01(-1))
 	require.Equal(t, float64(0.5), clamp01(0.5))
 }
+
+func TestCLUTEncoder(t *testing.T) {
+	clut := &CLUTTag{
+		GridPoints:     []uint8{2, 2},
+		InputChannels:  2,
+		OutputChannels: 1,
+		Values:         []float64{0, 0.25, 0.5, 1},
+	}
+	raw, err := clutEncoder(clut)
+	require.NoError(t, err)
+	val, err := clutDecoder(raw)
+	require.NoError(t, err)
+	decoded := val.(*CLUTTag)
+	assert.Equal(t, clut.GridPoints, decoded.GridPoints)
+	assert.Equal(t, clut.InputChannels, decoded.InputChannels)
+	assert.Equal(t, clut.OutputChannels, decoded.OutputChannels)
+	assert.InDeltaSlice(t, clut.Values, decoded.Values, 1.0/65535)
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := clutEncoder([]float64{})
+		assert.ErrorContains(t, err, "clut encoder expects *CLUTTag")
+		_, err = clutEncoder(&CLUTTag{GridPoints: []uint8{2}, InputChannels: 2, OutputChannels: 1})
+		assert.ErrorContains(t, err, "grid points mismatch: expected 2, got 1")
+		_, err = clutEncoder(&CLUTTag{GridPoints: []uint8{2, 2}, InputChannels: 2, OutputChannels: 1, Values: []float64{0}})
+		assert.ErrorContains(t, err, "CLUT unexpected values length: expected 4, got 1")
+	})
+}
@@ -28,16 +28,16 @@ type ParametricCurveFunction uint16
 
 const (
 	SimpleGammaFunction     ParametricCurveFunction = 0 // Y = X^g
-	ConditionalZeroFunction ParametricCurveFunction = 1 // Y = (aX+b)^g for X >= d, else 0
-	ConditionalCFunction    ParametricCurveFunction = 2 // Y = (aX+b)^g for X >= d, else c
-	SplitFunction           ParametricCurveFunction = 3 // Two different functions split at d
-	ComplexFunction         ParametricCurveFunction = 4 // More complex piecewise function
+	ConditionalZeroFunction ParametricCurveFunction = 1 // Y = (aX+b)^g for X >= -b/a, else 0
+	ConditionalCFunction    ParametricCurveFunction = 2 // Y = (aX+b)^g + c for X >= -b/a, else c
+	SplitFunction           ParametricCurveFunction = 3 // Y = (aX+b)^g for X >= d, else cX
+	ComplexFunction         ParametricCurveFunction = 4 // Y = (aX+b)^g + e for X >= d, else cX + f
 )
 
 // ParametricCurveTag represents a parametric curve tag (TagParametricCurve)
 type ParametricCurveTag struct {
 	FunctionType ParametricCurveFunction
-	Parameters   []float64
+	Parameters   []float64 // in spec order - g, a, b, c, d, e, f
 }
 
 var _ ChannelTransformer = (*ParametricCurveTag)(nil)
@@ -73,22 +73,11 @@ func parametricCurveDecoder(raw []byte) (any, error) {
 		return nil, errors.New("para tag too short")
 	}
 	funcType := ParametricCurveFunction(binary.BigEndian.Uint16(raw[8:10]))
-	var expected int
-	switch funcType {
-	case SimpleGammaFunction:
-		expected = 1
-	case ConditionalZeroFunction:
-		expected = 3
-	case ConditionalCFunction:
-		expected = 4
-	case SplitFunction:
-		expected = 5
-	case ComplexFunction:
-		expected = 7
-	default:
+	expected, ok := parametricParameterCount(funcType)
+	if !ok {
 		return nil, fmt.Errorf("unknown parametric function type: %d", funcType)
 	}
-	offset := 10
+	offset := 12 // function type is followed by 2 reserved bytes
 	if len(raw) < offset+(expected*4) {
 		return nil, fmt.Errorf("para tag truncated for function %d", funcType)
 	}
@@ -103,6 +92,22 @@ func parametricCurveDecoder(raw []byte) (any, error) {
 	}, nil
 }
 
+func parametricParameterCount(funcType ParametricCurveFunction) (int, bool) {
+	switch funcType {
+	case SimpleGammaFunction:
+		return 1, true
+	case ConditionalZeroFunction:
+		return 3, true
+	case ConditionalCFunction:
+		return 4, true
+	case SplitFunction:
+		return 5, true
+	case ComplexFunction:
+		return 7, true
+	}
+	return 0, false
+}
+
 func (c *CurveTag) Transform(inputs ...float64) ([]float64, error) {
 	if len(inputs) != 1 {
 		return nil, fmt.Errorf("curve expects 1 input, got %d", len(inputs))
@@ -150,7 +155,7 @@ func (p *ParametricCurveTag) Transform(inputs ...float64) ([]float64, error) {
 		if len(p.Parameters) != 3 {
 			return nil, errors.New("function 1 expects 3 parameters")
 		}
-		a, b, g := p.Parameters[0], p.Parameters[1], p.Parameters[2]
+		g, a, b := p.Parameters[0], p.Parameters[1], p.Parameters[2]
 		if x >= -b/a {
 			result = math.Pow(a*x+b, g)
 		} else {
@@ -161,7 +166,7 @@ func (p *ParametricCurveTag) Transform(inputs ...float64) ([]float64, error) {
 		if len(p.Parameters) != 4 {
 			return nil, errors.New("function 2 expects 4 parameters")
 		}
-		a, b, g, c := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3]
+		g, a, b, c := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3]
 		if x >= -b/a {
 			result = math.Pow(a*x+b, g) + c
 		} else {
@@ -172,7 +177,7 @@ func (p *ParametricCurveTag) Transform(inputs ...float64) ([]float64, error) {
 		if len(p.Parameters) != 5 {
 			return nil, errors.New("function 3 expects 5 parameters")
 		}
-		a, b, g, c, d := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3], p.Parameters[4]
+		g, a, b, c, d := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3], p.Parameters[4]
 		if x >= d {
 			result = math.Pow(a*x+b, g)
 		} else {
@@ -183,7 +188,7 @@ func (p *ParametricCurveTag) Transform(inputs ...float64) ([]float64, error) {
 		if len(p.Parameters) != 7 {
 			return nil, errors.New("function 4 expects 7 parameters")
 		}
-		a, b, g, c, d, e, f := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3], p.Parameters[4], p.Parameters[5], p.Parameters[6]
+		g, a, b, c, d, e, f := p.Parameters[0], p.Parameters[1], p.Parameters[2], p.Parameters[3], p.Parameters[4], p.Parameters[5], p.Parameters[6]
 		if x >= d {
 			result = math.Pow(a*x+b, g) + e
 		} else {
@@ -194,3 +199,53 @@ func (p *ParametricCurveTag) Transform(inputs ...float64) ([]float64, error) {
 	}
 	return []float64{result}, nil
 }
+
+func curveEncoder(value any) ([]byte, error) {
+	c, ok := value.(*CurveTag)
+	if !ok {
+		return nil, fmt.Errorf("curv encoder expects *CurveTag (got %T)", value)
+	}
+	buf := newTagBuffer(TagCurve)
+	switch c.Type {
+	case CurveTypeIdentity:
+		_ = binary.Write(buf, binary.BigEndian, uint32(0))
+	case CurveTypeGamma:
+		// 8.8 fixed-point
+		gamma := math.Round(c.Gamma * 256.0)
+		if gamma < 0 || gamma > math.MaxUint16 {
+			return nil, fmt.Errorf("curv gamma %v out of range", c.Gamma)
+		}
+		_ = binary.Write(buf, binary.BigEndian, uint32(1))
+		_ = binary.Write(buf, binary.BigEndian, uint16(gamma))
+	case CurveTypePoints:
+		if len(c.Points) < 2 {
+			return nil, fmt.Errorf("curv tag requires at least 2 points, got %d", len(c.Points))
+		}
+		_ = binary.Write(buf, binary.BigEndian, uint32(len(c.Points)))
+		_ = binary.Write(buf, binary.BigEndian, c.Points)
+	default:
+		return nil, fmt.Errorf("unknown curve type: %d", c.Type)
+	}
+	return buf.Bytes(), nil
+}
+
+func parametricCurveEncoder(value any) ([]byte, error) {
+	c, ok := value.(*ParametricCurveTag)
+	if !ok {
+		return nil, fmt.Errorf("para encoder expects *ParametricCurveTag (got %T)", value)
+	}
+	expected, ok := parametricParameterCount(c.FunctionType)
+	if !ok {
+		return nil, fmt.Errorf("unknown parametric function type: %d", c.FunctionType)
+	}
+	if len(c.Parameters) != expected {
+		return nil, fmt.Errorf("para function %d expects %d parameters, got %d", c.FunctionType, expected, len(c.Parameters))
+	}
+	buf := newTagBuffer(TagParametricCurve)
+	_ = binary.Write(buf, binary.BigEndian, uint16(c.FunctionType))
+	buf.Write([]byte{0, 0}) // reserved
+	for _, v := range c.Parameters {
+		buf.Write(encodeS15Fixed16BE(v))
+	}
+	return buf.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package iccarus
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math"
@@ -66,6 +67,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")                             // 4 bytes
 		buf.Write([]byte{0, 0, 0, 0})                       // reserved
 		_ = binary.Write(&buf, binary.BigEndian, uint16(0)) // function type 0
+		buf.Write([]byte{0, 0})                             // reserved
 		buf.Write(encodeS15Fixed16BE(1.0))                  // 1.0
 
 		val, err := parametricCurveDecoder(buf.Bytes())
@@ -81,6 +83,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")
 		buf.Write([]byte{0, 0, 0, 0})
 		_ = binary.Write(&buf, binary.BigEndian, uint16(1))
+		buf.Write([]byte{0, 0})
 		for i := 0; i < 3; i++ {
 			buf.Write(encodeS15Fixed16BE(float64(i)))
 		}
@@ -99,6 +102,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")
 		buf.Write([]byte{0, 0, 0, 0})
 		_ = binary.Write(&buf, binary.BigEndian, uint16(2))
+		buf.Write([]byte{0, 0})
 		for i := 0; i < 4; i++ {
 			buf.Write(encodeS15Fixed16BE(float64(i)))
 		}
@@ -118,6 +122,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")
 		buf.Write([]byte{0, 0, 0, 0})
 		_ = binary.Write(&buf, binary.BigEndian, uint16(3))
+		buf.Write([]byte{0, 0})
 		for i := 0; i < 5; i++ {
 			buf.Write(encodeS15Fixed16BE(float64(i)))
 		}
@@ -138,6 +143,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")
 		buf.Write([]byte{0, 0, 0, 0})
 		_ = binary.Write(&buf, binary.BigEndian, uint16(4))
+		buf.Write([]byte{0, 0})
 		for i := 0; i < 7; i++ {
 			buf.Write(encodeS15Fixed16BE(float64(i)))
 		}
@@ -164,6 +170,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 		buf.WriteString("para")
 		buf.Write([]byte{0, 0, 0, 0})
 		_ = binary.Write(&buf, binary.BigEndian, uint16(5))
+		buf.Write([]byte{0, 0})
 		for i := 0; i < 7; i++ {
 			_ = binary.Write(&buf, binary.BigEndian, uint32(0x00010000)) // 1.0
 		}
@@ -173,7 +180,7 @@ func TestParametricCurveDecoder(t *testing.T) {
 	})
 	t.Run("TruncatedParameters", func(t *testing.T) {
 		raw := []byte("para\x00\x00\x00\x00" +
-			"\x00\x02" + // function type 2 (needs 4 params)
+			"\x00\x02\x00\x00" + // function type 2 (needs 4 params) + reserved
 			"\x00\x01\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00") // only 3 params
 		_, err := parametricCurveDecoder(raw)
 		assert.ErrorContains(t, err, "para tag truncated for function 2")
@@ -248,7 +255,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ConditionalZeroFunction", func(t *testing.T) {
 		curve := &ParametricCurveTag{
 			FunctionType: ConditionalZeroFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0}, // Y = (X)^2 if X>=0 else 0
+			Parameters:   []float64{2.0, 1.0, 0.0}, // Y = (X)^2 if X>=0 else 0
 		}
 		out, err := curve.Transform(-0.5)
 		require.NoError(t, err)
@@ -258,7 +265,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ConditionalZeroFunction_PositiveBranch", func(t *testing.T) {
 		p := &ParametricCurveTag{
 			FunctionType: ConditionalZeroFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0}, // a=1.0, b=0.0, g=2.0
+			Parameters:   []float64{2.0, 1.0, 0.0}, // g=2.0, a=1.0, b=0.0
 		}
 		out, err := p.Transform(0.5) // 0.5 >= -b/a → 0.5 >= 0 → true
 		require.NoError(t, err)
@@ -275,7 +282,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ConditionalCFunction", func(t *testing.T) {
 		curve := &ParametricCurveTag{
 			FunctionType: ConditionalCFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 0.1}, // Y = (X)^2+0.1 if X>=0 else 0.1
+			Parameters:   []float64{2.0, 1.0, 0.0, 0.1}, // Y = (X)^2+0.1 if X>=0 else 0.1
 		}
 		out, err := curve.Transform(-0.5)
 		require.NoError(t, err)
@@ -285,7 +292,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ConditionalCFunction_PositiveBranch", func(t *testing.T) {
 		p := &ParametricCurveTag{
 			FunctionType: ConditionalCFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 0.1}, // a=1.0, b=0.0, g=2.0, c=0.1
+			Parameters:   []float64{2.0, 1.0, 0.0, 0.1}, // g=2.0, a=1.0, b=0.0, c=0.1
 		}
 		out, err := p.Transform(0.5) // 0.5 >= -b/a → 0.5 >= 0 → true
 		require.NoError(t, err)
@@ -302,7 +309,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("SplitFunction", func(t *testing.T) {
 		curve := &ParametricCurveTag{
 			FunctionType: SplitFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 2.0, 0.5}, // switch at 0.5
+			Parameters:   []float64{2.0, 1.0, 0.0, 2.0, 0.5}, // switch at 0.5
 		}
 		out, err := curve.Transform(0.4)
 		require.NoError(t, err)
@@ -312,7 +319,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("SplitFunction_PositiveBranch", func(t *testing.T) {
 		p := &ParametricCurveTag{
 			FunctionType: SplitFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 0.5, 0.4}, // a=1.0, b=0.0, g=2.0, c=0.5, d=0.4
+			Parameters:   []float64{2.0, 1.0, 0.0, 0.5, 0.4}, // g=2.0, a=1.0, b=0.0, c=0.5, d=0.4
 		}
 		out, err := p.Transform(0.5) // 0.5 >= 0.4 → true
 		require.NoError(t, err)
@@ -329,7 +336,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ComplexFunction", func(t *testing.T) {
 		curve := &ParametricCurveTag{
 			FunctionType: ComplexFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 2.0, 0.5, 0.1, 0.2}, // split at 0.5
+			Parameters:   []float64{2.0, 1.0, 0.0, 2.0, 0.5, 0.1, 0.2}, // split at 0.5
 		}
 		out, err := curve.Transform(0.6)
 		require.NoError(t, err)
@@ -339,7 +346,7 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 	t.Run("ComplexFunction_NegativeBranch", func(t *testing.T) {
 		p := &ParametricCurveTag{
 			FunctionType: ComplexFunction,
-			Parameters:   []float64{1.0, 0.0, 2.0, 0.5, 0.6, 0.1, 0.2}, // a=1, b=0, g=2, c=0.5, d=0.6, e=0.1, f=0.2
+			Parameters:   []float64{2.0, 1.0, 0.0, 0.5, 0.6, 0.1, 0.2}, // g=2, a=1, b=0, c=0.5, d=0.6, e=0.1, f=0.2
 		}
 		out, err := p.Transform(0.5) // 0.5 < 0.6 → false branch
 		require.NoError(t, err)
@@ -367,3 +374,72 @@ func TestParametricCurveTag_Transform(t *testing.T) {
 		assert.ErrorContains(t, err, "unknown parametric function type")
 	})
 }
+
+func TestCurveEncoder(t *testing.T) {
+	testCases := []struct {
+		name  string
+		curve *CurveTag
+	}{
+		{name: "Identity", curve: &CurveTag{Type: CurveTypeIdentity}},
+		{name: "Gamma", curve: &CurveTag{Type: CurveTypeGamma, Gamma: 2.2}},
+		{name: "Points", curve: &CurveTag{Type: CurveTypePoints, Points: []uint16{0, 1000, 30000, 65535}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := curveEncoder(tc.curve)
+			require.NoError(t, err)
+			assert.Equal(t, "curv", string(raw[0:4]))
+			val, err := curveDecoder(raw)
+			require.NoError(t, err)
+			curve := val.(*CurveTag)
+			assert.Equal(t, tc.curve.Type, curve.Type)
+			assert.InDelta(t, tc.curve.Gamma, curve.Gamma, 1.0/256)
+			assert.Equal(t, tc.curve.Points, curve.Points)
+		})
+	}
+	t.Run("Errors", func(t *testing.T) {
+		_, err := curveEncoder("not a curve")
+		assert.ErrorContains(t, err, "curv encoder expects *CurveTag (got string)")
+		_, err = curveEncoder(&CurveTag{Type: CurveTypeGamma, Gamma: 256})
+		assert.ErrorContains(t, err, "curv gamma 256 out of range")
+		_, err = curveEncoder(&CurveTag{Type: CurveTypeGamma, Gamma: -1})
+		assert.ErrorContains(t, err, "curv gamma -1 out of range")
+		_, err = curveEncoder(&CurveTag{Type: CurveTypePoints, Points: []uint16{0}})
+		assert.ErrorContains(t, err, "curv tag requires at least 2 points, got 1")
+		_, err = curveEncoder(&CurveTag{Type: 99})
+		assert.ErrorContains(t, err, "unknown curve type: 99")
+	})
+}
+
+func TestParametricCurveEncoder(t *testing.T) {
+	testCases := []struct {
+		fn     ParametricCurveFunction
+		params []float64
+	}{
+		{SimpleGammaFunction, []float64{2.2}},
+		{ConditionalZeroFunction, []float64{2.2, 1.1, -0.1}},
+		{ConditionalCFunction, []float64{2.2, 1.1, -0.1, 0.05}},
+		{SplitFunction, []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}},
+		{ComplexFunction, []float64{2.4, 0.9, 0.1, 0.07, 0.04, 0.01, 0.02}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("Function %d", tc.fn), func(t *testing.T) {
+			raw, err := parametricCurveEncoder(&ParametricCurveTag{FunctionType: tc.fn, Parameters: tc.params})
+			require.NoError(t, err)
+			assert.Equal(t, 12+len(tc.params)*4, len(raw))
+			val, err := parametricCurveDecoder(raw)
+			require.NoError(t, err)
+			curve := val.(*ParametricCurveTag)
+			assert.Equal(t, tc.fn, curve.FunctionType)
+			assert.InDeltaSlice(t, tc.params, curve.Parameters, 1.0/65536)
+		})
+	}
+	t.Run("Errors", func(t *testing.T) {
+		_, err := parametricCurveEncoder(&CurveTag{})
+		assert.ErrorContains(t, err, "para encoder expects *ParametricCurveTag (got *iccarus.CurveTag)")
+		_, err = parametricCurveEncoder(&ParametricCurveTag{FunctionType: 9})
+		assert.ErrorContains(t, err, "unknown parametric function type: 9")
+		_, err = parametricCurveEncoder(&ParametricCurveTag{FunctionType: SplitFunction, Parameters: []float64{2.2}})
+		assert.ErrorContains(t, err, "para function 3 expects 5 parameters, got 1")
+	})
+}
@@ -53,3 +53,20 @@ func (m *MatrixTag) Transform(inputs ...float64) ([]float64, error) {
 	}
 	return out, nil
 }
+
+func mtxEncoder(value any) ([]byte, error) {
+	m, ok := value.(*MatrixTag)
+	if !ok {
+		return nil, fmt.Errorf("mtx encoder expects *MatrixTag (got %T)", value)
+	}
+	buf := newTagBuffer(TagMatrix)
+	for i := 0; i < 9; i++ {
+		buf.Write(encodeS15Fixed16BE(m.Matrix[i/3][i%3]))
+	}
+	if m.Offset != nil {
+		for i := 0; i < 3; i++ {
+			buf.Write(encodeS15Fixed16BE(m.Offset[i]))
+		}
+	}
+	return buf.Bytes(), nil
+}
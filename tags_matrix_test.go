@@ -128,3 +128,33 @@ func TestMatrixTag_Transform(t *testing.T) {
 		assert.ErrorContains(t, err, "matrix transform expects 3 inputs")
 	})
 }
+
+func TestMtxEncoder(t *testing.T) {
+	matrix := [3][3]float64{{0.4361, 0.3851, 0.1431}, {0.2225, 0.7169, 0.0606}, {0.0139, 0.0971, 0.7141}}
+	t.Run("Without Offset", func(t *testing.T) {
+		raw, err := mtxEncoder(&MatrixTag{Matrix: matrix})
+		require.NoError(t, err)
+		assert.Len(t, raw, 44)
+		val, err := mtxDecoder(raw)
+		require.NoError(t, err)
+		m := val.(*MatrixTag)
+		for i := 0; i < 3; i++ {
+			assert.InDeltaSlice(t, matrix[i][:], m.Matrix[i][:], 1.0/65536)
+		}
+		assert.Nil(t, m.Offset)
+	})
+	t.Run("With Offset", func(t *testing.T) {
+		raw, err := mtxEncoder(&MatrixTag{Matrix: matrix, Offset: &[3]float64{0.1, -0.2, 0.3}})
+		require.NoError(t, err)
+		assert.Len(t, raw, 56)
+		val, err := mtxDecoder(raw)
+		require.NoError(t, err)
+		m := val.(*MatrixTag)
+		require.NotNil(t, m.Offset)
+		assert.InDeltaSlice(t, []float64{0.1, -0.2, 0.3}, m.Offset[:], 1.0/65536)
+	})
+	t.Run("Wrong Type", func(t *testing.T) {
+		_, err := mtxEncoder(matrix)
+		assert.ErrorContains(t, err, "mtx encoder expects *MatrixTag")
+	})
+}
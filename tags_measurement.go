@@ -3,6 +3,7 @@ package iccarus
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 // MeasurementTag represents a measurement tag (TagMeasurement)
@@ -30,3 +31,17 @@ func measurementDecoder(raw []byte) (any, error) {
 		Illuminant: binary.BigEndian.Uint32(raw[32:36]),
 	}, nil
 }
+
+func measurementEncoder(value any) ([]byte, error) {
+	meas, ok := value.(*MeasurementTag)
+	if !ok {
+		return nil, fmt.Errorf("meas encoder expects *MeasurementTag (got %T)", value)
+	}
+	buf := newTagBuffer(TagMeasurement)
+	_ = binary.Write(buf, binary.BigEndian, meas.Observer)
+	buf.Write(encodeXYZNumber(meas.Backing))
+	_ = binary.Write(buf, binary.BigEndian, meas.Geometry)
+	buf.Write(encodeS15Fixed16BE(meas.Flare))
+	_ = binary.Write(buf, binary.BigEndian, meas.Illuminant)
+	return buf.Bytes(), nil
+}
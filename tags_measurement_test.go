@@ -36,3 +36,22 @@ func TestMeasurementDecoder(t *testing.T) {
 		assert.ErrorContains(t, err, "meas tag too short")
 	})
 }
+
+func TestMeasurementEncoder(t *testing.T) {
+	meas := &MeasurementTag{
+		Observer:   1,
+		Backing:    XYZNumber{X: 1.0, Y: 0.5, Z: 0.25},
+		Geometry:   2,
+		Flare:      0.125,
+		Illuminant: 3,
+	}
+	raw, err := measurementEncoder(meas)
+	assert.NoError(t, err)
+	assert.Len(t, raw, 36)
+	val, err := measurementDecoder(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, meas, val)
+
+	_, err = measurementEncoder(MeasurementTag{})
+	assert.ErrorContains(t, err, "meas encoder expects *MeasurementTag")
+}
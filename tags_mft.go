@@ -1,6 +1,7 @@
 package iccarus
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -19,6 +20,8 @@ type MFT2Tag struct {
 }
 
 var _ ChannelTransformer = (*MFT2Tag)(nil)
+var _ ToCIEXYZ = (*MFT2Tag)(nil)
+var _ FromCIEXYZ = (*MFT2Tag)(nil)
 
 // MFT1Tag represents a multi function table 1 tag (TagMultiFunctionTable1)
 type MFT1Tag struct {
@@ -32,6 +35,8 @@ type MFT1Tag struct {
 }
 
 var _ ChannelTransformer = (*MFT1Tag)(nil)
+var _ ToCIEXYZ = (*MFT1Tag)(nil)
+var _ FromCIEXYZ = (*MFT1Tag)(nil)
 
 func mft2Decoder(raw []byte) (any, error) {
 	if len(raw) < 52 {
@@ -197,6 +202,14 @@ func (tag *MFT2Tag) Transform(inputs ...float64) ([]float64, error) {
 	return out, nil
 }
 
+func (tag *MFT2Tag) ToCIEXYZ(channels ...float64) ([]float64, error) {
+	return tag.Transform(channels...)
+}
+
+func (tag *MFT2Tag) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	return tag.Transform(channels...)
+}
+
 func mft1Decoder(raw []byte) (any, error) {
 	if len(raw) < 48 {
 		return nil, errors.New("mft1 tag too short")
@@ -322,3 +335,109 @@ func (m *MFT1Tag) Transform(inputs ...float64) ([]float64, error) {
 	}
 	return final, nil
 }
+
+func (m *MFT1Tag) ToCIEXYZ(channels ...float64) ([]float64, error) {
+	return m.Transform(channels...)
+}
+
+func (m *MFT1Tag) FromCIEXYZ(channels ...float64) ([]float64, error) {
+	return m.Transform(channels...)
+}
+
+func mft2Encoder(value any) ([]byte, error) {
+	tag, ok := value.(*MFT2Tag)
+	if !ok {
+		return nil, fmt.Errorf("mft2 encoder expects *MFT2Tag (got %T)", value)
+	}
+	inputEntries, err := mftCurveEntries(tag.InputCurves, int(tag.InputChannels), "input")
+	if err != nil {
+		return nil, fmt.Errorf("mft2: %w", err)
+	}
+	outputEntries, err := mftCurveEntries(tag.OutputCurves, int(tag.OutputChannels), "output")
+	if err != nil {
+		return nil, fmt.Errorf("mft2: %w", err)
+	}
+	if inputEntries < 2 || inputEntries > 4096 || outputEntries < 2 || outputEntries > 4096 {
+		return nil, errors.New("mft2: curve entries must be 2..4096")
+	}
+	if err := mftCheckCLUT(tag.CLUT, tag.GridPoints, tag.InputChannels, tag.OutputChannels); err != nil {
+		return nil, fmt.Errorf("mft2: %w", err)
+	}
+	buf := mftHeader(TagMultiFunctionTable2, tag.InputChannels, tag.OutputChannels, tag.GridPoints, tag.Matrix)
+	_ = binary.Write(buf, binary.BigEndian, uint16(inputEntries))
+	_ = binary.Write(buf, binary.BigEndian, uint16(outputEntries))
+	for _, curve := range tag.InputCurves {
+		_ = binary.Write(buf, binary.BigEndian, curve)
+	}
+	for _, v := range tag.CLUT {
+		_ = binary.Write(buf, binary.BigEndian, encodeNormalizedUint16(v))
+	}
+	for _, curve := range tag.OutputCurves {
+		_ = binary.Write(buf, binary.BigEndian, curve)
+	}
+	return buf.Bytes(), nil
+}
+
+func mft1Encoder(value any) ([]byte, error) {
+	tag, ok := value.(*MFT1Tag)
+	if !ok {
+		return nil, fmt.Errorf("mft1 encoder expects *MFT1Tag (got %T)", value)
+	}
+	if entries, err := mftCurveEntries(tag.InputCurves, int(tag.InputChannels), "input"); err != nil {
+		return nil, fmt.Errorf("mft1: %w", err)
+	} else if entries != 256 {
+		return nil, fmt.Errorf("mft1: input curves must have 256 entries, got %d", entries)
+	}
+	if entries, err := mftCurveEntries(tag.OutputCurves, int(tag.OutputChannels), "output"); err != nil {
+		return nil, fmt.Errorf("mft1: %w", err)
+	} else if entries != 256 {
+		return nil, fmt.Errorf("mft1: output curves must have 256 entries, got %d", entries)
+	}
+	if err := mftCheckCLUT(tag.CLUT, tag.GridPoints, tag.InputChannels, tag.OutputChannels); err != nil {
+		return nil, fmt.Errorf("mft1: %w", err)
+	}
+	buf := mftHeader(TagMultiFunctionTable1, tag.InputChannels, tag.OutputChannels, tag.GridPoints, tag.Matrix)
+	for _, curve := range tag.InputCurves {
+		buf.Write(curve)
+	}
+	for _, v := range tag.CLUT {
+		buf.WriteByte(encodeNormalizedUint8(v))
+	}
+	for _, curve := range tag.OutputCurves {
+		buf.Write(curve)
+	}
+	return buf.Bytes(), nil
+}
+
+// mftHeader encodes the common mft1/mft2 header - channels, grid points, padding byte and matrix
+func mftHeader(signature TagName, inCh uint8, outCh uint8, gridPoints uint8, matrix [9]float64) *bytes.Buffer {
+	buf := newTagBuffer(signature)
+	buf.Write([]byte{inCh, outCh, gridPoints, 0})
+	for _, v := range matrix {
+		buf.Write(encodeS15Fixed16BE(v))
+	}
+	return buf
+}
+
+// mftCurveEntries checks the number of curves and returns the (common) number of entries per curve
+func mftCurveEntries[T uint8 | uint16](curves [][]T, channels int, kind string) (int, error) {
+	if len(curves) != channels {
+		return 0, fmt.Errorf("expected %d %s curves, got %d", channels, kind, len(curves))
+	}
+	entries := 0
+	for i, curve := range curves {
+		if i == 0 {
+			entries = len(curve)
+		} else if len(curve) != entries {
+			return 0, fmt.Errorf("%s curve %d has %d entries, expected %d", kind, i, len(curve), entries)
+		}
+	}
+	return entries, nil
+}
+
+func mftCheckCLUT(clut []float64, gridPoints uint8, inCh uint8, outCh uint8) error {
+	if expected := int(math.Pow(float64(gridPoints), float64(inCh))) * int(outCh); len(clut) != expected {
+		return fmt.Errorf("unexpected clut length: expected %d, got %d", expected, len(clut))
+	}
+	return nil
+}
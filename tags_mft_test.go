@@ -303,3 +303,109 @@ func TestMFT1Tag_Transform(t *testing.T) {
 		assert.ErrorContains(t, err, "CLUT index out of bounds")
 	})
 }
+
+func TestMFT2Encoder(t *testing.T) {
+	t.Run("Round Trip", func(t *testing.T) {
+		p := testProfile(t, "default/ISOcoated_v2_300_eci.icc")
+		for _, name := range []TagHeaderName{TagHeaderAToB0, TagHeaderBToA0} {
+			tag, ok := p.TagByHeader(name)
+			require.True(t, ok)
+			val, err := tag.Value()
+			require.NoError(t, err)
+			raw, err := mft2Encoder(val)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(tag.Raw, raw), "%s encoded bytes differ from original", name)
+		}
+	})
+	valid := func() *MFT2Tag {
+		return &MFT2Tag{
+			InputChannels:  1,
+			OutputChannels: 1,
+			GridPoints:     2,
+			Matrix:         [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+			InputCurves:    [][]uint16{{0, 65535}},
+			CLUT:           []float64{0.5, 1},
+			OutputCurves:   [][]uint16{{65535, 0}},
+		}
+	}
+	t.Run("Errors", func(t *testing.T) {
+		_, err := mft2Encoder(&MFT1Tag{})
+		assert.ErrorContains(t, err, "mft2 encoder expects *MFT2Tag")
+		tag := valid()
+		tag.InputCurves = nil
+		_, err = mft2Encoder(tag)
+		assert.ErrorContains(t, err, "mft2: expected 1 input curves, got 0")
+		tag = valid()
+		tag.OutputChannels = 2
+		_, err = mft2Encoder(tag)
+		assert.ErrorContains(t, err, "mft2: expected 2 output curves, got 1")
+		tag = valid()
+		tag.InputCurves = [][]uint16{{0}}
+		_, err = mft2Encoder(tag)
+		assert.ErrorContains(t, err, "mft2: curve entries must be 2..4096")
+		tag = valid()
+		tag.CLUT = []float64{0}
+		_, err = mft2Encoder(tag)
+		assert.ErrorContains(t, err, "mft2: unexpected clut length: expected 2, got 1")
+	})
+}
+
+func TestMFT1Encoder(t *testing.T) {
+	identity := make([]uint8, 256)
+	inverse := make([]uint8, 256)
+	for i := range identity {
+		identity[i] = uint8(i)
+		inverse[i] = uint8(255 - i)
+	}
+	valid := func() *MFT1Tag {
+		return &MFT1Tag{
+			InputChannels:  2,
+			OutputChannels: 1,
+			GridPoints:     2,
+			Matrix:         [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+			InputCurves:    [][]uint8{identity, inverse},
+			CLUT:           []float64{0, 0.2, 0.6, 1},
+			OutputCurves:   [][]uint8{identity},
+		}
+	}
+	t.Run("Round Trip", func(t *testing.T) {
+		tag := valid()
+		raw, err := mft1Encoder(tag)
+		require.NoError(t, err)
+		assert.Len(t, raw, 48+256*2+4+256)
+		val, err := mft1Decoder(raw)
+		require.NoError(t, err)
+		decoded := val.(*MFT1Tag)
+		assert.Equal(t, tag.InputChannels, decoded.InputChannels)
+		assert.Equal(t, tag.OutputChannels, decoded.OutputChannels)
+		assert.Equal(t, tag.GridPoints, decoded.GridPoints)
+		assert.Equal(t, tag.Matrix, decoded.Matrix)
+		assert.Equal(t, tag.InputCurves, decoded.InputCurves)
+		assert.InDeltaSlice(t, tag.CLUT, decoded.CLUT, 1.0/255)
+		assert.Equal(t, tag.OutputCurves, decoded.OutputCurves)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := mft1Encoder(&MFT2Tag{})
+		assert.ErrorContains(t, err, "mft1 encoder expects *MFT1Tag")
+		tag := valid()
+		tag.InputCurves = [][]uint8{identity, identity[:10]}
+		_, err = mft1Encoder(tag)
+		assert.ErrorContains(t, err, "mft1: input curve 1 has 10 entries, expected 256")
+		tag = valid()
+		tag.InputCurves = [][]uint8{identity[:10], identity[:10]}
+		_, err = mft1Encoder(tag)
+		assert.ErrorContains(t, err, "mft1: input curves must have 256 entries, got 10")
+		tag = valid()
+		tag.OutputCurves = nil
+		_, err = mft1Encoder(tag)
+		assert.ErrorContains(t, err, "mft1: expected 1 output curves, got 0")
+		tag = valid()
+		tag.OutputCurves = [][]uint8{identity[:2]}
+		_, err = mft1Encoder(tag)
+		assert.ErrorContains(t, err, "mft1: output curves must have 256 entries, got 2")
+		tag = valid()
+		tag.CLUT = nil
+		_, err = mft1Encoder(tag)
+		assert.ErrorContains(t, err, "mft1: unexpected clut length: expected 4, got 0")
+	})
+}
@@ -1,5 +1,10 @@
 package iccarus
 
+import (
+	"errors"
+	"fmt"
+)
+
 func dictDecoder(raw []byte) (any, error) {
 	// TODO: dictionary tag (ICC.1:2010-12), parse only if needed
 	return raw, nil
@@ -29,3 +34,15 @@ func msbnDecoder(raw []byte) (any, error) {
 	// TODO: Unknown vendor-specific tag (MSBN) – stubbed
 	return raw, nil
 }
+
+// rawEncoder encodes the stubbed tags (whose decoded values are the raw tag bytes)
+func rawEncoder(value any) ([]byte, error) {
+	raw, ok := value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw encoder expects []byte (got %T)", value)
+	}
+	if len(raw) < 8 {
+		return nil, errors.New("raw tag too short")
+	}
+	return raw, nil
+}
@@ -46,3 +46,15 @@ func TestMsbnDecoder(t *testing.T) {
 	require.IsType(t, []byte{}, result)
 	require.Len(t, result, 3)
 }
+
+func TestRawEncoder(t *testing.T) {
+	raw := []byte("dict\x00\x00\x00\x00foo")
+	result, err := rawEncoder(raw)
+	require.NoError(t, err)
+	require.Equal(t, raw, result)
+
+	_, err = rawEncoder([]byte("foo"))
+	require.ErrorContains(t, err, "raw tag too short")
+	_, err = rawEncoder("foo")
+	require.ErrorContains(t, err, "raw encoder expects []byte (got string)")
+}
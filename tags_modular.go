@@ -1,99 +1,211 @@
 package iccarus
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 )
 
 // ModularTag represents a modular tag (TagModularAB / TagModularBA)
+//
+// all five processing stages are optional and are applied in the spec order for the direction:
+//
+//	mAB (lutAtoBType): A curves -> CLUT -> M curves -> Matrix -> B curves
+//	mBA (lutBtoAType): B curves -> Matrix -> M curves -> CLUT -> A curves
 type ModularTag struct {
 	Signature      string
 	InputChannels  uint8
 	OutputChannels uint8
-	Elements       []*Tag
+	// BCurves is the B curves (one per PCS side channel)
+	BCurves CurveSet
+	// Matrix is the 3x3 matrix plus offsets (only present when the PCS side has 3 channels)
+	Matrix *MatrixTag
+	// MCurves is the M curves (one per PCS side channel, only present with Matrix)
+	MCurves CurveSet
+	// CLUT is the multi-dimensional color lookup table
+	CLUT *CLUTTag
+	// ACurves is the A curves (one per device side channel)
+	ACurves CurveSet
 }
 
 var _ ToCIEXYZ = (*ModularTag)(nil)
 var _ FromCIEXYZ = (*ModularTag)(nil)
 
+// CurveSet is a set of per-channel curves (each a *CurveTag or *ParametricCurveTag)
+type CurveSet []ChannelTransformer
+
+var _ ChannelTransformer = (CurveSet)(nil)
+
+// Transform applies each curve to its corresponding channel
+func (cs CurveSet) Transform(inputs ...float64) ([]float64, error) {
+	if len(inputs) != len(cs) {
+		return nil, fmt.Errorf("curve set expects %d inputs, got %d", len(cs), len(inputs))
+	}
+	result := make([]float64, len(inputs))
+	for i, curve := range cs {
+		v, err := curve.Transform(clamp01(inputs[i]))
+		if err != nil {
+			return nil, fmt.Errorf("curve %d: %w", i, err)
+		}
+		result[i] = v[0]
+	}
+	return result, nil
+}
+
 func modularDecoder(raw []byte) (any, error) {
-	if len(raw) < 12 {
+	const headerLength = 32 // sig, reserved, channels, padding + 5 offsets
+	if len(raw) < headerLength {
 		return nil, errors.New("modular (mAB/mBA) tag too short")
 	}
-	inputCh := int(binary.BigEndian.Uint16(raw[8:10]))
-	outputCh := int(binary.BigEndian.Uint16(raw[10:12]))
-	offset := 12
-	offsets := []int{}
-	// decide if there's an offset table or not
-	if offset+4 <= len(raw) {
-		first := binary.BigEndian.Uint32(raw[offset : offset+4])
-		// check if the "first offset" is actually an offset? Or just start of element?
-		if first < uint32(len(raw)) && !isASCII(raw[offset:offset+4]) {
-			// it's probably a real offset table
-			for offset+4 <= len(raw) {
-				elemOffset := int(binary.BigEndian.Uint32(raw[offset : offset+4]))
-				if elemOffset == 0 || (len(offsets) > 0 && elemOffset <= offsets[len(offsets)-1]) {
-					break
-				}
-				if elemOffset >= len(raw) {
-					break
-				}
-				offsets = append(offsets, elemOffset)
-				offset += 4
-			}
+	result := &ModularTag{
+		Signature:      stringed(raw[:4]),
+		InputChannels:  raw[8],
+		OutputChannels: raw[9],
+	}
+	inputCh, outputCh := int(raw[8]), int(raw[9])
+	// the B curves are always on the PCS side, the A curves on the device side...
+	bChannels, aChannels := outputCh, inputCh
+	if result.Signature == TagModularBA {
+		bChannels, aChannels = inputCh, outputCh
+	}
+	offsetB := int(binary.BigEndian.Uint32(raw[12:16]))
+	offsetMatrix := int(binary.BigEndian.Uint32(raw[16:20]))
+	offsetM := int(binary.BigEndian.Uint32(raw[20:24]))
+	offsetCLUT := int(binary.BigEndian.Uint32(raw[24:28]))
+	offsetA := int(binary.BigEndian.Uint32(raw[28:32]))
+	var err error
+	if offsetB != 0 {
+		if result.BCurves, err = decodeCurveSet(raw, offsetB, bChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) B curves: %w", err)
 		}
 	}
-	if len(offsets) == 0 {
-		// fallback - assume single element begins directly after header
-		offsets = append(offsets, offset)
+	if offsetMatrix != 0 {
+		if bChannels != 3 {
+			return nil, fmt.Errorf("modular (mAB/mBA) matrix requires 3 channels, got %d", bChannels)
+		}
+		if result.Matrix, err = decodeModularMatrix(raw, offsetMatrix); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) matrix: %w", err)
+		}
 	}
-	elements := make([]*Tag, 0, len(offsets))
-	for i, elemStart := range offsets {
-		elemEnd := len(raw)
-		if i+1 < len(offsets) {
-			elemEnd = offsets[i+1]
+	if offsetM != 0 {
+		if result.MCurves, err = decodeCurveSet(raw, offsetM, bChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) M curves: %w", err)
 		}
-		if elemEnd > len(raw) {
-			return nil, fmt.Errorf("modular (mAB/mBA): element %d end offset 0x%X out of bounds", i, elemEnd)
+	}
+	if offsetCLUT != 0 {
+		if result.CLUT, err = decodeModularCLUT(raw, offsetCLUT, inputCh, outputCh); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) CLUT: %w", err)
 		}
-		elemBlock := raw[elemStart:elemEnd]
-		if len(elemBlock) < 8 {
-			return nil, fmt.Errorf("modular (mAB/mBA): element %d too short to contain header", i)
+	}
+	if offsetA != 0 {
+		if result.ACurves, err = decodeCurveSet(raw, offsetA, aChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) A curves: %w", err)
 		}
-		tagSig := string(elemBlock[0:4])
-		elements = append(elements, decodeEmbeddedTag(tagSig, elemBlock))
 	}
-	return &ModularTag{
-		Signature:      stringed(raw[:4]),
-		InputChannels:  uint8(inputCh),
-		OutputChannels: uint8(outputCh),
-		Elements:       elements,
-	}, nil
+	return result, nil
 }
 
-func isASCII(b []byte) bool {
-	for _, ch := range b[:4] {
-		if ch < 32 || ch > 126 {
-			return false
+// decodeCurveSet decodes a sequence of curves (curv or para) - each padded to a 4-byte boundary
+func decodeCurveSet(raw []byte, offset int, count int) (CurveSet, error) {
+	result := make(CurveSet, 0, count)
+	for i := 0; i < count; i++ {
+		if offset+12 > len(raw) {
+			return nil, fmt.Errorf("curve %d offset 0x%X out of bounds", i, offset)
+		}
+		sig := stringed(raw[offset : offset+4])
+		var size int
+		switch sig {
+		case TagCurve:
+			size = 12 + int(binary.BigEndian.Uint32(raw[offset+8:offset+12]))*2
+		case TagParametricCurve:
+			params, ok := parametricParameterCount(ParametricCurveFunction(binary.BigEndian.Uint16(raw[offset+8 : offset+10])))
+			if !ok {
+				return nil, fmt.Errorf("curve %d unknown parametric function type", i)
+			}
+			size = 12 + params*4
+		default:
+			return nil, fmt.Errorf("curve %d has unexpected type %q", i, sig)
+		}
+		if offset+size > len(raw) {
+			return nil, fmt.Errorf("curve %d truncated", i)
+		}
+		decoder := curveDecoder
+		if sig == TagParametricCurve {
+			decoder = parametricCurveDecoder
+		}
+		val, err := decoder(raw[offset : offset+size])
+		if err != nil {
+			return nil, fmt.Errorf("curve %d: %w", i, err)
+		}
+		result = append(result, val.(ChannelTransformer))
+		offset += size
+		if pad := offset % 4; pad != 0 {
+			offset += 4 - pad
 		}
 	}
-	return true
+	return result, nil
 }
 
-func decodeEmbeddedTag(tagSig string, raw []byte) *Tag {
-	result := &Tag{
-		Name:    tagSig,
-		Raw:     raw,
-		decoder: defaultDecoders[tagSig],
+// decodeModularMatrix decodes the 12 element (3x3 + 3 offsets) matrix
+func decodeModularMatrix(raw []byte, offset int) (*MatrixTag, error) {
+	if offset+48 > len(raw) {
+		return nil, fmt.Errorf("offset 0x%X out of bounds", offset)
 	}
-	if result.decoder == nil {
-		result.Name = string(raw[:4])
-		result.error = fmt.Errorf("unknown embedded tag type: %q", tagSig)
-	} else {
-		result.value, result.error = result.decoder(raw)
+	body := raw[offset : offset+48]
+	result := &MatrixTag{Offset: &[3]float64{}}
+	for i := 0; i < 9; i++ {
+		result.Matrix[i/3][i%3] = readS15Fixed16BE(body[i*4 : (i+1)*4])
 	}
-	return result
+	for i := 0; i < 3; i++ {
+		result.Offset[i] = readS15Fixed16BE(body[36+i*4 : 36+(i+1)*4])
+	}
+	return result, nil
+}
+
+// decodeModularCLUT decodes the CLUT - 16 grid point bytes, precision byte, 3 padding bytes and then the table
+func decodeModularCLUT(raw []byte, offset int, inputCh int, outputCh int) (*CLUTTag, error) {
+	if inputCh > 16 {
+		return nil, fmt.Errorf("too many input channels (%d)", inputCh)
+	}
+	if offset+20 > len(raw) {
+		return nil, fmt.Errorf("offset 0x%X out of bounds", offset)
+	}
+	gridPoints := make([]uint8, inputCh)
+	copy(gridPoints, raw[offset:offset+inputCh])
+	precision := raw[offset+16]
+	if precision != 1 && precision != 2 {
+		return nil, fmt.Errorf("invalid precision %d", precision)
+	}
+	body := raw[offset+20:]
+	// (the grid product is checked against the available values as it is computed - so it cannot overflow)
+	available := len(body) / int(precision)
+	count := outputCh
+	for _, g := range gridPoints {
+		if g != 0 && count > available/int(g) {
+			return nil, fmt.Errorf("table truncated: grid points %v x %d output channels exceeds %d bytes", gridPoints, outputCh, len(body))
+		}
+		count *= int(g)
+	}
+	if count > available {
+		return nil, fmt.Errorf("table truncated: expected %d bytes, got %d", count*int(precision), len(body))
+	}
+	values := make([]float64, count)
+	for i := 0; i < count; i++ {
+		if precision == 1 {
+			values[i] = float64(body[i]) / 255.0
+		} else {
+			values[i] = float64(binary.BigEndian.Uint16(body[i*2:i*2+2])) / 65535.0
+		}
+	}
+	return &CLUTTag{
+		GridPoints:     gridPoints,
+		InputChannels:  uint8(inputCh),
+		OutputChannels: uint8(outputCh),
+		Precision:      precision,
+		Values:         values,
+		expectedValues: count,
+	}, nil
 }
 
 func (m *ModularTag) ToCIEXYZ(channels ...float64) ([]float64, error) {
@@ -104,30 +216,194 @@ func (m *ModularTag) FromCIEXYZ(channels ...float64) ([]float64, error) {
 	return m.transformChannels(channels)
 }
 
+type modularStage struct {
+	name        string
+	transformer ChannelTransformer
+}
+
+// stages returns the present processing stages in the spec order for the direction
+func (m *ModularTag) stages() []modularStage {
+	var matrix ChannelTransformer
+	if m.Matrix != nil {
+		matrix = m.Matrix
+	}
+	var clut ChannelTransformer
+	if m.CLUT != nil {
+		clut = m.CLUT
+	}
+	ordered := []modularStage{
+		{name: "A curves", transformer: m.ACurves},
+		{name: "CLUT", transformer: clut},
+		{name: "M curves", transformer: m.MCurves},
+		{name: "matrix", transformer: matrix},
+		{name: "B curves", transformer: m.BCurves},
+	}
+	if m.Signature == TagModularBA {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+	result := make([]modularStage, 0, len(ordered))
+	for _, stage := range ordered {
+		if cs, ok := stage.transformer.(CurveSet); ok && len(cs) == 0 {
+			continue
+		}
+		if stage.transformer != nil {
+			result = append(result, stage)
+		}
+	}
+	return result
+}
+
 func (m *ModularTag) transformChannels(channels []float64) ([]float64, error) {
 	if len(channels) != int(m.InputChannels) {
 		return nil, fmt.Errorf("expected %d input channels, got %d", m.InputChannels, len(channels))
 	}
+	stages := m.stages()
+	if len(stages) == 0 {
+		return nil, errors.New("modular tag has no transformable elements")
+	}
 	result := channels
-	applied := false
-	for _, element := range m.Elements {
-		switch element.Name {
-		case TagCurve, TagParametricCurve, TagMatrix, TagColorLookupTable:
-			val, err := element.Value()
-			if err != nil {
-				return nil, fmt.Errorf("failed decoding modular element %q: %w", element.Name, err)
-			}
-			if proc, ok := val.(ChannelTransformer); ok {
-				result, err = proc.Transform(result...)
-				if err != nil {
-					return nil, fmt.Errorf("failed processing modular element %q: %w", element.Name, err)
-				}
-				applied = true
-			}
+	for _, stage := range stages {
+		var err error
+		if result, err = stage.transformer.Transform(result...); err != nil {
+			return nil, fmt.Errorf("failed processing modular %s: %w", stage.name, err)
 		}
 	}
-	if !applied {
-		return nil, errors.New("modular tag has no transformable elements")
+	return result, nil
+}
+
+func modularEncoder(value any) ([]byte, error) {
+	m, ok := value.(*ModularTag)
+	if !ok {
+		return nil, fmt.Errorf("modular (mAB/mBA) encoder expects *ModularTag (got %T)", value)
+	}
+	if m.Signature != TagModularAB && m.Signature != TagModularBA {
+		return nil, fmt.Errorf("modular (mAB/mBA) unexpected signature %q", m.Signature)
+	}
+	inputCh, outputCh := int(m.InputChannels), int(m.OutputChannels)
+	bChannels, aChannels := outputCh, inputCh
+	if m.Signature == TagModularBA {
+		bChannels, aChannels = inputCh, outputCh
+	}
+	buf := newTagBuffer(m.Signature)
+	buf.Write([]byte{m.InputChannels, m.OutputChannels, 0, 0})
+	buf.Write(make([]byte, 20)) // offsets - filled in once the elements are written
+	var offsets [5]uint32
+	setOffset := func(i int) {
+		offsets[i] = uint32(buf.Len())
+	}
+	if m.BCurves != nil {
+		setOffset(0)
+		if err := encodeCurveSet(buf, m.BCurves, bChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) B curves: %w", err)
+		}
+	}
+	if m.Matrix != nil {
+		if bChannels != 3 {
+			return nil, fmt.Errorf("modular (mAB/mBA) matrix requires 3 channels, got %d", bChannels)
+		}
+		setOffset(1)
+		encodeModularMatrix(buf, m.Matrix)
+	}
+	if m.MCurves != nil {
+		setOffset(2)
+		if err := encodeCurveSet(buf, m.MCurves, bChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) M curves: %w", err)
+		}
+	}
+	if m.CLUT != nil {
+		setOffset(3)
+		if err := encodeModularCLUT(buf, m.CLUT, inputCh, outputCh); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) CLUT: %w", err)
+		}
+	}
+	if m.ACurves != nil {
+		setOffset(4)
+		if err := encodeCurveSet(buf, m.ACurves, aChannels); err != nil {
+			return nil, fmt.Errorf("modular (mAB/mBA) A curves: %w", err)
+		}
+	}
+	result := buf.Bytes()
+	for i, offset := range offsets {
+		binary.BigEndian.PutUint32(result[12+i*4:], offset)
 	}
 	return result, nil
 }
+
+// encodeCurveSet encodes a sequence of curves (curv or para) - each padded to a 4-byte boundary
+func encodeCurveSet(buf *bytes.Buffer, curves CurveSet, count int) error {
+	if len(curves) != count {
+		return fmt.Errorf("expected %d curves, got %d", count, len(curves))
+	}
+	for i, curve := range curves {
+		var data []byte
+		var err error
+		switch c := curve.(type) {
+		case *CurveTag:
+			data, err = curveEncoder(c)
+		case *ParametricCurveTag:
+			data, err = parametricCurveEncoder(c)
+		default:
+			err = fmt.Errorf("unexpected type %T", curve)
+		}
+		if err != nil {
+			return fmt.Errorf("curve %d: %w", i, err)
+		}
+		buf.Write(data)
+		padTo4(buf)
+	}
+	return nil
+}
+
+// encodeModularMatrix encodes the 12 element (3x3 + 3 offsets) matrix
+func encodeModularMatrix(buf *bytes.Buffer, matrix *MatrixTag) {
+	for i := 0; i < 9; i++ {
+		buf.Write(encodeS15Fixed16BE(matrix.Matrix[i/3][i%3]))
+	}
+	for i := 0; i < 3; i++ {
+		var offset float64
+		if matrix.Offset != nil {
+			offset = matrix.Offset[i]
+		}
+		buf.Write(encodeS15Fixed16BE(offset))
+	}
+}
+
+// encodeModularCLUT encodes the CLUT - 16 grid point bytes, precision byte, 3 padding bytes and then the table
+func encodeModularCLUT(buf *bytes.Buffer, clut *CLUTTag, inputCh int, outputCh int) error {
+	if inputCh > 16 {
+		return fmt.Errorf("too many input channels (%d)", inputCh)
+	}
+	if len(clut.GridPoints) != inputCh {
+		return fmt.Errorf("grid points mismatch: expected %d, got %d", inputCh, len(clut.GridPoints))
+	}
+	precision := clut.Precision
+	if precision == 0 {
+		precision = 2
+	}
+	if precision != 1 && precision != 2 {
+		return fmt.Errorf("invalid precision %d", precision)
+	}
+	if expected := expectedValues(clut.GridPoints, outputCh); len(clut.Values) != expected {
+		return fmt.Errorf("unexpected values length: expected %d, got %d", expected, len(clut.Values))
+	}
+	var gridPoints [16]byte
+	copy(gridPoints[:], clut.GridPoints)
+	buf.Write(gridPoints[:])
+	buf.Write([]byte{precision, 0, 0, 0})
+	for _, v := range clut.Values {
+		if precision == 1 {
+			buf.WriteByte(encodeNormalizedUint8(v))
+		} else {
+			_ = binary.Write(buf, binary.BigEndian, encodeNormalizedUint16(v))
+		}
+	}
+	padTo4(buf)
+	return nil
+}
+
+// padTo4 pads the buffer to a 4-byte boundary
+func padTo4(buf *bytes.Buffer) {
+	buf.Write(make([]byte, align4(buf.Len())-buf.Len()))
+}
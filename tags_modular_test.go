@@ -9,97 +9,384 @@ import (
 	"testing"
 )
 
-func TestModularDecoder(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
-		var buf bytes.Buffer
-		buf.WriteString("mAB ")       // Name (4 bytes)
-		buf.Write([]byte{0, 0, 0, 0}) // Reserved (4 bytes)
-		// Input channels = 2, Output channels = 3
-		_ = binary.Write(&buf, binary.BigEndian, uint16(2)) // input
-		_ = binary.Write(&buf, binary.BigEndian, uint16(3)) // output
-		// Fake offset table (one element starting at byte 20)
-		_ = binary.Write(&buf, binary.BigEndian, uint32(20))
-		// Fill with dummy bytes to reach offset 20
-		for buf.Len() < 20 {
-			buf.WriteByte(0)
+// testModularTag builds a raw mAB/mBA tag - any nil stage is omitted (zero offset)
+func testModularTag(sig string, in, out uint8, bCurves, matrix, mCurves, clut, aCurves []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(sig)
+	buf.Write([]byte{0, 0, 0, 0}) // reserved
+	buf.Write([]byte{in, out, 0, 0})
+	offsets := make([]byte, 20)
+	buf.Write(offsets)
+	for i, stage := range [][]byte{bCurves, matrix, mCurves, clut, aCurves} {
+		if stage != nil {
+			binary.BigEndian.PutUint32(offsets[i*4:], uint32(buf.Len()))
+			buf.Write(stage)
+			for buf.Len()%4 != 0 {
+				buf.WriteByte(0)
+			}
 		}
-		// Embedded tag block: "curv" + reserved + data
+	}
+	raw := buf.Bytes()
+	copy(raw[12:32], offsets)
+	return raw
+}
+
+func testGammaCurve(gamma float64) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("curv")
+	buf.Write([]byte{0, 0, 0, 0})
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(gamma*256))
+	buf.Write([]byte{0, 0}) // pad to 4-byte boundary
+	return buf.Bytes()
+}
+
+func testIdentityCurves(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
 		buf.WriteString("curv")
-		buf.Write([]byte{0, 0, 0, 0}) // Reserved
-		buf.Write([]byte{0, 0, 0, 1}) // One curve point (1)
-		val, err := modularDecoder(buf.Bytes())
+		buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0})
+	}
+	return buf.Bytes()
+}
+
+func testModularMatrix(values ...float64) []byte {
+	var buf bytes.Buffer
+	for _, v := range values {
+		buf.Write(encodeS15Fixed16BE(v))
+	}
+	return buf.Bytes()
+}
+
+func testModularCLUT(precision uint8, gridPoints []uint8, values []uint16) []byte {
+	var buf bytes.Buffer
+	gp := make([]byte, 16)
+	copy(gp, gridPoints)
+	buf.Write(gp)
+	buf.Write([]byte{precision, 0, 0, 0})
+	for _, v := range values {
+		if precision == 1 {
+			buf.WriteByte(uint8(v))
+		} else {
+			_ = binary.Write(&buf, binary.BigEndian, v)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestModularDecoder(t *testing.T) {
+	t.Run("Success mAB", func(t *testing.T) {
+		var para bytes.Buffer
+		para.WriteString("para")
+		para.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}) // reserved, function 0, reserved
+		para.Write(encodeS15Fixed16BE(2.0))
+		bCurves := append(append(testGammaCurve(1.0), para.Bytes()...), testIdentityCurves(1)...)
+		matrix := testModularMatrix(1, 0, 0, 0, 1, 0, 0, 0, 1, 0.1, 0.2, 0.3)
+		clut := testModularCLUT(1, []uint8{2}, []uint16{0, 0, 0, 255, 255, 255})
+		raw := testModularTag("mAB ", 1, 3, bCurves, matrix, testIdentityCurves(3), clut, testIdentityCurves(1))
+		val, err := modularDecoder(raw)
 		require.NoError(t, err)
 		require.IsType(t, &ModularTag{}, val)
 		tag := val.(*ModularTag)
 		assert.Equal(t, "mAB", tag.Signature)
-		assert.Equal(t, uint8(2), tag.InputChannels)
+		assert.Equal(t, uint8(1), tag.InputChannels)
 		assert.Equal(t, uint8(3), tag.OutputChannels)
-		assert.Len(t, tag.Elements, 1)
-	})
-	t.Run("HappyPathSingleElementNoOffsets", func(t *testing.T) {
-		var buf bytes.Buffer
-		buf.WriteString("mBA ")                             // Name (4 bytes)
-		buf.Write([]byte{0, 0, 0, 0})                       // Reserved
-		_ = binary.Write(&buf, binary.BigEndian, uint16(1)) // input
-		_ = binary.Write(&buf, binary.BigEndian, uint16(1)) // output
-		// No offset table, straight into embedded tag
-		buf.WriteString("curv")
-		buf.Write([]byte{0, 0, 0, 0})
-		buf.Write([]byte{0, 0, 0, 1})
-		val, err := modularDecoder(buf.Bytes())
+		require.Len(t, tag.BCurves, 3)
+		assert.IsType(t, &CurveTag{}, tag.BCurves[0])
+		assert.IsType(t, &ParametricCurveTag{}, tag.BCurves[1])
+		assert.IsType(t, &CurveTag{}, tag.BCurves[2])
+		require.NotNil(t, tag.Matrix)
+		assert.Equal(t, [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, tag.Matrix.Matrix)
+		require.NotNil(t, tag.Matrix.Offset)
+		assert.InDelta(t, 0.3, tag.Matrix.Offset[2], 0.0001)
+		assert.Len(t, tag.MCurves, 3)
+		require.NotNil(t, tag.CLUT)
+		assert.Equal(t, uint8(1), tag.CLUT.Precision)
+		assert.Equal(t, []uint8{2}, tag.CLUT.GridPoints)
+		assert.Equal(t, uint8(1), tag.CLUT.InputChannels)
+		assert.Equal(t, uint8(3), tag.CLUT.OutputChannels)
+		assert.Equal(t, []float64{0, 0, 0, 1, 1, 1}, tag.CLUT.Values)
+		assert.Len(t, tag.ACurves, 1)
+		out, err := tag.ToCIEXYZ(0.5)
+		require.NoError(t, err)
+		require.Len(t, out, 3)
+		assert.InDelta(t, 0.6, out[0], 0.001)     // gamma 1.0 of (0.5 + 0.1)
+		assert.InDelta(t, 0.7*0.7, out[1], 0.001) // gamma 2.0 of (0.5 + 0.2)
+		assert.InDelta(t, 0.8, out[2], 0.001)     // identity of (0.5 + 0.3)
+	})
+	t.Run("Success mBA", func(t *testing.T) {
+		clut := testModularCLUT(2, []uint8{2, 2, 2}, []uint16{
+			0, 0, 0, 0, 0, 0, 0, 65535, // 8 grid points x 1 output
+		})
+		raw := testModularTag("mBA ", 3, 1, testIdentityCurves(3), nil, nil, clut, testIdentityCurves(1))
+		val, err := modularDecoder(raw)
 		require.NoError(t, err)
-		require.IsType(t, &ModularTag{}, val)
 		tag := val.(*ModularTag)
 		assert.Equal(t, "mBA", tag.Signature)
-		assert.Equal(t, uint8(1), tag.InputChannels)
-		assert.Equal(t, uint8(1), tag.OutputChannels)
-		assert.Len(t, tag.Elements, 1)
+		assert.Len(t, tag.BCurves, 3)
+		assert.Nil(t, tag.Matrix)
+		assert.Nil(t, tag.MCurves)
+		require.NotNil(t, tag.CLUT)
+		assert.Equal(t, uint8(2), tag.CLUT.Precision)
+		assert.Equal(t, uint8(3), tag.CLUT.InputChannels)
+		assert.Equal(t, uint8(1), tag.CLUT.OutputChannels)
+		assert.Len(t, tag.ACurves, 1)
+		out, err := tag.FromCIEXYZ(1, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, out, 1)
+		assert.InDelta(t, 1.0, out[0], 0.0001)
+		out, err = tag.FromCIEXYZ(0.5, 1, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, out[0], 0.0001)
 	})
 	t.Run("TooShort", func(t *testing.T) {
 		data := []byte{1, 2, 3}
 		_, err := modularDecoder(data)
 		assert.ErrorContains(t, err, "modular (mAB/mBA) tag too short")
 	})
-	t.Run("ElementTooShort", func(t *testing.T) {
-		var buf bytes.Buffer
-		buf.WriteString("mAB ")
-		buf.Write([]byte{0, 0, 0, 0})
-		_ = binary.Write(&buf, binary.BigEndian, uint16(1))
-		_ = binary.Write(&buf, binary.BigEndian, uint16(1))
-		// No offset table, but embedded tag is less than 8 bytes
-		buf.Write([]byte("bad"))
-		_, err := modularDecoder(buf.Bytes())
-		assert.ErrorContains(t, err, "modular (mAB/mBA): element 0 too short to contain header")
+	t.Run("B curves out of bounds", func(t *testing.T) {
+		raw := testModularTag("mAB ", 3, 3, testIdentityCurves(2), nil, nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) B curves: curve 2 offset")
+	})
+	t.Run("B curves unexpected type", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, []byte("XYZ \x00\x00\x00\x00\x00\x00\x00\x00"), nil, nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, `curve 0 has unexpected type "XYZ"`)
+	})
+	t.Run("B curves truncated", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, []byte("curv\x00\x00\x00\x00\x00\x00\x00\x10"), nil, nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "curve 0 truncated")
+	})
+	t.Run("B curves unknown parametric", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, []byte("para\x00\x00\x00\x00\x00\x09\x00\x00"), nil, nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "curve 0 unknown parametric function type")
+	})
+	t.Run("Matrix requires 3 channels", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, nil, testModularMatrix(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0), nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "matrix requires 3 channels, got 1")
+	})
+	t.Run("Matrix out of bounds", func(t *testing.T) {
+		raw := testModularTag("mAB ", 3, 3, nil, testModularMatrix(1, 0, 0), nil, nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) matrix: offset")
+	})
+	t.Run("M curves fail", func(t *testing.T) {
+		raw := testModularTag("mAB ", 3, 3, nil, nil, testIdentityCurves(1), nil, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) M curves")
+	})
+	t.Run("A curves fail", func(t *testing.T) {
+		raw := testModularTag("mBA ", 3, 2, nil, nil, nil, nil, testIdentityCurves(1))
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) A curves")
+	})
+	t.Run("CLUT invalid precision", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, nil, nil, nil, testModularCLUT(3, []uint8{2}, nil), nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: invalid precision 3")
+	})
+	t.Run("CLUT truncated", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, nil, nil, nil, testModularCLUT(2, []uint8{2}, nil), nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: table truncated")
+	})
+	t.Run("CLUT grid overflow", func(t *testing.T) {
+		gridPoints := bytes.Repeat([]byte{255}, 16)
+		raw := testModularTag("mAB ", 16, 15, nil, nil, nil, testModularCLUT(2, gridPoints, nil), nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: table truncated")
+		_, err = decodeModularCLUT(testModularCLUT(1, gridPoints, nil), 0, 16, 15)
+		assert.ErrorContains(t, err, "table truncated")
+	})
+	t.Run("CLUT out of bounds", func(t *testing.T) {
+		raw := testModularTag("mAB ", 1, 1, nil, nil, nil, []byte{2, 0, 0, 0}, nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: offset")
+	})
+	t.Run("CLUT too many inputs", func(t *testing.T) {
+		raw := testModularTag("mAB ", 17, 1, nil, nil, nil, testModularCLUT(2, nil, nil), nil)
+		_, err := modularDecoder(raw)
+		assert.ErrorContains(t, err, "too many input channels (17)")
 	})
 }
 
-func TestIsASCII(t *testing.T) {
-	assert.True(t, isASCII([]byte("foob`")))
-	assert.False(t, isASCII([]byte{0, 0, 0, 0}))
+func TestModularEncoder(t *testing.T) {
+	var para bytes.Buffer
+	para.WriteString("para")
+	para.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}) // reserved, function 0, reserved
+	para.Write(encodeS15Fixed16BE(2.0))
+	bCurves := append(append(testGammaCurve(1.0), para.Bytes()...), testIdentityCurves(1)...)
+	matrix := testModularMatrix(1, 0, 0, 0, 1, 0, 0, 0, 1, 0.1, 0.2, 0.3)
+	testCases := map[string][]byte{
+		"mAB": testModularTag("mAB ", 1, 3, bCurves, matrix, testIdentityCurves(3),
+			testModularCLUT(1, []uint8{2}, []uint16{0, 0, 0, 255, 255, 255}), testIdentityCurves(1)),
+		"mBA": testModularTag("mBA ", 3, 1, testIdentityCurves(3), nil, nil,
+			testModularCLUT(2, []uint8{2, 2, 2}, []uint16{0, 0, 0, 0, 0, 0, 0, 65535}), testIdentityCurves(1)),
+		"B curves only": testModularTag("mAB ", 3, 3, testIdentityCurves(3), nil, nil, nil, nil),
+	}
+	for name, raw := range testCases {
+		t.Run(name, func(t *testing.T) {
+			val, err := modularDecoder(raw)
+			require.NoError(t, err)
+			encoded, err := modularEncoder(val)
+			require.NoError(t, err)
+			assert.Equal(t, raw, encoded)
+		})
+	}
+	t.Run("Default Precision", func(t *testing.T) {
+		tag := &ModularTag{
+			Signature:      TagModularAB,
+			InputChannels:  1,
+			OutputChannels: 1,
+			BCurves:        CurveSet{&CurveTag{}},
+			CLUT:           &CLUTTag{GridPoints: []uint8{2}, Values: []float64{0, 1}},
+		}
+		raw, err := modularEncoder(tag)
+		require.NoError(t, err)
+		val, err := modularDecoder(raw)
+		require.NoError(t, err)
+		decoded := val.(*ModularTag)
+		require.NotNil(t, decoded.CLUT)
+		assert.Equal(t, uint8(2), decoded.CLUT.Precision)
+		assert.Equal(t, []float64{0, 1}, decoded.CLUT.Values)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		valid := func() *ModularTag {
+			return &ModularTag{
+				Signature:      TagModularAB,
+				InputChannels:  1,
+				OutputChannels: 3,
+				BCurves:        CurveSet{&CurveTag{}, &CurveTag{}, &CurveTag{}},
+				Matrix:         &MatrixTag{},
+				MCurves:        CurveSet{&CurveTag{}, &CurveTag{}, &CurveTag{}},
+				CLUT:           &CLUTTag{GridPoints: []uint8{2}, Precision: 2, Values: make([]float64, 6)},
+				ACurves:        CurveSet{&CurveTag{}},
+			}
+		}
+		_, err := modularEncoder(valid())
+		require.NoError(t, err)
+		_, err = modularEncoder(&MatrixTag{})
+		assert.ErrorContains(t, err, "modular (mAB/mBA) encoder expects *ModularTag")
+		tag := valid()
+		tag.Signature = "mft2"
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, `modular (mAB/mBA) unexpected signature "mft2"`)
+		tag = valid()
+		tag.BCurves = tag.BCurves[:2]
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) B curves: expected 3 curves, got 2")
+		tag = valid()
+		tag.BCurves[1] = &mockTransformer{}
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) B curves: curve 1: unexpected type *iccarus.mockTransformer")
+		tag = valid()
+		tag.BCurves[2] = &ParametricCurveTag{FunctionType: 9}
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) B curves: curve 2: unknown parametric function type: 9")
+		tag = valid()
+		tag.OutputChannels = 1
+		tag.BCurves = tag.BCurves[:1]
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) matrix requires 3 channels, got 1")
+		tag = valid()
+		tag.MCurves = CurveSet{}
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) M curves: expected 3 curves, got 0")
+		tag = valid()
+		tag.ACurves = CurveSet{}
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) A curves: expected 1 curves, got 0")
+		tag = valid()
+		tag.InputChannels = 17
+		tag.ACurves = nil
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: too many input channels (17)")
+		tag = valid()
+		tag.CLUT.GridPoints = []uint8{2, 2}
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: grid points mismatch: expected 1, got 2")
+		tag = valid()
+		tag.CLUT.Precision = 3
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: invalid precision 3")
+		tag = valid()
+		tag.CLUT.Values = nil
+		_, err = modularEncoder(tag)
+		assert.ErrorContains(t, err, "modular (mAB/mBA) CLUT: unexpected values length: expected 6, got 0")
+	})
 }
 
-func TestModularTag_transformChannels(t *testing.T) {
+func TestCurveSet_Transform(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
+		cs := CurveSet{
+			&CurveTag{Type: CurveTypeIdentity},
+			&CurveTag{Type: CurveTypeGamma, Gamma: 2.0},
+		}
+		out, err := cs.Transform(0.5, 0.5)
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.5, 0.25}, out)
+		// inputs are clamped...
+		out, err = cs.Transform(1.5, -1)
+		require.NoError(t, err)
+		assert.Equal(t, []float64{1, 0}, out)
+	})
+	t.Run("WrongChannelCount", func(t *testing.T) {
+		cs := CurveSet{&CurveTag{Type: CurveTypeIdentity}}
+		_, err := cs.Transform(0.5, 0.5)
+		assert.ErrorContains(t, err, "curve set expects 1 inputs, got 2")
+	})
+	t.Run("CurveFails", func(t *testing.T) {
+		cs := CurveSet{&mockFailTransformer{}}
+		_, err := cs.Transform(0.5)
+		assert.ErrorContains(t, err, "curve 0: mock transform failure")
+	})
+}
+
+func TestModularTag_transformChannels(t *testing.T) {
+	t.Run("Order mAB", func(t *testing.T) {
+		mod := &ModularTag{
+			Signature:      TagModularAB,
+			InputChannels:  3,
+			OutputChannels: 3,
+			ACurves:        CurveSet{&CurveTag{Type: CurveTypeGamma, Gamma: 2}, &CurveTag{Type: CurveTypeGamma, Gamma: 2}, &CurveTag{Type: CurveTypeGamma, Gamma: 2}},
+			Matrix:         &MatrixTag{Matrix: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, Offset: &[3]float64{0.1, 0.1, 0.1}},
+		}
+		// A curves then matrix: 0.5^2 + 0.1...
+		result, err := mod.transformChannels([]float64{0.5, 0.5, 0.5})
+		require.NoError(t, err)
+		assert.InDelta(t, 0.35, result[0], 0.0001)
+	})
+	t.Run("Order mBA", func(t *testing.T) {
+		mod := &ModularTag{
+			Signature:      TagModularBA,
+			InputChannels:  3,
+			OutputChannels: 3,
+			ACurves:        CurveSet{&CurveTag{Type: CurveTypeGamma, Gamma: 2}, &CurveTag{Type: CurveTypeGamma, Gamma: 2}, &CurveTag{Type: CurveTypeGamma, Gamma: 2}},
+			Matrix:         &MatrixTag{Matrix: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, Offset: &[3]float64{0.1, 0.1, 0.1}},
+		}
+		// matrix then A curves: (0.5 + 0.1)^2...
+		result, err := mod.transformChannels([]float64{0.5, 0.5, 0.5})
+		require.NoError(t, err)
+		assert.InDelta(t, 0.36, result[0], 0.0001)
+	})
+	t.Run("Stages", func(t *testing.T) {
 		mod := &ModularTag{
+			Signature:      TagModularAB,
 			InputChannels:  3,
 			OutputChannels: 3,
-			Elements: []*Tag{
-				{
-					Name:  "curv",
-					value: &mockTransformer{offset: 1.0},
-				},
-				{
-					Name:  "curv",
-					value: &mockTransformer{offset: 2.0},
-				},
-			},
+			BCurves:        CurveSet{&mockTransformer{offset: 0.1}, &mockTransformer{offset: 0.1}, &mockTransformer{offset: 0.1}},
+			MCurves:        CurveSet{&mockTransformer{offset: 0.2}, &mockTransformer{offset: 0.2}, &mockTransformer{offset: 0.2}},
 		}
 		result, err := mod.transformChannels([]float64{0.1, 0.2, 0.3})
 		require.NoError(t, err)
 		require.Len(t, result, 3)
-		assert.InDelta(t, 0.1+1.0+2.0, result[0], 0.001)
-		assert.InDelta(t, 0.2+1.0+2.0, result[1], 0.001)
-		assert.InDelta(t, 0.3+1.0+2.0, result[2], 0.001)
+		assert.InDelta(t, 0.4, result[0], 0.001)
+		assert.InDelta(t, 0.5, result[1], 0.001)
+		assert.InDelta(t, 0.6, result[2], 0.001)
 	})
 	t.Run("WrongChannelCount", func(t *testing.T) {
 		mod := &ModularTag{InputChannels: 3}
@@ -109,40 +396,18 @@ func TestModularTag_transformChannels(t *testing.T) {
 	t.Run("NoTransformers", func(t *testing.T) {
 		mod := &ModularTag{
 			InputChannels: 3,
-			Elements: []*Tag{
-				{
-					Name: "noop", // value is nil
-				},
-			},
+			BCurves:       CurveSet{},
 		}
 		_, err := mod.transformChannels([]float64{0.1, 0.2, 0.3})
 		assert.ErrorContains(t, err, "no transformable elements")
 	})
-	t.Run("DecodeFails", func(t *testing.T) {
-		mod := &ModularTag{
-			InputChannels: 3,
-			Elements: []*Tag{
-				{
-					Name:  TagCurve,
-					error: errors.New("decode failed"),
-				},
-			},
-		}
-		_, err := mod.transformChannels([]float64{0.1, 0.2, 0.3})
-		assert.ErrorContains(t, err, "failed decoding modular element")
-	})
 	t.Run("TransformerFails", func(t *testing.T) {
 		mod := &ModularTag{
 			InputChannels: 3,
-			Elements: []*Tag{
-				{
-					Name:  TagCurve,
-					value: &mockFailTransformer{},
-				},
-			},
+			CLUT:          &CLUTTag{InputChannels: 1},
 		}
 		_, err := mod.transformChannels([]float64{0.1, 0.2, 0.3})
-		assert.ErrorContains(t, err, "failed processing modular element")
+		assert.ErrorContains(t, err, "failed processing modular CLUT")
 	})
 }
 
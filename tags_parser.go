@@ -1,6 +1,7 @@
 package iccarus
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"slices"
@@ -101,6 +102,8 @@ func parseTags(r io.Reader, table TagHeaderTable, options *ParseOptions) ([]*Tag
 
 var defaultDecoders map[string]func(raw []byte) (any, error)
 
+var defaultEncoders map[string]func(value any) ([]byte, error)
+
 func init() {
 	defaultDecoders = map[string]func(raw []byte) (any, error){
 		TagColorLookupTable:           clutDecoder,
@@ -126,4 +129,36 @@ func init() {
 		"MSBN":                        msbnDecoder,
 		TagZXML:                       zxmlDecoder,
 	}
+	defaultEncoders = map[string]func(value any) ([]byte, error){
+		TagColorLookupTable:           clutEncoder,
+		TagCurve:                      curveEncoder,
+		TagDescription:                descEncoder,
+		TagDictionary:                 rawEncoder,
+		TagGamutBoundaryDescription:   rawEncoder,
+		TagMatrix:                     mtxEncoder,
+		TagModularAB:                  modularEncoder,
+		TagModularBA:                  modularEncoder,
+		TagMeasurement:                measurementEncoder,
+		TagMultiFunctionTable1:        mft1Encoder,
+		TagMultiFunctionTable2:        mft2Encoder,
+		TagMultiLocalizedUnicode:      mlucEncoder,
+		TagParametricCurve:            parametricCurveEncoder,
+		TagProfileSequenceDescription: rawEncoder,
+		TagProfileSequenceIdentifier:  rawEncoder,
+		TagS15Fixed16ArrayType:        sf32Encoder,
+		TagSignatureType:              sigEncoder,
+		TagText:                       textEncoder,
+		TagView:                       viewEncoder,
+		TagXYZ:                        xyzEncoder,
+		"MSBN":                        rawEncoder,
+		TagZXML:                       rawEncoder,
+	}
+}
+
+// newTagBuffer returns a buffer for encoding a tag - initialised with the tag type signature and reserved bytes
+func newTagBuffer(signature TagName) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	buf.Write(signatureBytes(signature))
+	buf.Write([]byte{0, 0, 0, 0}) // reserved
+	return buf
 }
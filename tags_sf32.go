@@ -1,24 +1,36 @@
 package iccarus
 
 import (
-	"encoding/binary"
 	"errors"
-	"math"
+	"fmt"
 )
 
+// sf32Decoder decodes an s15Fixed16ArrayType tag (e.g. the chad tag)
 func sf32Decoder(raw []byte) (any, error) {
 	if len(raw) < 8 {
 		return nil, errors.New("sf32 tag too short")
 	}
 	data := raw[8:] // skip type sig and reserved
 	if len(data)%4 != 0 {
-		return nil, errors.New("sf32 float32 data not aligned")
+		return nil, errors.New("sf32 s15Fixed16 data not aligned")
 	}
 	count := len(data) / 4
-	values := make([]float32, count)
+	values := make([]float64, count)
 	for i := 0; i < count; i++ {
-		bits := binary.BigEndian.Uint32(data[i*4 : i*4+4])
-		values[i] = math.Float32frombits(bits)
+		values[i] = readS15Fixed16BE(data[i*4 : i*4+4])
 	}
 	return values, nil
 }
+
+// sf32Encoder encodes an s15Fixed16ArrayType tag
+func sf32Encoder(value any) ([]byte, error) {
+	values, ok := value.([]float64)
+	if !ok {
+		return nil, fmt.Errorf("sf32 encoder expects []float64 (got %T)", value)
+	}
+	buf := newTagBuffer(TagS15Fixed16ArrayType)
+	for _, v := range values {
+		buf.Write(encodeS15Fixed16BE(v))
+	}
+	return buf.Bytes(), nil
+}
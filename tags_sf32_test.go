@@ -2,10 +2,8 @@ package iccarus
 
 import (
 	"bytes"
-	"encoding/binary"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"math"
 	"testing"
 )
 
@@ -14,15 +12,14 @@ func TestSF32Decoder(t *testing.T) {
 		var buf bytes.Buffer
 		buf.WriteString("sf32")       // tag signature
 		buf.Write([]byte{0, 0, 0, 0}) // reserved
-		// Write 3 float32 values: 1.0, 0.5, -2.0
-		for _, f := range []float32{1.0, 0.5, -2.0} {
-			bits := math.Float32bits(f)
-			_ = binary.Write(&buf, binary.BigEndian, bits)
+		// Write 3 s15Fixed16 values: 1.0, 0.5, -2.0
+		for _, f := range []float64{1.0, 0.5, -2.0} {
+			buf.Write(encodeS15Fixed16BE(f))
 		}
 		val, err := sf32Decoder(buf.Bytes())
 		require.NoError(t, err)
-		require.IsType(t, []float32{}, val)
-		result := val.([]float32)
+		require.IsType(t, []float64{}, val)
+		result := val.([]float64)
 		require.Len(t, result, 3)
 		assert.InDelta(t, 1.0, result[0], 0.0001)
 		assert.InDelta(t, 0.5, result[1], 0.0001)
@@ -34,10 +31,23 @@ func TestSF32Decoder(t *testing.T) {
 		_, err := sf32Decoder(data)
 		assert.ErrorContains(t, err, "sf32 tag too short")
 	})
-	t.Run("MisalignedFloatData", func(t *testing.T) {
-		// 8 bytes header + 5 byte float data = 13 total
+	t.Run("MisalignedData", func(t *testing.T) {
+		// 8 bytes header + 5 byte data = 13 total
 		buf := append([]byte("sf32\x00\x00\x00\x00"), []byte{1, 2, 3, 4, 5}...)
 		_, err := sf32Decoder(buf)
-		assert.ErrorContains(t, err, "sf32 float32 data not aligned")
+		assert.ErrorContains(t, err, "sf32 s15Fixed16 data not aligned")
 	})
 }
+
+func TestSF32Encoder(t *testing.T) {
+	values := []float64{1.04788, 0.02292, -0.0502, 0.02959, 0.99048, -0.01706, -0.00923, 0.01508, 0.75168}
+	raw, err := sf32Encoder(values)
+	require.NoError(t, err)
+	assert.Len(t, raw, 8+36)
+	val, err := sf32Decoder(raw)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, values, val, 1.0/65536)
+
+	_, err = sf32Encoder([]XYZNumber{})
+	assert.ErrorContains(t, err, "sf32 encoder expects []float64")
+}
@@ -5,62 +5,68 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strings"
 	"unicode/utf16"
 )
 
 // DescriptionTag represents a description tag (TagDescription)
 type DescriptionTag struct {
-	ASCII   string
-	Unicode string
-	Script  string
+	ASCII string
+	// UnicodeLanguage is the Unicode language code
+	UnicodeLanguage uint32
+	Unicode         string
+	// ScriptCode is the (Macintosh) ScriptCode code
+	ScriptCode uint16
+	Script     string
 }
 
+// descScriptLength is the length of the (fixed size) ScriptCode field of a textDescriptionType
+const descScriptLength = 67
+
+// descDecoder decodes a textDescriptionType - tolerating tags that end after the ASCII or Unicode parts
 func descDecoder(raw []byte) (any, error) {
 	if len(raw) < 12 {
 		return nil, errors.New("desc tag too short")
 	}
 	asciiLen := int(binary.BigEndian.Uint32(raw[8:12]))
-	if asciiLen < 1 || 12+asciiLen > len(raw) {
+	if asciiLen < 1 || asciiLen > len(raw)-12 {
 		return nil, errors.New("invalid ASCII length in desc tag")
 	}
 	ascii := raw[12 : 12+asciiLen]
 	if i := bytes.IndexByte(ascii, 0); i >= 0 {
 		ascii = ascii[:i]
 	}
+	result := &DescriptionTag{ASCII: string(ascii)}
 
 	offset := 12 + asciiLen
-	if len(raw) < offset+4 {
-		return &DescriptionTag{ASCII: string(ascii)}, nil // ASCII-only, no Unicode
+	if len(raw) < offset+8 {
+		return result, nil // ASCII-only, no Unicode
 	}
-
-	unicodeCount := int(binary.BigEndian.Uint32(raw[offset : offset+4]))
-	offset += 4
-	if len(raw) < offset+(unicodeCount*2) {
+	result.UnicodeLanguage = binary.BigEndian.Uint32(raw[offset : offset+4])
+	unicodeCount := int(binary.BigEndian.Uint32(raw[offset+4 : offset+8]))
+	offset += 8
+	if unicodeCount > (len(raw)-offset)/2 {
 		return nil, errors.New("desc tag truncated: missing UTF-16 data")
 	}
-	unicodeData := raw[offset : offset+(unicodeCount*2)]
+	unicode := decodeUTF16BE(raw[offset : offset+(unicodeCount*2)])
+	result.Unicode = strings.TrimRight(unicode, "\x00")
 	offset += unicodeCount * 2
-	unicode := decodeUTF16BE(unicodeData)
 
-	if len(raw) <= offset {
-		return &DescriptionTag{
-			ASCII:   string(ascii),
-			Unicode: unicode,
-		}, nil
+	if len(raw) < offset+3 {
+		return result, nil // no ScriptCode
 	}
-
-	scriptCount := int(raw[offset])
-	offset++
+	result.ScriptCode = binary.BigEndian.Uint16(raw[offset : offset+2])
+	scriptCount := min(int(raw[offset+2]), descScriptLength)
+	offset += 3
 	if len(raw) < offset+scriptCount {
 		return nil, errors.New("desc tag truncated: missing ScriptCode data")
 	}
-	script := string(raw[offset : offset+scriptCount])
-
-	return &DescriptionTag{
-		ASCII:   string(ascii),
-		Unicode: unicode,
-		Script:  script,
-	}, nil
+	script := raw[offset : offset+scriptCount]
+	if i := bytes.IndexByte(script, 0); i >= 0 {
+		script = script[:i]
+	}
+	result.Script = string(script)
+	return result, nil
 }
 
 func textDecoder(raw []byte) (any, error) {
@@ -131,3 +137,95 @@ func decodeUTF16BE(data []byte) string {
 	}
 	return string(utf16.Decode(codeUnits))
 }
+
+func descEncoder(value any) ([]byte, error) {
+	desc, ok := value.(*DescriptionTag)
+	if !ok {
+		return nil, fmt.Errorf("desc encoder expects *DescriptionTag (got %T)", value)
+	}
+	if len(desc.Script) >= descScriptLength {
+		return nil, errors.New("desc ScriptCode too long")
+	}
+	buf := newTagBuffer(TagDescription)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(desc.ASCII)+1))
+	buf.WriteString(desc.ASCII)
+	buf.WriteByte(0)
+	// Unicode language code, count (including the terminating null) & UTF-16 string...
+	_ = binary.Write(buf, binary.BigEndian, desc.UnicodeLanguage)
+	if desc.Unicode == "" {
+		_ = binary.Write(buf, binary.BigEndian, uint32(0))
+	} else {
+		unicode := append(utf16.Encode([]rune(desc.Unicode)), 0)
+		_ = binary.Write(buf, binary.BigEndian, uint32(len(unicode)))
+		_ = binary.Write(buf, binary.BigEndian, unicode)
+	}
+	// ScriptCode code, count (including the terminating null) & fixed size 67 byte field...
+	_ = binary.Write(buf, binary.BigEndian, desc.ScriptCode)
+	var script [descScriptLength]byte
+	if desc.Script != "" {
+		buf.WriteByte(byte(len(desc.Script) + 1))
+		copy(script[:], desc.Script)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.Write(script[:])
+	return buf.Bytes(), nil
+}
+
+func textEncoder(value any) ([]byte, error) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("text encoder expects string (got %T)", value)
+	}
+	buf := newTagBuffer(TagText)
+	buf.WriteString(text)
+	buf.WriteByte(0)
+	return buf.Bytes(), nil
+}
+
+func sigEncoder(value any) ([]byte, error) {
+	sig, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("sig encoder expects string (got %T)", value)
+	}
+	buf := newTagBuffer(TagSignatureType)
+	buf.Write(signatureBytes(sig))
+	return buf.Bytes(), nil
+}
+
+func mlucEncoder(value any) ([]byte, error) {
+	tag, ok := value.(*MultiLocalizedTag)
+	if !ok {
+		return nil, fmt.Errorf("mluc encoder expects *MultiLocalizedTag (got %T)", value)
+	}
+	const recordSize = 12
+	buf := newTagBuffer(TagMultiLocalizedUnicode)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(tag.Strings)))
+	_ = binary.Write(buf, binary.BigEndian, uint32(recordSize))
+	strOffset := 16 + len(tag.Strings)*recordSize
+	strs := make([][]byte, len(tag.Strings))
+	for i, s := range tag.Strings {
+		if len(s.Language) != 2 || len(s.Country) != 2 {
+			return nil, fmt.Errorf("invalid language/country code in mluc record %d", i)
+		}
+		strs[i] = encodeUTF16BE(s.Value)
+		buf.WriteString(s.Language)
+		buf.WriteString(s.Country)
+		_ = binary.Write(buf, binary.BigEndian, uint32(len(strs[i])))
+		_ = binary.Write(buf, binary.BigEndian, uint32(strOffset))
+		strOffset += len(strs[i])
+	}
+	for _, str := range strs {
+		buf.Write(str)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeUTF16BE(s string) []byte {
+	codeUnits := utf16.Encode([]rune(s))
+	data := make([]byte, len(codeUnits)*2)
+	for i, cu := range codeUnits {
+		binary.BigEndian.PutUint16(data[i*2:], cu)
+	}
+	return data
+}
@@ -5,25 +5,29 @@ import (
 	"encoding/binary"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 	"unicode/utf16"
 )
 
 func TestDescDecoder(t *testing.T) {
 	ascii := []byte("Test description\x00")
-	unicode := []uint16{'T', 'e', 's', 't', 0xD834, 0xDD1E} // "Test𝄞"
-	script := []byte("Latn")
+	unicode := []uint16{'T', 'e', 's', 't', 0xD834, 0xDD1E, 0} // "Test𝄞"
+	script := make([]byte, descScriptLength)
+	copy(script, "Latn")
 
 	var buf bytes.Buffer
 	buf.WriteString("desc")
 	buf.Write([]byte{0, 0, 0, 0}) // reserved
 	_ = binary.Write(&buf, binary.BigEndian, uint32(len(ascii)))
 	buf.Write(ascii)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0x656E0000)) // Unicode language code
 	_ = binary.Write(&buf, binary.BigEndian, uint32(len(unicode)))
 	for _, r := range unicode {
 		_ = binary.Write(&buf, binary.BigEndian, r)
 	}
-	buf.WriteByte(byte(len(script)))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(1)) // ScriptCode code
+	buf.WriteByte(5)
 	buf.Write(script)
 
 	val, err := descDecoder(buf.Bytes())
@@ -31,7 +35,9 @@ func TestDescDecoder(t *testing.T) {
 	require.IsType(t, &DescriptionTag{}, val)
 	desc := val.(*DescriptionTag)
 	assert.Equal(t, "Test description", desc.ASCII)
+	assert.Equal(t, uint32(0x656E0000), desc.UnicodeLanguage)
 	assert.Equal(t, "Test𝄞", desc.Unicode)
+	assert.Equal(t, uint16(1), desc.ScriptCode)
 	assert.Equal(t, "Latn", desc.Script)
 }
 
@@ -62,6 +68,7 @@ func TestDescDecoder_AsciiAndUnicodeOnly(t *testing.T) {
 	buf.Write([]byte{0, 0, 0, 0}) // reserved
 	_ = binary.Write(&buf, binary.BigEndian, uint32(len(ascii)))
 	buf.Write(ascii)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0)) // Unicode language code
 	_ = binary.Write(&buf, binary.BigEndian, uint32(len(unicode)))
 	for _, r := range unicode {
 		_ = binary.Write(&buf, binary.BigEndian, r)
@@ -93,6 +100,7 @@ func TestDescDecoder_Errors(t *testing.T) {
 		ascii := []byte("Short\x00")
 		_ = binary.Write(&buf, binary.BigEndian, uint32(len(ascii)))
 		buf.Write(ascii)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(0)) // Unicode language code
 		_ = binary.Write(&buf, binary.BigEndian, uint32(2)) // claims 2 UTF-16 code units
 		buf.Write([]byte{0x00, 0x41})                       // only one byte pair instead of two
 		_, err := descDecoder(buf.Bytes())
@@ -105,8 +113,10 @@ func TestDescDecoder_Errors(t *testing.T) {
 		ascii := []byte("Just ASCII\x00")
 		_ = binary.Write(&buf, binary.BigEndian, uint32(len(ascii)))
 		buf.Write(ascii)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(0)) // Unicode language code
 		_ = binary.Write(&buf, binary.BigEndian, uint32(1)) // one code unit
 		buf.Write([]byte{0x00, 0x61})                       // 'a'
+		buf.Write([]byte{0, 0})                             // ScriptCode code
 		buf.WriteByte(4)                                    // says 4 bytes of script
 		buf.Write([]byte("La"))                             // only 2 bytes provided
 		_, err := descDecoder(buf.Bytes())
@@ -257,3 +267,97 @@ func TestDecodeUTF16BE(t *testing.T) {
 		assert.Equal(t, "Test𝄞", str)
 	})
 }
+
+func TestDescEncoder(t *testing.T) {
+	testCases := []*DescriptionTag{
+		{ASCII: "Test description", UnicodeLanguage: 0x656E0000, Unicode: "Test𝄞", ScriptCode: 1, Script: "Latn"},
+		{ASCII: "Test description"},
+		{Script: strings.Repeat("x", descScriptLength-1)},
+		{},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ASCII, func(t *testing.T) {
+			raw, err := descEncoder(tc)
+			require.NoError(t, err)
+			val, err := descDecoder(raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc, val)
+		})
+	}
+	t.Run("Layout", func(t *testing.T) {
+		raw, err := descEncoder(&DescriptionTag{ASCII: "sRGB", Unicode: "sRGB"})
+		require.NoError(t, err)
+		// header (8), ASCII count (4) & "sRGB\x00", language (4), Unicode count (4) & "sRGB\x00" (UTF-16), ScriptCode code (2), count (1) & 67 bytes
+		require.Len(t, raw, 12+5+8+10+3+descScriptLength)
+		assert.Equal(t, []byte("sRGB\x00"), raw[12:17])
+		assert.Equal(t, uint32(5), binary.BigEndian.Uint32(raw[21:25]))
+		assert.Equal(t, []byte{0, 's', 0, 'R', 0, 'G', 0, 'B', 0, 0}, raw[25:35])
+		assert.Equal(t, make([]byte, 3+descScriptLength), raw[35:])
+	})
+	t.Run("Real Profile", func(t *testing.T) {
+		p := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+		val, err := p.TagValue(TagHeaderDescription)
+		require.NoError(t, err)
+		require.IsType(t, &DescriptionTag{}, val)
+		raw, err := descEncoder(val)
+		require.NoError(t, err)
+		decoded, err := descDecoder(raw)
+		require.NoError(t, err)
+		assert.Equal(t, val, decoded)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := descEncoder("desc")
+		assert.ErrorContains(t, err, "desc encoder expects *DescriptionTag (got string)")
+		_, err = descEncoder(&DescriptionTag{Script: strings.Repeat("x", descScriptLength)})
+		assert.ErrorContains(t, err, "desc ScriptCode too long")
+	})
+}
+
+func TestTextEncoder(t *testing.T) {
+	raw, err := textEncoder("Copyright foo")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("text\x00\x00\x00\x00Copyright foo\x00"), raw)
+	val, err := textDecoder(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Copyright foo", val)
+
+	_, err = textEncoder(123)
+	assert.ErrorContains(t, err, "text encoder expects string (got int)")
+}
+
+func TestSigEncoder(t *testing.T) {
+	raw, err := sigEncoder("CRT")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sig \x00\x00\x00\x00CRT "), raw)
+	val, err := sigDecoder(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "CRT", val)
+
+	_, err = sigEncoder(123)
+	assert.ErrorContains(t, err, "sig encoder expects string (got int)")
+}
+
+func TestMLUCEncoder(t *testing.T) {
+	tag := &MultiLocalizedTag{Strings: []LocalizedString{
+		{Language: "en", Country: "US", Value: "Hello!"},
+		{Language: "de", Country: "DE", Value: "Grüß Gott 𝄞"},
+		{Language: "fr", Country: "FR", Value: ""},
+	}}
+	raw, err := mlucEncoder(tag)
+	require.NoError(t, err)
+	val, err := mlucDecoder(raw)
+	require.NoError(t, err)
+	assert.Equal(t, tag, val)
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := mlucEncoder(&DescriptionTag{})
+		assert.ErrorContains(t, err, "mluc encoder expects *MultiLocalizedTag")
+		_, err = mlucEncoder(&MultiLocalizedTag{Strings: []LocalizedString{{Language: "eng", Country: "US"}}})
+		assert.ErrorContains(t, err, "invalid language/country code in mluc record 0")
+	})
+}
+
+func TestEncodeUTF16BE(t *testing.T) {
+	assert.Equal(t, []byte{0x00, 'G', 0x00, 'o', 0x00, '!'}, encodeUTF16BE("Go!"))
+	assert.Equal(t, "Test𝄞", decodeUTF16BE(encodeUTF16BE("Test𝄞")))
+}
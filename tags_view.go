@@ -3,6 +3,7 @@ package iccarus
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 // ViewingConditionsTag represents a viewing conditions tag (TagView)
@@ -30,3 +31,15 @@ func viewDecoder(raw []byte) (any, error) {
 		IlluminantType: binary.BigEndian.Uint32(raw[32:36]),
 	}, nil
 }
+
+func viewEncoder(value any) ([]byte, error) {
+	view, ok := value.(*ViewingConditionsTag)
+	if !ok {
+		return nil, fmt.Errorf("view encoder expects *ViewingConditionsTag (got %T)", value)
+	}
+	buf := newTagBuffer(TagView)
+	buf.Write(encodeXYZNumber(view.Illuminant))
+	buf.Write(encodeXYZNumber(view.Surround))
+	_ = binary.Write(buf, binary.BigEndian, view.IlluminantType)
+	return buf.Bytes(), nil
+}
@@ -40,3 +40,24 @@ func TestViewDecoder(t *testing.T) {
 		assert.ErrorContains(t, err, "view tag too short")
 	})
 }
+
+func TestViewEncoder(t *testing.T) {
+	view := &ViewingConditionsTag{
+		Illuminant:     XYZNumber{X: 19.6445, Y: 20.3718, Z: 16.8089},
+		Surround:       XYZNumber{X: 3.92889, Y: 4.07439, Z: 3.36179},
+		IlluminantType: 1,
+	}
+	raw, err := viewEncoder(view)
+	require.NoError(t, err)
+	assert.Len(t, raw, 36)
+	val, err := viewDecoder(raw)
+	require.NoError(t, err)
+	decoded := val.(*ViewingConditionsTag)
+	assert.InDelta(t, view.Illuminant.X, decoded.Illuminant.X, 1.0/65536)
+	assert.InDelta(t, view.Illuminant.Z, decoded.Illuminant.Z, 1.0/65536)
+	assert.InDelta(t, view.Surround.Y, decoded.Surround.Y, 1.0/65536)
+	assert.Equal(t, uint32(1), decoded.IlluminantType)
+
+	_, err = viewEncoder(ViewingConditionsTag{})
+	assert.ErrorContains(t, err, "view encoder expects *ViewingConditionsTag")
+}
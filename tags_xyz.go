@@ -2,6 +2,8 @@ package iccarus
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 )
 
 // XYZNumber represents an XYZ tag (TagXYZ)
@@ -29,3 +31,27 @@ func xyzDecoder(raw []byte) (any, error) {
 	}
 	return result, nil
 }
+
+func xyzEncoder(value any) ([]byte, error) {
+	var values []XYZNumber
+	switch v := value.(type) {
+	case []XYZNumber:
+		values = v
+	case XYZNumber:
+		values = []XYZNumber{v}
+	default:
+		return nil, fmt.Errorf("XYZ encoder expects []XYZNumber (got %T)", value)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("XYZ tag requires at least one value")
+	}
+	buf := newTagBuffer(TagXYZ)
+	for _, xyz := range values {
+		buf.Write(encodeXYZNumber(xyz))
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXYZNumber(xyz XYZNumber) []byte {
+	return slices.Concat(encodeS15Fixed16BE(xyz.X), encodeS15Fixed16BE(xyz.Y), encodeS15Fixed16BE(xyz.Z))
+}
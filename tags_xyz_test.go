@@ -40,3 +40,32 @@ func TestXYZDecoder(t *testing.T) {
 		assert.ErrorContains(t, err, "invalid length")
 	})
 }
+
+func TestXYZEncoder(t *testing.T) {
+	t.Run("Slice", func(t *testing.T) {
+		values := []XYZNumber{{X: 0.9642, Y: 1.0, Z: 0.8249}, {X: 0.1, Y: -0.2, Z: 0.3}}
+		raw, err := xyzEncoder(values)
+		require.NoError(t, err)
+		assert.Len(t, raw, 8+24)
+		val, err := xyzDecoder(raw)
+		require.NoError(t, err)
+		xyz := val.([]XYZNumber)
+		require.Len(t, xyz, 2)
+		for i := range values {
+			assert.InDelta(t, values[i].X, xyz[i].X, 1.0/65536)
+			assert.InDelta(t, values[i].Y, xyz[i].Y, 1.0/65536)
+			assert.InDelta(t, values[i].Z, xyz[i].Z, 1.0/65536)
+		}
+	})
+	t.Run("Single", func(t *testing.T) {
+		raw, err := xyzEncoder(D50)
+		require.NoError(t, err)
+		assert.Len(t, raw, 20)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := xyzEncoder([]XYZNumber{})
+		assert.ErrorContains(t, err, "XYZ tag requires at least one value")
+		_, err = xyzEncoder([]float64{})
+		assert.ErrorContains(t, err, "XYZ encoder expects []XYZNumber (got []float64)")
+	})
+}
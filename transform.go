@@ -0,0 +1,54 @@
+package iccarus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Transform is a profile to profile color transform
+//
+// device values of the source profile are converted to the PCS (using the source A2Bx or matrix/TRC) and then
+// to device values of the destination profile (using the destination B2Ax or inverse matrix/TRC) - differences
+// in PCS (Lab or XYZ) and PCS encoding between the two profiles are handled by connecting in CIE XYZ
+type Transform struct {
+	Source      *Profile
+	Destination *Profile
+	Intent      RenderingIntent
+}
+
+var _ ChannelTransformer = (*Transform)(nil)
+
+// NewTransform creates a new Transform from the source profile to the destination profile using the rendering intent
+//
+// returns an error if either profile does not support the conversion
+func NewTransform(src *Profile, dst *Profile, intent RenderingIntent) (*Transform, error) {
+	if src == nil {
+		return nil, errors.New("source profile is nil")
+	} else if dst == nil {
+		return nil, errors.New("destination profile is nil")
+	}
+	if _, err := src.findA2B(intent); err != nil {
+		return nil, fmt.Errorf("source profile: %w", err)
+	}
+	if _, err := dst.findB2A(intent); err != nil {
+		return nil, fmt.Errorf("destination profile: %w", err)
+	}
+	return &Transform{
+		Source:      src,
+		Destination: dst,
+		Intent:      intent,
+	}, nil
+}
+
+// Transform converts source profile device values to destination profile device values
+func (t *Transform) Transform(inputs ...float64) ([]float64, error) {
+	xyz, err := t.Source.toXYZ(t.Intent, inputs...)
+	if err != nil {
+		return nil, fmt.Errorf("source profile: %w", err)
+	}
+	result, err := t.Destination.fromXYZ(t.Intent, xyz[:])
+	if err != nil {
+		return nil, fmt.Errorf("destination profile: %w", err)
+	}
+	return result, nil
+}
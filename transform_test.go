@@ -0,0 +1,114 @@
+package iccarus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewTransform(t *testing.T) {
+	p3 := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	t.Run("Success", func(t *testing.T) {
+		tr, err := NewTransform(p3, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		assert.Same(t, p3, tr.Source)
+		assert.Same(t, p3, tr.Destination)
+		assert.Equal(t, IntentRelativeColorimetric, tr.Intent)
+	})
+	t.Run("Nil Source", func(t *testing.T) {
+		_, err := NewTransform(nil, p3, IntentPerceptual)
+		assert.ErrorContains(t, err, "source profile is nil")
+	})
+	t.Run("Nil Destination", func(t *testing.T) {
+		_, err := NewTransform(p3, nil, IntentPerceptual)
+		assert.ErrorContains(t, err, "destination profile is nil")
+	})
+	t.Run("Source Unsupported", func(t *testing.T) {
+		_, err := NewTransform(&Profile{}, p3, IntentPerceptual)
+		assert.ErrorContains(t, err, "source profile: A2B0 tag not found")
+	})
+	t.Run("Destination Unsupported", func(t *testing.T) {
+		_, err := NewTransform(p3, &Profile{}, IntentSaturation)
+		assert.ErrorContains(t, err, "destination profile: B2A2 tag not found")
+	})
+	t.Run("Unknown Intent", func(t *testing.T) {
+		_, err := NewTransform(p3, p3, RenderingIntent(5))
+		assert.ErrorContains(t, err, "unknown rendering intent 5")
+	})
+}
+
+func TestTransform_Transform(t *testing.T) {
+	p3 := testProfile(t, "default/display-p3-v4-with-v2-desc.icc")
+	cmyk := testProfile(t, "default/ISOcoated_v2_300_eci.icc")
+	t.Run("Same Profile", func(t *testing.T) {
+		tr, err := NewTransform(p3, p3, IntentPerceptual)
+		require.NoError(t, err)
+		for _, rgb := range [][]float64{{0.2, 0.4, 0.6}, {1, 1, 1}, {0, 0, 0}} {
+			out, err := tr.Transform(rgb...)
+			require.NoError(t, err)
+			require.Len(t, out, 3)
+			for i := range rgb {
+				assert.InDelta(t, rgb[i], out[i], 0.0001)
+			}
+		}
+	})
+	t.Run("XYZ PCS to Lab PCS", func(t *testing.T) {
+		tr, err := NewTransform(p3, cmyk, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		// white maps to paper white (no ink)...
+		out, err := tr.Transform(1, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, out, 4)
+		for _, v := range out {
+			assert.InDelta(t, 0.0, v, 0.001)
+		}
+		// red maps to mostly magenta and yellow...
+		out, err = tr.Transform(1, 0, 0)
+		require.NoError(t, err)
+		assert.Less(t, out[0], 0.1)
+		assert.Greater(t, out[1], 0.7)
+		assert.Greater(t, out[2], 0.9)
+	})
+	t.Run("Lab PCS to XYZ PCS", func(t *testing.T) {
+		tr, err := NewTransform(cmyk, p3, IntentRelativeColorimetric)
+		require.NoError(t, err)
+		// paper white maps to white...
+		out, err := tr.Transform(0, 0, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, out, 3)
+		for _, v := range out {
+			assert.InDelta(t, 1.0, v, 0.001)
+		}
+		// cyan ink is mostly green and blue...
+		out, err = tr.Transform(1, 0, 0, 0)
+		require.NoError(t, err)
+		assert.Less(t, out[0], out[1])
+		assert.Less(t, out[1], out[2])
+	})
+	t.Run("Absolute Colorimetric", func(t *testing.T) {
+		tr, err := NewTransform(cmyk, p3, IntentAbsoluteColorimetric)
+		require.NoError(t, err)
+		// paper white is darker than display white...
+		out, err := tr.Transform(0, 0, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, out, 3)
+		for _, v := range out {
+			assert.Less(t, v, 0.99)
+		}
+	})
+	t.Run("Source Fails", func(t *testing.T) {
+		tr, err := NewTransform(p3, cmyk, IntentPerceptual)
+		require.NoError(t, err)
+		_, err = tr.Transform(1, 1)
+		assert.ErrorContains(t, err, "source profile: matrix/TRC expects 3 input channels, got 2")
+	})
+	t.Run("Destination Fails", func(t *testing.T) {
+		dst := &Profile{
+			b2a: [3]FromCIEXYZ{&ModularTag{InputChannels: 4}},
+		}
+		tr, err := NewTransform(p3, dst, IntentPerceptual)
+		require.NoError(t, err)
+		_, err = tr.Transform(1, 1, 1)
+		assert.ErrorContains(t, err, "destination profile: expected 4 input channels, got 3")
+	})
+}